@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/cobra"
+
+	"price-feeder/oracle/consumer"
+	"price-feeder/oracle/mirror"
+	"price-feeder/oracle/voting"
+)
+
+const (
+	flagMirrorNode    = "mirror-node"
+	flagTopic         = "topic"
+	flagFromSequence  = "from-sequence"
+	flagVotePeriod    = "vote-period"
+	flagPower         = "power"
+	flagVoteThreshold = "vote-threshold"
+)
+
+func getConsumeCmd() *cobra.Command {
+	consumeCmd := &cobra.Command{
+		Use:   "consume",
+		Short: "Tally the prevotes and votes of a topic into reference prices",
+		Long: `Reads all messages of a topic from a Hedera mirror node, verifies
+the envelope signature of every message against the key of the feeder
+account, checks every vote against the hash of the feeder's prevote and
+computes the weighted median of every denom per round, as x/oracle does
+on chain.
+The results are printed as one JSON object per round.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger, err := getLogger(cmd)
+			if err != nil {
+				return err
+			}
+
+			mirrorURL, err := cmd.Flags().GetString(flagMirrorNode)
+			if err != nil {
+				return err
+			}
+
+			topic, err := cmd.Flags().GetString(flagTopic)
+			if err != nil {
+				return err
+			}
+			if topic == "" {
+				return fmt.Errorf("no topic provided")
+			}
+
+			fromSequence, err := cmd.Flags().GetUint64(flagFromSequence)
+			if err != nil {
+				return err
+			}
+
+			powers, err := cmd.Flags().GetStringToInt64(flagPower)
+			if err != nil {
+				return err
+			}
+
+			thresholdStr, err := cmd.Flags().GetString(flagVoteThreshold)
+			if err != nil {
+				return err
+			}
+			threshold, err := sdk.NewDecFromStr(thresholdStr)
+			if err != nil {
+				return fmt.Errorf("failed to parse vote threshold: %w", err)
+			}
+
+			// without a vote period the consensus time of messages is not
+			// checked against their round
+			rounds := voting.VoteRounds{}
+			votePeriodStr, err := cmd.Flags().GetString(flagVotePeriod)
+			if err != nil {
+				return err
+			}
+			if votePeriodStr != "" {
+				votePeriod, err := time.ParseDuration(votePeriodStr)
+				if err != nil {
+					return fmt.Errorf("failed to parse vote period: %w", err)
+				}
+				rounds, err = voting.NewVoteRounds(votePeriod, 0, 0)
+				if err != nil {
+					return err
+				}
+			}
+
+			mirrorClient, err := mirror.NewClient(mirrorURL, &http.Client{Timeout: 30 * time.Second})
+			if err != nil {
+				return err
+			}
+
+			messages, err := mirrorClient.GetTopicMessages(cmd.Context(), topic, fromSequence)
+			if err != nil {
+				return fmt.Errorf("failed to fetch topic messages: %w", err)
+			}
+
+			keys := consumer.NewMirrorKeys(cmd.Context(), mirrorClient)
+			aggregator := consumer.NewAggregator(logger, keys, rounds, powers, threshold)
+			for _, msg := range messages {
+				if err := aggregator.Add(msg); err != nil {
+					logger.Warn().
+						Err(err).
+						Uint64("sequence_number", msg.SequenceNumber).
+						Msg("skipping topic message")
+				}
+			}
+
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			for _, round := range aggregator.Rounds() {
+				result, err := aggregator.Tally(round)
+				if err != nil {
+					return err
+				}
+				if err := encoder.Encode(result); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	consumeCmd.Flags().String(flagMirrorNode, "https://testnet.mirrornode.hedera.com", "Base URL of the mirror node REST API")
+	consumeCmd.Flags().String(flagTopic, "", "ID of the topic to consume, e.g. 0.0.1234")
+	consumeCmd.Flags().Uint64(flagFromSequence, 1, "Sequence number of the first message to consume")
+	consumeCmd.Flags().String(flagVotePeriod, "", "Vote period of the feeders; if set, messages outside of their round are rejected")
+	consumeCmd.Flags().StringToInt64(flagPower, map[string]int64{}, "Voting power per feeder account, e.g. 0.0.1234=10; if unset every feeder has a power of one")
+	consumeCmd.Flags().String(flagVoteThreshold, "0.5", "Share of the total power a denom needs to be tallied")
+
+	return consumeCmd
+}
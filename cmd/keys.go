@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	input "github.com/cosmos/cosmos-sdk/client/input"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/spf13/cobra"
+
+	"price-feeder/config"
+	"price-feeder/oracle/client"
+)
+
+const (
+	flagKeyType       = "type"
+	flagKeystore      = "keystore"
+	flagMnemonicIndex = "index"
+	flagKeyFile       = "key-file"
+	flagKeyEnv        = "key-env"
+)
+
+type keyInfo struct {
+	Account      string `json:"account,omitempty"`
+	OperatorID   string `json:"operator_id,omitempty"`
+	Type         string `json:"type"`
+	PublicKey    string `json:"public_key"`
+	PublicKeyRaw string `json:"public_key_raw"`
+	PrivateKey   string `json:"private_key,omitempty"`
+	Mnemonic     string `json:"mnemonic,omitempty"`
+	Keystore     string `json:"keystore,omitempty"`
+}
+
+func newKeyInfo(key hedera.PrivateKey) keyInfo {
+	return keyInfo{
+		Type:         client.KeyType(key),
+		PublicKey:    key.PublicKey().StringDer(),
+		PublicKeyRaw: key.PublicKey().StringRaw(),
+	}
+}
+
+func getKeysCmd() *cobra.Command {
+	keysCmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage operator keys",
+		Long: `Create, import and show operator keys. Keys are stored in encrypted
+keystore files, the password is read from the PRICE_FEEDER_PASS environment
+variable or prompted for.`,
+	}
+
+	keysCmd.AddCommand(
+		getKeysCreateCmd(),
+		getKeysImportCmd(),
+		getKeysShowCmd(),
+	)
+
+	return keysCmd
+}
+
+func getKeysCreateCmd() *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a new key from a random mnemonic",
+		Long: `Creates a new 24-word mnemonic and derives the key of the given type
+and index. The mnemonic is printed as it is the only way to recover the key.
+If a keystore path is given, the key is written to an encrypted keystore,
+otherwise the DER encoded private key is printed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyType, index, keystorePath, err := getKeyFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			mnemonic, err := hedera.GenerateMnemonic24()
+			if err != nil {
+				return err
+			}
+
+			key, err := client.DeriveKey(mnemonic.String(), keyType, index)
+			if err != nil {
+				return err
+			}
+
+			info := newKeyInfo(key)
+			info.Mnemonic = mnemonic.String()
+			if keystorePath == "" {
+				info.PrivateKey = key.StringDer()
+			} else {
+				if err := writeKeystore(keystorePath, key); err != nil {
+					return err
+				}
+				info.Keystore = keystorePath
+			}
+
+			return printKeyInfo(cmd, info)
+		},
+	}
+
+	addKeyFlags(createCmd)
+
+	return createCmd
+}
+
+func getKeysImportCmd() *cobra.Command {
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a private key or mnemonic into an encrypted keystore",
+		Long: `Imports a hex encoded raw or DER private key, or derives the key of
+the given type and index from a mnemonic, and writes it to an encrypted
+keystore. The key is read from the given file or environment variable, or
+from stdin otherwise, so it doesn't end up in the shell history.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// don't echo the arguments, they are likely a key
+			if len(args) > 0 {
+				return fmt.Errorf("the key must be given on stdin or with --%s or --%s", flagKeyFile, flagKeyEnv)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			keyType, index, keystorePath, err := getKeyFlags(cmd)
+			if err != nil {
+				return err
+			}
+			if keystorePath == "" {
+				return fmt.Errorf("no keystore provided")
+			}
+
+			key, err := readImportKey(cmd, keyType, index)
+			if err != nil {
+				return err
+			}
+
+			if err := writeKeystore(keystorePath, key); err != nil {
+				return err
+			}
+
+			info := newKeyInfo(key)
+			info.Keystore = keystorePath
+			return printKeyInfo(cmd, info)
+		},
+	}
+
+	addKeyFlags(importCmd)
+	importCmd.Flags().String(flagKeyFile, "", "Path of a PEM file or a file containing the key or mnemonic to import")
+	importCmd.Flags().String(flagKeyEnv, "", "Name of an environment variable containing the key or mnemonic to import")
+
+	return importCmd
+}
+
+// readImportKey reads the key to import from the file or environment variable
+// given by the flags, or prompts for it on stdin.
+func readImportKey(cmd *cobra.Command, keyType string, index uint32) (hedera.PrivateKey, error) {
+	keyFile, err := cmd.Flags().GetString(flagKeyFile)
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+	keyEnv, err := cmd.Flags().GetString(flagKeyEnv)
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+	if keyFile != "" && keyEnv != "" {
+		return hedera.PrivateKey{}, fmt.Errorf("only one of --%s and --%s may be set", flagKeyFile, flagKeyEnv)
+	}
+
+	if keyFile != "" || keyEnv != "" {
+		return client.LoadPrivateKey(client.KeySource{
+			File:          keyFile,
+			Env:           keyEnv,
+			Type:          keyType,
+			MnemonicIndex: index,
+		}, getKeyringPassword)
+	}
+
+	// the key is not echoed, like a password
+	value, err := input.GetPassword("Enter private key or mnemonic: ", stdin)
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+	return client.ParsePrivateKey(strings.TrimSpace(value), keyType, index)
+}
+
+func getKeysShowCmd() *cobra.Command {
+	showCmd := &cobra.Command{
+		Use:   "show [config-file]",
+		Short: "Show the public keys of the configured operator keys",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.ParseConfig(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, account := range cfg.Account {
+				key, err := client.LoadPrivateKey(account.ToKeySource(), getKeyringPassword)
+				if err != nil {
+					return fmt.Errorf("failed to load operator key of %s: %w", account.Name, err)
+				}
+
+				info := newKeyInfo(key)
+				info.Account = account.Name
+				info.OperatorID = account.OperatorID
+				info.Keystore = account.OperatorKeystore
+				if err := printKeyInfo(cmd, info); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	return showCmd
+}
+
+func addKeyFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagKeyType, client.KeyTypeEd25519, "Key type (ed25519|ecdsa)")
+	cmd.Flags().Uint32(flagMnemonicIndex, 0, "Derivation index of the key if derived from a mnemonic")
+	cmd.Flags().String(flagKeystore, "", "Path of the encrypted keystore to write")
+}
+
+func getKeyFlags(cmd *cobra.Command) (keyType string, index uint32, keystorePath string, err error) {
+	if keyType, err = cmd.Flags().GetString(flagKeyType); err != nil {
+		return "", 0, "", err
+	}
+	if _, ok := client.SupportedKeyTypes[keyType]; !ok {
+		return "", 0, "", fmt.Errorf("unsupported key type: %s", keyType)
+	}
+	if index, err = cmd.Flags().GetUint32(flagMnemonicIndex); err != nil {
+		return "", 0, "", err
+	}
+	if keystorePath, err = cmd.Flags().GetString(flagKeystore); err != nil {
+		return "", 0, "", err
+	}
+	return keyType, index, keystorePath, nil
+}
+
+func writeKeystore(path string, key hedera.PrivateKey) error {
+	pass, err := getKeyringPassword()
+	if err != nil {
+		return err
+	}
+	if pass == "" {
+		return fmt.Errorf("empty keystore password")
+	}
+	return client.WriteKeystore(path, key, pass)
+}
+
+func printKeyInfo(cmd *cobra.Command, info keyInfo) error {
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(info)
+}
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"context"
 	"fmt"
-	"github.com/hashgraph/hedera-sdk-go/v2"
 	"io"
 	"net/http"
 	"os"
@@ -14,6 +13,7 @@ import (
 	"time"
 
 	input "github.com/cosmos/cosmos-sdk/client/input"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/gorilla/mux"
@@ -23,15 +23,18 @@ import (
 
 	"price-feeder/config"
 	"price-feeder/oracle"
+	"price-feeder/oracle/budget"
 	"price-feeder/oracle/client"
-	"price-feeder/oracle/derivative"
+	"price-feeder/oracle/envelope"
 	"price-feeder/oracle/history"
-	"price-feeder/oracle/provider"
-	"price-feeder/oracle/types"
+	"price-feeder/oracle/mirror"
+	"price-feeder/oracle/publisher"
+	"price-feeder/oracle/push"
+	"price-feeder/oracle/tracker"
+	"price-feeder/oracle/voting"
 	v1 "price-feeder/router/v1"
 
 	"github.com/cosmos/cosmos-sdk/telemetry"
-	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 const (
@@ -44,6 +47,10 @@ const (
 	envVariablePass = "PRICE_FEEDER_PASS"
 )
 
+// stdin is shared by all prompts, so that lines piped into the price feeder
+// are not lost in the buffer of another reader.
+var stdin = bufio.NewReader(os.Stdin)
+
 var rootCmd = &cobra.Command{
 	Use:   "price-feeder [config-file]",
 	Args:  cobra.ExactArgs(1),
@@ -56,7 +63,6 @@ an API. Secondly, the price-feeder consumes this data and periodically submits
 vote and prevote messages following the oracle voting procedure.`,
 	RunE: priceFeederCmdHandler,
 }
-var hashgraphClient *hedera.Client
 
 func init() {
 	rootCmd.PersistentFlags().String(flagLogLevel, zerolog.InfoLevel.String(), "logging level")
@@ -64,37 +70,34 @@ func init() {
 
 	rootCmd.AddCommand(getVersionCmd())
 	rootCmd.AddCommand(getBacktestCmd())
+	rootCmd.AddCommand(getConsumeCmd())
+	rootCmd.AddCommand(getKeysCmd())
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	hashgraphClient, err := hedera.ClientForName(string(hedera.NetworkNameTestnet))
-	hashgraphClient.SetTransportSecurity(false)
-	if err != nil {
-		println(err.Error(), ": error creating client")
-		return
-	}
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
-func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
+// getLogger creates a logger from the log level and format flags.
+func getLogger(cmd *cobra.Command) (zerolog.Logger, error) {
 	logLvlStr, err := cmd.Flags().GetString(flagLogLevel)
 	if err != nil {
-		return err
+		return zerolog.Logger{}, err
 	}
 
 	logLvl, err := zerolog.ParseLevel(logLvlStr)
 	if err != nil {
-		return err
+		return zerolog.Logger{}, err
 	}
 
 	logFormatStr, err := cmd.Flags().GetString(flagLogFormat)
 	if err != nil {
-		return err
+		return zerolog.Logger{}, err
 	}
 
 	var logWriter io.Writer
@@ -109,11 +112,18 @@ func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
 		}
 
 	default:
-		return fmt.Errorf("invalid logging format: %s", logFormatStr)
+		return zerolog.Logger{}, fmt.Errorf("invalid logging format: %s", logFormatStr)
 	}
 
 	zerolog.TimeFieldFormat = time.StampMilli
-	logger := zerolog.New(logWriter).Level(logLvl).With().Timestamp().Logger()
+	return zerolog.New(logWriter).Level(logLvl).With().Timestamp().Logger(), nil
+}
+
+func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
+	logger, err := getLogger(cmd)
+	if err != nil {
+		return err
+	}
 
 	cfg, err := config.ParseConfig(args[0])
 	if err != nil {
@@ -125,28 +135,44 @@ func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
 
 	// listen for and trap any OS signal to gracefully shutdown and exit
 	trapSignal(cancel, logger)
-	votePeriod, err := time.ParseDuration(cfg.VotePeriod)
-	if err != nil {
-		return fmt.Errorf("failed to parse vote period: %w", err)
-	}
 
-	heightPollInterval, err := time.ParseDuration(cfg.HeightPollInterval)
+	history, err := history.NewPriceHistory(cfg.HistoryDb, logger)
 	if err != nil {
-		return fmt.Errorf("failed to parse height poll interval: %w", err)
+		return fmt.Errorf("failed to init price history db: %v", err)
 	}
+	// the history is shared by the oracle, the outboxes and the server, so it
+	// is closed once all of them stopped
+	defer func() {
+		if err := history.Close(); err != nil {
+			logger.Error().Err(err).Msg("failed to close price history db")
+		}
+	}()
+	// every account is an independent publish target sharing the prices
+	targets := make([]*oracle.Target, 0, len(cfg.Account))
+	trackers := tracker.Group{}
+	for _, account := range cfg.Account {
+		target, outbox, balanceMonitor, submissionTracker, err := newTarget(ctx, logger, cfg, account, &history)
+		if err != nil {
+			return fmt.Errorf("failed to init account %s: %w", account.Name, err)
+		}
+		targets = append(targets, target)
+		trackers[account.Name] = submissionTracker
 
-	oracleClient, err := client.NewOracleClient(
-		ctx,
-		logger,
-		cfg.Account.NetworkName,
-		cfg.Account.OperatorID,
-		cfg.Account.OperatorSeed,
-		cfg.Account.TopicID,
-		votePeriod,
-		heightPollInterval,
-	)
-	if err != nil {
-		return err
+		g.Go(func() error {
+			// retry the messages whose delivery failed
+			return outbox.Start(ctx)
+		})
+		if submissionTracker != nil {
+			g.Go(func() error {
+				return submissionTracker.Start(ctx)
+			})
+		}
+		if cfg.EnableVoter && balanceMonitor != nil {
+			g.Go(func() error {
+				// check the operator balance paying for the votes
+				return balanceMonitor.Start(ctx)
+			})
+		}
 	}
 
 	providerTimeout, err := time.ParseDuration(cfg.ProviderTimeout)
@@ -154,88 +180,50 @@ func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to parse provider timeout: %w", err)
 	}
 
-	deviations := make(map[string]sdk.Dec, len(cfg.Deviations))
-	for _, deviation := range cfg.Deviations {
-		threshold, err := sdk.NewDecFromStr(deviation.Threshold)
-		if err != nil {
-			return err
-		}
-		deviations[deviation.Base] = threshold
-	}
-
-	providerMinOverrides := make(map[string]int, len(cfg.ProviderMinOverrides))
-	for _, override := range cfg.ProviderMinOverrides {
-		for _, denom := range override.Denoms {
-			_, found := providerMinOverrides[denom]
-			if found {
-				logger.Warn().
-					Str("denom", denom).
-					Msg("provider_min_overrides already set")
-			}
-			providerMinOverrides[denom] = int(override.Providers)
-		}
-	}
-
-	endpoints := make(map[provider.Name]provider.Endpoint, len(cfg.ProviderEndpoints))
-	for _, e := range cfg.ProviderEndpoints {
-		endpoint, err := e.ToEndpoint()
-		if err != nil {
-			return err
-		}
-		endpoints[endpoint.Name] = endpoint
+	shutdownGracePeriod, err := time.ParseDuration(cfg.ShutdownGracePeriod)
+	if err != nil {
+		return fmt.Errorf("failed to parse shutdown grace period: %w", err)
 	}
 
-	history, err := history.NewPriceHistory(cfg.HistoryDb, logger)
+	auditRetention, err := time.ParseDuration(cfg.AuditRetention)
 	if err != nil {
-		return fmt.Errorf("failed to init price history db: %v", err)
+		return fmt.Errorf("failed to parse audit retention: %w", err)
 	}
 
-	derivativePairs := map[string][]types.CurrencyPair{}
-	derivativePeriods := map[string]map[string]time.Duration{}
-	derivativeSymbols := map[string]struct{}{}
-	providerPairs := []config.CurrencyPair{}
-	for _, pair := range cfg.CurrencyPairs {
-		if pair.Derivative != "" {
-			period, err := time.ParseDuration(pair.DerivativePeriod)
-			if err != nil {
-				return err
-			}
-			pairs, ok := derivativePairs[pair.Derivative]
-			if !ok {
-				pairs = []types.CurrencyPair{}
-				derivativePeriods[pair.Derivative] = map[string]time.Duration{}
-			}
-			currencyPair := types.CurrencyPair{Base: pair.Base, Quote: pair.Quote}
-			derivativePairs[pair.Derivative] = append(pairs, currencyPair)
-			derivativePeriods[pair.Derivative][currencyPair.String()] = period
-			derivativeSymbols[pair.Base+pair.Quote] = struct{}{}
-		}
-		providerPairs = append(providerPairs, pair)
+	var reputation *oracle.Reputation
+	if cfg.Reputation.Enabled {
+		reputation = oracle.NewReputation(
+			sdk.MustNewDecFromStr(cfg.Reputation.Smoothing),
+			sdk.MustNewDecFromStr(cfg.Reputation.Tolerance),
+		)
 	}
 
-	derivatives := map[string]derivative.Derivative{}
-	for name, pairs := range derivativePairs {
-		d, err := derivative.NewDerivative(name, logger, &history, pairs, derivativePeriods[name])
-		if err != nil {
-			return err
-		}
-		derivatives[name] = d
+	settings, err := newSettings(logger, cfg, &history)
+	if err != nil {
+		return err
 	}
 
 	oracle := oracle.New(
 		logger,
-		oracleClient,
-		providerPairs,
+		targets,
+		settings.CurrencyPairs,
 		providerTimeout,
-		deviations,
-		providerMinOverrides,
-		endpoints,
-		derivatives,
-		derivativePairs,
-		derivativeSymbols,
+		shutdownGracePeriod,
+		auditRetention,
+		settings.Deviations,
+		settings.ProviderMinOverrides,
+		settings.Aggregators,
+		settings.ProviderWeights,
+		settings.VolumeShareCap,
+		settings.ConversionTolerance,
+		reputation,
+		settings.Endpoints,
+		settings.Derivatives,
+		settings.DerivativePairs,
+		settings.DerivativeSymbols,
 		cfg.Healthchecks,
 		history,
-		cfg.ContractAdresses,
+		settings.ContractAddresses,
 	)
 
 	telemetryCfg := telemetry.Config{}
@@ -248,10 +236,18 @@ func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// settings can only be reloaded while the oracle is running
+	var configReloader v1.Reloader
+	if cfg.EnableVoter {
+		r := &reloader{path: args[0], cfg: cfg, logger: logger, oracle: oracle, history: &history}
+		trapReload(ctx, logger, r)
+		configReloader = r
+	}
+
 	if cfg.EnableServer {
 		g.Go(func() error {
 			// start the process that observes and publishes exchange prices
-			return startPriceFeeder(ctx, logger, cfg, oracle, metrics)
+			return startPriceFeeder(ctx, logger, cfg, oracle, metrics, trackers, configReloader)
 		})
 	}
 
@@ -267,12 +263,165 @@ func priceFeederCmdHandler(cmd *cobra.Command, args []string) error {
 	return g.Wait()
 }
 
-func getKeyringPassword() (string, error) {
-	reader := bufio.NewReader(os.Stdin)
+// newTarget sets up the client, publisher and signer of an account and
+// returns them as oracle target, along with the outbox retrying failed
+// messages, the monitor of the operator balance and the submission tracker.
+// The monitor is nil unless messages are submitted to HCS, the tracker is
+// nil as well if the account has no mirror node.
+func newTarget(
+	ctx context.Context,
+	logger zerolog.Logger,
+	cfg config.Config,
+	account config.Account,
+	history *history.PriceHistory,
+) (*oracle.Target, *publisher.Outbox, *budget.Monitor, *tracker.Tracker, error) {
+	logger = logger.With().Str("target", account.Name).Logger()
+
+	votePeriod, err := time.ParseDuration(account.VotePeriod)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse vote period: %w", err)
+	}
+
+	prevoteOffset, err := time.ParseDuration(cfg.PrevoteOffset)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse prevote offset: %w", err)
+	}
+
+	voteOffset, err := time.ParseDuration(cfg.VoteOffset)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse vote offset: %w", err)
+	}
+
+	voteRounds, err := voting.NewVoteRounds(votePeriod, prevoteOffset, voteOffset)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	heightPollInterval, err := time.ParseDuration(cfg.HeightPollInterval)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to parse height poll interval: %w", err)
+	}
+
+	operatorKey, err := client.LoadPrivateKey(account.ToKeySource(), getKeyringPassword)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to load operator key: %w", err)
+	}
+
+	publisherConfig, err := cfg.Publisher.ToPublisherConfig()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	// only the HCS publisher submits to the network, the client, the balance
+	// monitor and the submission tracker are nil for all other publishers
+	var (
+		oracleClient      *client.OracleClient
+		balanceMonitor    *budget.Monitor
+		submissionTracker *tracker.Tracker
+	)
+	feeder := account.Name
+	if publisherConfig.Type == publisher.PublisherHCS {
+		maxTxFee, err := account.ToMaxTxFee()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		hcsClient, err := client.NewOracleClient(
+			ctx,
+			logger,
+			account.ToNetwork(),
+			account.OperatorID,
+			operatorKey,
+			account.TopicID,
+			maxTxFee,
+			votePeriod,
+			heightPollInterval,
+		)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		oracleClient = &hcsClient
+		feeder = oracleClient.OperatorAccount.String()
 
+		budgetConfig, err := cfg.Budget.ToBudgetConfig()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		balanceMonitor = budget.NewMonitor(logger, account.Name, oracleClient, budgetConfig)
+
+		// submissions are only tracked if a mirror node is configured, all
+		// methods of the tracker are no-ops otherwise
+		if account.MirrorNodeURL != "" {
+			pollInterval, err := time.ParseDuration(cfg.MirrorNode.PollInterval)
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("failed to parse mirror node poll interval: %w", err)
+			}
+			mirrorClient, err := mirror.NewClient(account.MirrorNodeURL, &http.Client{Timeout: pollInterval})
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
+			submissionTracker = tracker.NewTracker(
+				logger,
+				account.Name,
+				mirrorClient,
+				voteRounds.End,
+				pollInterval,
+				balanceMonitor,
+			)
+		}
+	}
+
+	basePublisher, err := publisher.NewPublisher(
+		logger,
+		publisherConfig,
+		oracleClient,
+		submissionTracker,
+	)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	signer, err := envelope.NewSigner(
+		feeder,
+		operatorKey,
+		cfg.Publisher.Encoding,
+		cfg.Publisher.MaxMessageSize,
+	)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	votePublisher := publisher.NewOutbox(logger, account.Name, basePublisher, history, votePeriod)
+	if err := votePublisher.Replay(); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to replay outbox: %w", err)
+	}
+
+	target := &oracle.Target{
+		Name:      account.Name,
+		Feeder:    feeder,
+		Mode:      account.PublishMode,
+		Publisher: votePublisher,
+		Signer:    signer,
+		Rounds:    voteRounds,
+		Quotes:    account.Quotes,
+	}
+	if account.PublishMode == push.ModePushOnDeviation {
+		pushConfig, err := cfg.Push.ToPushConfig()
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		target.Trigger = push.NewTrigger(pushConfig)
+	}
+	if balanceMonitor != nil {
+		target.Budget = balanceMonitor
+	}
+	return target, votePublisher, balanceMonitor, submissionTracker, nil
+}
+
+func getKeyringPassword() (string, error) {
 	pass := os.Getenv(envVariablePass)
 	if pass == "" {
-		return input.GetString("Enter keyring password", reader)
+		return input.GetPassword("Enter keyring password: ", stdin)
 	}
 	return pass, nil
 }
@@ -298,9 +447,11 @@ func startPriceFeeder(
 	cfg config.Config,
 	oracle *oracle.Oracle,
 	metrics *telemetry.Metrics,
+	trackers tracker.Group,
+	reloader v1.Reloader,
 ) error {
 	rtr := mux.NewRouter()
-	v1Router := v1.New(logger, cfg, oracle, metrics)
+	v1Router := v1.New(logger, cfg, oracle, metrics, trackers, reloader)
 	v1Router.RegisterRoutes(rtr, v1.APIPathPrefix)
 
 	writeTimeout, err := time.ParseDuration(cfg.Server.WriteTimeout)
@@ -359,7 +510,8 @@ func startPriceOracle(ctx context.Context, logger zerolog.Logger, oracle *oracle
 		select {
 		case <-ctx.Done():
 			logger.Info().Msg("shutting down price-feeder oracle...")
-			return nil
+			// wait for pending votes to be revealed
+			return <-srvErrCh
 
 		case err := <-srvErrCh:
 			logger.Err(err).Msg("error starting the price-feeder oracle")
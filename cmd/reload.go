@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+
+	"price-feeder/config"
+	"price-feeder/oracle"
+	"price-feeder/oracle/derivative"
+	"price-feeder/oracle/history"
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+// reloadableSettings are the config settings applied by a reload, all others
+// require a restart.
+var reloadableSettings = map[string]struct{}{
+	"currency_pairs":         {},
+	"deviation_thresholds":   {},
+	"provider_min_overrides": {},
+	"aggregation":            {},
+	"provider_weights":       {},
+	"volume_share_cap":       {},
+	"conversion_tolerance":   {},
+	"provider_endpoints":     {},
+	"contract_addresses":     {},
+}
+
+// reloader re-parses the config file and applies its price settings to the
+// running oracle. The config the feeder was started with is kept to warn
+// about changed settings which are not reloaded.
+type reloader struct {
+	path    string
+	cfg     config.Config
+	logger  zerolog.Logger
+	oracle  *oracle.Oracle
+	history *history.PriceHistory
+}
+
+// Reload implements the v1.Reloader interface.
+func (r *reloader) Reload(ctx context.Context) error {
+	cfg, err := config.ParseConfig(r.path)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if changed := restartSettings(r.cfg, cfg); len(changed) > 0 {
+		r.logger.Warn().Strs("settings", changed).Msg("changed settings require a restart")
+	}
+	settings, err := newSettings(r.logger, cfg, r.history)
+	if err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	return r.oracle.Reload(ctx, settings)
+}
+
+// restartSettings returns the settings which differ between the running and
+// the reloaded config but are not applied by a reload, e.g. the accounts or
+// the vote period.
+func restartSettings(running, reloaded config.Config) []string {
+	changed := []string{}
+	runningValue := reflect.ValueOf(running)
+	reloadedValue := reflect.ValueOf(reloaded)
+	for i := 0; i < runningValue.NumField(); i++ {
+		name := runningValue.Type().Field(i).Tag.Get("toml")
+		if _, ok := reloadableSettings[name]; ok {
+			continue
+		}
+		if !reflect.DeepEqual(runningValue.Field(i).Interface(), reloadedValue.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// trapReload reloads the config whenever SIGHUP is received.
+func trapReload(ctx context.Context, logger zerolog.Logger, r *reloader) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigCh)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				logger.Info().Msg("caught SIGHUP; reloading config...")
+				if err := r.Reload(ctx); err != nil {
+					logger.Error().Err(err).Msg("failed to reload config")
+				}
+			}
+		}
+	}()
+}
+
+// newSettings returns the price settings of the oracle defined by the config.
+func newSettings(
+	logger zerolog.Logger,
+	cfg config.Config,
+	history *history.PriceHistory,
+) (oracle.Settings, error) {
+	deviations := make(map[string]oracle.DeviationFilter, len(cfg.Deviations))
+	for _, deviation := range cfg.Deviations {
+		threshold, err := sdk.NewDecFromStr(deviation.Threshold)
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		filter := oracle.DeviationFilter{Method: deviation.Method, Threshold: threshold}
+		if deviation.Agreement != "" {
+			filter.Agreement, err = sdk.NewDecFromStr(deviation.Agreement)
+			if err != nil {
+				return oracle.Settings{}, err
+			}
+		}
+		deviations[deviation.Base] = filter
+	}
+
+	providerMinOverrides := make(map[string]int, len(cfg.ProviderMinOverrides))
+	for _, override := range cfg.ProviderMinOverrides {
+		for _, denom := range override.Denoms {
+			_, found := providerMinOverrides[denom]
+			if found {
+				logger.Warn().
+					Str("denom", denom).
+					Msg("provider_min_overrides already set")
+			}
+			providerMinOverrides[denom] = int(override.Providers)
+		}
+	}
+
+	aggregators := map[string]oracle.Aggregator{}
+	for _, aggregation := range cfg.Aggregations {
+		aggregator, err := oracle.NewAggregator(aggregation.Strategy)
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		for _, denom := range aggregation.Denoms {
+			aggregators[denom] = aggregator
+		}
+	}
+
+	providerWeights := map[provider.Name]sdk.Dec{}
+	for _, weight := range cfg.ProviderWeights {
+		value, err := sdk.NewDecFromStr(weight.Weight)
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		for _, name := range weight.Providers {
+			providerWeights[name] = value
+		}
+	}
+
+	var volumeShareCap sdk.Dec
+	if cfg.VolumeShareCap != "" {
+		value, err := sdk.NewDecFromStr(cfg.VolumeShareCap)
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		volumeShareCap = value
+	}
+
+	var conversionTolerance sdk.Dec
+	if cfg.ConversionTolerance != "" {
+		value, err := sdk.NewDecFromStr(cfg.ConversionTolerance)
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		conversionTolerance = value
+	}
+
+	endpoints := make(map[provider.Name]provider.Endpoint, len(cfg.ProviderEndpoints))
+	for _, e := range cfg.ProviderEndpoints {
+		endpoint, err := e.ToEndpoint()
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		endpoints[endpoint.Name] = endpoint
+	}
+
+	derivativePairs := map[string][]types.CurrencyPair{}
+	derivativePeriods := map[string]map[string]time.Duration{}
+	derivativeSymbols := map[string]struct{}{}
+	providerPairs := []config.CurrencyPair{}
+	for _, pair := range cfg.CurrencyPairs {
+		if pair.Derivative != "" {
+			period, err := time.ParseDuration(pair.DerivativePeriod)
+			if err != nil {
+				return oracle.Settings{}, err
+			}
+			pairs, ok := derivativePairs[pair.Derivative]
+			if !ok {
+				pairs = []types.CurrencyPair{}
+				derivativePeriods[pair.Derivative] = map[string]time.Duration{}
+			}
+			currencyPair := types.CurrencyPair{Base: pair.Base, Quote: pair.Quote}
+			derivativePairs[pair.Derivative] = append(pairs, currencyPair)
+			derivativePeriods[pair.Derivative][currencyPair.String()] = period
+			derivativeSymbols[pair.Base+pair.Quote] = struct{}{}
+		}
+		providerPairs = append(providerPairs, pair)
+	}
+
+	derivatives := map[string]derivative.Derivative{}
+	for name, pairs := range derivativePairs {
+		d, err := derivative.NewDerivative(name, logger, history, pairs, derivativePeriods[name])
+		if err != nil {
+			return oracle.Settings{}, err
+		}
+		derivatives[name] = d
+	}
+
+	return oracle.Settings{
+		CurrencyPairs:        providerPairs,
+		Deviations:           deviations,
+		ProviderMinOverrides: providerMinOverrides,
+		Aggregators:          aggregators,
+		ProviderWeights:      providerWeights,
+		VolumeShareCap:       volumeShareCap,
+		ConversionTolerance:  conversionTolerance,
+		Endpoints:            endpoints,
+		Derivatives:          derivatives,
+		DerivativePairs:      derivativePairs,
+		DerivativeSymbols:    derivativeSymbols,
+		ContractAddresses:    cfg.ContractAdresses,
+	}, nil
+}
@@ -7,24 +7,57 @@ import (
 	"strings"
 	"time"
 
+	"price-feeder/oracle/budget"
+	"price-feeder/oracle/client"
 	"price-feeder/oracle/derivative"
+	"price-feeder/oracle/envelope"
 	"price-feeder/oracle/provider"
+	"price-feeder/oracle/publisher"
+	"price-feeder/oracle/push"
+	"price-feeder/oracle/voting"
 
 	"github.com/BurntSushi/toml"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/go-playground/validator/v10"
+	"github.com/hashgraph/hedera-sdk-go/v2"
 )
 
 const (
 	DenomUSD = "USD"
 
+	AggregationVWAP                 = "vwap"
+	AggregationMedian               = "median"
+	AggregationVolumeWeightedMedian = "volume_weighted_median"
+	AggregationTrimmedMean          = "trimmed_mean"
+	AggregationLiquidityWeighted    = "liquidity_weighted"
+
+	DeviationStdDev     = "stddev"
+	DeviationMAD        = "mad"
+	DeviationPercentage = "percentage"
+
 	defaultListenAddr         = "0.0.0.0:7171"
 	defaultSrvWriteTimeout    = 15 * time.Second
 	defaultSrvReadTimeout     = 15 * time.Second
 	defaultProviderTimeout    = 100 * time.Millisecond
+	defaultShutdownGrace      = 10 * time.Second
+	defaultAuditRetention     = 24 * time.Hour
 	defaultHeightPollInterval = 1 * time.Second
 	defaultHistoryDb          = "prices.db"
 	defaultDerivativePeriod   = 30 * time.Minute
+	defaultPublisher          = publisher.PublisherHCS
+	defaultAccountName        = "default"
+	defaultEncoding           = envelope.EncodingJSON
+	defaultMaxMessageSize     = 1024
+	defaultMirrorPollInterval = 2 * time.Second
+	defaultBalanceInterval    = time.Minute
+	defaultPauseLevel         = budget.LevelCritical
+	defaultPublishMode        = push.ModeCommitReveal
+	defaultPushHeartbeat      = time.Hour
+	defaultPushThreshold      = "0.5"
+	defaultPrevoteOffset      = time.Duration(0)
+	defaultVoteOffset         = time.Duration(0)
+	defaultReputationSmooth   = "0.1"
+	defaultReputationTol      = "0.01"
 )
 
 var (
@@ -80,6 +113,39 @@ var (
 		derivative.DerivativeTwap: {},
 	}
 
+	// SupportedAggregations defines the strategies which can be used to
+	// aggregate the provider prices of a denom.
+	SupportedAggregations = map[string]struct{}{
+		AggregationVWAP:                 {},
+		AggregationMedian:               {},
+		AggregationVolumeWeightedMedian: {},
+		AggregationTrimmedMean:          {},
+		AggregationLiquidityWeighted:    {},
+	}
+
+	// SupportedNetworks defines the public Hedera networks that can be
+	// selected by name.
+	SupportedNetworks = map[string]struct{}{
+		string(hedera.NetworkNameMainnet):    {},
+		string(hedera.NetworkNameTestnet):    {},
+		string(hedera.NetworkNamePreviewnet): {},
+	}
+
+	SupportedPublishers = map[string]struct{}{
+		publisher.PublisherHCS:     {},
+		publisher.PublisherFile:    {},
+		publisher.PublisherStdout:  {},
+		publisher.PublisherWebhook: {},
+	}
+
+	// SupportedDeviationMethods defines the methods which can be used to
+	// detect outliers among the provider prices of a denom.
+	SupportedDeviationMethods = map[string]struct{}{
+		DeviationStdDev:     {},
+		DeviationMAD:        {},
+		DeviationPercentage: {},
+	}
+
 	// maxDeviationThreshold is the maxmimum allowed amount of standard
 	// deviations which validators are able to set for a given asset.
 	maxDeviationThreshold = sdk.MustNewDecFromStr("3.0")
@@ -92,10 +158,25 @@ type (
 		CurrencyPairs        []CurrencyPair               `toml:"currency_pairs" validate:"required,gt=0,dive,required"`
 		Deviations           []Deviation                  `toml:"deviation_thresholds"`
 		ProviderMinOverrides []ProviderMinOverrides       `toml:"provider_min_overrides"`
-		Account              Account                      `toml:"account" validate:"required,gt=0,dive,required"`
+		Aggregations         []Aggregation                `toml:"aggregation" validate:"dive"`
+		ProviderWeights      []ProviderWeight             `toml:"provider_weights" validate:"dive"`
+		Reputation           Reputation                   `toml:"reputation"`
+		VolumeShareCap       string                       `toml:"volume_share_cap"`
+		ConversionTolerance  string                       `toml:"conversion_tolerance"`
+		Account              []Account                    `toml:"account" validate:"dive"`
+		Publisher            Publisher                    `toml:"publisher"`
+		MirrorNode           MirrorNode                   `toml:"mirror_node"`
+		Budget               Budget                       `toml:"budget"`
+		Push                 Push                         `toml:"push"`
 		Telemetry            Telemetry                    `toml:"telemetry"`
 		VotePeriod           string                       `toml:"vote_period" validate:"required"`
+		PublishMode          string                       `toml:"publish_mode"`
+		Quotes               []string                     `toml:"quotes"`
+		PrevoteOffset        string                       `toml:"prevote_offset"`
+		VoteOffset           string                       `toml:"vote_offset"`
 		ProviderTimeout      string                       `toml:"provider_timeout"`
+		ShutdownGracePeriod  string                       `toml:"shutdown_grace_period"`
+		AuditRetention       string                       `toml:"audit_retention"`
 		ProviderEndpoints    []ProviderEndpoints          `toml:"provider_endpoints" validate:"dive"`
 		EnableServer         bool                         `toml:"enable_server"`
 		EnableVoter          bool                         `toml:"enable_voter"`
@@ -112,6 +193,7 @@ type (
 		ReadTimeout    string   `toml:"read_timeout"`
 		VerboseCORS    bool     `toml:"verbose_cors"`
 		AllowedOrigins []string `toml:"allowed_origins"`
+		EnableAdmin    bool     `toml:"enable_admin"`
 	}
 
 	// CurrencyPair defines a price quote of the exchange rate for two different
@@ -125,10 +207,17 @@ type (
 	}
 
 	// Deviation defines a maximum amount of standard deviations that a given asset can
-	// be from the median without being filtered out before voting.
+	// be from the median without being filtered out before voting. Method
+	// selects how the deviation is measured, in standard deviations around the
+	// mean by default, in scaled median absolute deviations around the median
+	// or as a share of the median. Agreement is the share two prices, or a
+	// single price and the previous price, may differ by if there are too few
+	// prices to measure the deviation.
 	Deviation struct {
 		Base      string `toml:"base" validate:"required"`
 		Threshold string `toml:"threshold" validate:"required"`
+		Method    string `toml:"method"`
+		Agreement string `toml:"agreement"`
 	}
 
 	// ProviderMinOverrides defines the minimum amount of sources that need
@@ -138,13 +227,107 @@ type (
 		Providers uint     `toml:"providers" validate:"required"`
 	}
 
-	// Account defines account related configuration that is related to the
-	// network and transaction signing functionality.
+	// Aggregation defines the strategy used to aggregate the provider prices
+	// of the given denoms, VWAP is used for all other denoms.
+	Aggregation struct {
+		Denoms   []string `toml:"denoms" validate:"required,gt=0"`
+		Strategy string   `toml:"strategy" validate:"required"`
+	}
+
+	// ProviderWeight defines the static trust weight of the given providers,
+	// which scales their contribution to the aggregated prices. Providers
+	// without a weight have a weight of 1.
+	ProviderWeight struct {
+		Providers []provider.Name `toml:"providers" validate:"required,gt=0"`
+		Weight    string          `toml:"weight" validate:"required"`
+	}
+
+	// Reputation defines the dynamic trust score of the providers, which is
+	// derived from how far their prices were from the aggregated prices.
+	// Smoothing is the weight of the latest tick in the smoothed distance,
+	// Tolerance the distance which halves the score.
+	Reputation struct {
+		Enabled   bool   `toml:"enabled"`
+		Smoothing string `toml:"smoothing"`
+		Tolerance string `toml:"tolerance"`
+	}
+
+	// Account defines a publish target, i.e. the network, operator account
+	// and topic prevotes and votes are submitted to. Every account keeps its
+	// own commit-reveal state, identified by Name, which defaults to the
+	// operator id. VotePeriod, PublishMode, MirrorNodeURL and Quotes default
+	// to the global vote period, publish mode, mirror node and quotes.
+	// NetworkName selects a public network, unless Nodes defines the address
+	// book of a custom network. The operator key is loaded from exactly one
+	// of OperatorSeed, OperatorKey, OperatorKeyFile, OperatorKeyEnv and
+	// OperatorKeystore. The key signs the envelopes of all publishers, the
+	// operator, the network and the topic are only required by the HCS
+	// publisher.
 	Account struct {
-		NetworkName  string `toml:"network_name" validate:"required"`
-		OperatorID   string `toml:"operator_id" validate:"required"`
-		OperatorSeed string `toml:"operator_seed" validate:"required"`
-		TopicID      string `toml:"topic_id" validate:"required"`
+		Name               string            `toml:"name"`
+		NetworkName        string            `toml:"network_name"`
+		Nodes              map[string]string `toml:"nodes"`
+		MirrorNodes        []string          `toml:"mirror_nodes"`
+		TransportSecurity  *bool             `toml:"transport_security"`
+		VerifyCertificates *bool             `toml:"verify_certificates"`
+		OperatorID         string            `toml:"operator_id"`
+		OperatorSeed       string            `toml:"operator_seed"`
+		OperatorKey        string            `toml:"operator_key"`
+		OperatorKeyFile    string            `toml:"operator_key_file"`
+		OperatorKeyEnv     string            `toml:"operator_key_env"`
+		OperatorKeystore   string            `toml:"operator_keystore"`
+		KeyType            string            `toml:"key_type"`
+		MnemonicIndex      uint32            `toml:"mnemonic_index"`
+		TopicID            string            `toml:"topic_id"`
+		MaxTxFee           string            `toml:"max_tx_fee"`
+		VotePeriod         string            `toml:"vote_period"`
+		PublishMode        string            `toml:"publish_mode"`
+		MirrorNodeURL      string            `toml:"mirror_node_url"`
+		Quotes             []string          `toml:"quotes"`
+	}
+
+	// Publisher defines where prevote and vote messages are delivered to.
+	// Besides the HCS topics of the account sections, messages can be written
+	// to a JSONL file, to stdout or POSTed to a webhook. Encoding selects the
+	// encoding of the signed envelope wrapping every message, envelopes
+	// larger than MaxMessageSize are split into chunks.
+	Publisher struct {
+		Type           string            `toml:"type"`
+		Encoding       string            `toml:"encoding"`
+		MaxMessageSize int               `toml:"max_message_size" validate:"gte=0"`
+		Path           string            `toml:"path"`
+		URL            string            `toml:"url"`
+		Headers        map[string]string `toml:"headers"`
+		Timeout        string            `toml:"timeout"`
+	}
+
+	// MirrorNode defines the mirror node REST API used to confirm that
+	// submitted messages reached consensus. Tracking is disabled for
+	// accounts without a mirror node URL.
+	MirrorNode struct {
+		URL          string `toml:"url"`
+		PollInterval string `toml:"poll_interval"`
+	}
+
+	// Budget defines how the balance of the operator account is monitored.
+	// Balances are given in hbar. Once the balance drops to PauseLevel,
+	// i.e. the warning or critical balance, no new prevotes are submitted
+	// until the account is funded again.
+	Budget struct {
+		CheckInterval   string `toml:"check_interval"`
+		WarningBalance  string `toml:"warning_balance"`
+		CriticalBalance string `toml:"critical_balance"`
+		PauseLevel      string `toml:"pause_level"`
+	}
+
+	// Push defines when prices are pushed in push_on_deviation mode. Prices
+	// are pushed once a price moved by more than its threshold in percent
+	// since the last push, or the heartbeat expired. Thresholds overrides
+	// the default Threshold per denom.
+	Push struct {
+		Heartbeat  string            `toml:"heartbeat"`
+		Threshold  string            `toml:"threshold"`
+		Thresholds map[string]string `toml:"thresholds"`
 	}
 
 	// Telemetry defines the configuration options for application telemetry.
@@ -214,13 +397,217 @@ func endpointValidation(sl validator.StructLevel) {
 	}
 }
 
+// accountValidation is custom validation for the Account struct.
+func accountValidation(sl validator.StructLevel) {
+	a := sl.Current().Interface().(Account)
+
+	if a.keySources() > 1 {
+		sl.ReportError(a.OperatorKey, "operator_key", "OperatorKey", "atMostOneKeySource", "")
+	}
+	if _, ok := client.SupportedKeyTypes[a.KeyType]; a.KeyType != "" && !ok {
+		sl.ReportError(a.KeyType, "key_type", "KeyType", "unsupportedKeyType", "")
+	}
+}
+
+// configValidation is custom validation for the Config struct. Accounts always
+// define the key signing their envelopes, they are only required to define the
+// operator, the network and the topic if messages are submitted to HCS.
+func configValidation(sl validator.StructLevel) {
+	c := sl.Current().Interface().(Config)
+
+	if len(c.Account) == 0 {
+		sl.ReportError(c.Account, "account", "Account", "required", "")
+	}
+	for _, a := range c.Account {
+		if a.keySources() != 1 {
+			sl.ReportError(a.OperatorKey, "operator_key", "OperatorKey", "exactlyOneKeySource", "")
+		}
+	}
+
+	if c.Publisher.Type != "" && c.Publisher.Type != publisher.PublisherHCS {
+		return
+	}
+	for _, a := range c.Account {
+		if a.OperatorID == "" {
+			sl.ReportError(a.OperatorID, "operator_id", "OperatorID", "required", "")
+		}
+		if a.TopicID == "" {
+			sl.ReportError(a.TopicID, "topic_id", "TopicID", "required", "")
+		}
+		if _, ok := SupportedNetworks[a.NetworkName]; !ok && len(a.Nodes) == 0 {
+			sl.ReportError(a.NetworkName, "network_name", "NetworkName", "unsupportedNetwork", "")
+		}
+	}
+}
+
+// budgetValidation is custom validation for the Budget struct.
+func budgetValidation(sl validator.StructLevel) {
+	b := sl.Current().Interface().(Budget)
+
+	if _, ok := budget.SupportedPauseLevels[b.PauseLevel]; b.PauseLevel != "" && !ok {
+		sl.ReportError(b.PauseLevel, "pause_level", "PauseLevel", "unsupportedPauseLevel", "")
+	}
+}
+
+// publisherValidation is custom validation for the Publisher struct.
+func publisherValidation(sl validator.StructLevel) {
+	p := sl.Current().Interface().(Publisher)
+
+	if p.Type == "" {
+		return
+	}
+	if _, ok := SupportedPublishers[p.Type]; !ok {
+		sl.ReportError(p.Type, "type", "Type", "unsupportedPublisher", "")
+	}
+	if p.Type == publisher.PublisherFile && p.Path == "" {
+		sl.ReportError(p.Path, "path", "Path", "required", "")
+	}
+	if p.Type == publisher.PublisherWebhook && p.URL == "" {
+		sl.ReportError(p.URL, "url", "URL", "required", "")
+	}
+	if _, ok := envelope.SupportedEncodings[p.Encoding]; p.Encoding != "" && !ok {
+		sl.ReportError(p.Encoding, "encoding", "Encoding", "unsupportedEncoding", "")
+	}
+}
+
 // Validate returns an error if the Config object is invalid.
 func (c Config) Validate() error {
+	validate.RegisterStructValidation(configValidation, Config{})
 	validate.RegisterStructValidation(telemetryValidation, Telemetry{})
 	validate.RegisterStructValidation(endpointValidation, ProviderEndpoints{})
+	validate.RegisterStructValidation(accountValidation, Account{})
+	validate.RegisterStructValidation(publisherValidation, Publisher{})
+	validate.RegisterStructValidation(budgetValidation, Budget{})
 	return validate.Struct(c)
 }
 
+func (a Account) ToNetwork() client.Network {
+	return client.Network{
+		Name:               a.NetworkName,
+		Nodes:              a.Nodes,
+		MirrorNodes:        a.MirrorNodes,
+		TransportSecurity:  a.TransportSecurity,
+		VerifyCertificates: a.VerifyCertificates,
+	}
+}
+
+// keySources returns the number of configured sources of the operator key.
+func (a Account) keySources() int {
+	keySources := 0
+	for _, source := range []string{
+		a.OperatorSeed,
+		a.OperatorKey,
+		a.OperatorKeyFile,
+		a.OperatorKeyEnv,
+		a.OperatorKeystore,
+	} {
+		if source != "" {
+			keySources++
+		}
+	}
+	return keySources
+}
+
+func (a Account) ToKeySource() client.KeySource {
+	return client.KeySource{
+		Mnemonic:      a.OperatorSeed,
+		MnemonicIndex: a.MnemonicIndex,
+		Key:           a.OperatorKey,
+		File:          a.OperatorKeyFile,
+		Env:           a.OperatorKeyEnv,
+		Keystore:      a.OperatorKeystore,
+		Type:          a.KeyType,
+	}
+}
+
+// ToMaxTxFee returns the max transaction fee, zero if not set.
+func (a Account) ToMaxTxFee() (hedera.Hbar, error) {
+	maxTxFee, err := parseHbar(a.MaxTxFee)
+	if err != nil {
+		return hedera.ZeroHbar, fmt.Errorf("failed to parse max tx fee: %v", err)
+	}
+	return maxTxFee, nil
+}
+
+func (b Budget) ToBudgetConfig() (budget.Config, error) {
+	interval, err := time.ParseDuration(b.CheckInterval)
+	if err != nil {
+		return budget.Config{}, fmt.Errorf("failed to parse balance check interval: %v", err)
+	}
+	warning, err := parseHbar(b.WarningBalance)
+	if err != nil {
+		return budget.Config{}, fmt.Errorf("failed to parse warning balance: %v", err)
+	}
+	critical, err := parseHbar(b.CriticalBalance)
+	if err != nil {
+		return budget.Config{}, fmt.Errorf("failed to parse critical balance: %v", err)
+	}
+	if warning.AsTinybar() > 0 && warning.AsTinybar() < critical.AsTinybar() {
+		return budget.Config{}, fmt.Errorf("warning balance must not be below the critical balance")
+	}
+
+	return budget.Config{
+		CheckInterval: interval,
+		Warning:       warning,
+		Critical:      critical,
+		PauseLevel:    b.PauseLevel,
+	}, nil
+}
+
+// parseHbar parses an amount of hbar, e.g. "1.5" or "500 tℏ".
+func parseHbar(s string) (hedera.Hbar, error) {
+	if s == "" {
+		return hedera.ZeroHbar, nil
+	}
+	return hedera.HbarFromString(s)
+}
+
+func (p Push) ToPushConfig() (push.Config, error) {
+	heartbeat, err := time.ParseDuration(p.Heartbeat)
+	if err != nil {
+		return push.Config{}, fmt.Errorf("failed to parse push heartbeat: %v", err)
+	}
+	threshold, err := sdk.NewDecFromStr(p.Threshold)
+	if err != nil || !threshold.IsPositive() {
+		return push.Config{}, fmt.Errorf("push threshold must be a positive percentage: %s", p.Threshold)
+	}
+
+	thresholds := make(map[string]sdk.Dec, len(p.Thresholds))
+	for denom, value := range p.Thresholds {
+		t, err := sdk.NewDecFromStr(value)
+		if err != nil || !t.IsPositive() {
+			return push.Config{}, fmt.Errorf("push threshold of %s must be a positive percentage: %s", denom, value)
+		}
+		thresholds[denom] = t
+	}
+
+	return push.Config{
+		Heartbeat:  heartbeat,
+		Threshold:  threshold,
+		Thresholds: thresholds,
+	}, nil
+}
+
+func (p Publisher) ToPublisherConfig() (publisher.Config, error) {
+	var timeout time.Duration
+	if p.Timeout != "" {
+		t, err := time.ParseDuration(p.Timeout)
+		if err != nil {
+			return publisher.Config{}, fmt.Errorf("failed to parse publisher timeout: %v", err)
+		}
+		timeout = t
+	}
+
+	c := publisher.Config{
+		Type:    p.Type,
+		Path:    p.Path,
+		URL:     p.URL,
+		Headers: p.Headers,
+		Timeout: timeout,
+	}
+	return c, nil
+}
+
 func (p ProviderEndpoints) ToEndpoint() (provider.Endpoint, error) {
 	var pollInterval time.Duration
 	if p.PollInterval != "" {
@@ -255,7 +642,7 @@ func ParseConfig(configPath string) (Config, error) {
 		return cfg, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	if _, err := toml.Decode(string(configData), &cfg); err != nil {
+	if err := decodeConfig(string(configData), &cfg); err != nil {
 		return cfg, fmt.Errorf("failed to decode config: %w", err)
 	}
 
@@ -271,12 +658,83 @@ func ParseConfig(configPath string) (Config, error) {
 	if len(cfg.ProviderTimeout) == 0 {
 		cfg.ProviderTimeout = defaultProviderTimeout.String()
 	}
+	if cfg.ShutdownGracePeriod == "" {
+		cfg.ShutdownGracePeriod = defaultShutdownGrace.String()
+	}
+	if cfg.AuditRetention == "" {
+		cfg.AuditRetention = defaultAuditRetention.String()
+	}
 	if cfg.HeightPollInterval == "" {
 		cfg.HeightPollInterval = defaultHeightPollInterval.String()
 	}
 	if cfg.HistoryDb == "" {
 		cfg.HistoryDb = defaultHistoryDb
 	}
+	if cfg.PrevoteOffset == "" {
+		cfg.PrevoteOffset = defaultPrevoteOffset.String()
+	}
+	if cfg.VoteOffset == "" {
+		cfg.VoteOffset = defaultVoteOffset.String()
+	}
+	if cfg.Publisher.Type == "" {
+		cfg.Publisher.Type = defaultPublisher
+	}
+	if cfg.Publisher.Encoding == "" {
+		cfg.Publisher.Encoding = defaultEncoding
+	}
+	if cfg.Publisher.MaxMessageSize == 0 {
+		cfg.Publisher.MaxMessageSize = defaultMaxMessageSize
+	}
+	if cfg.MirrorNode.PollInterval == "" {
+		cfg.MirrorNode.PollInterval = defaultMirrorPollInterval.String()
+	}
+	if cfg.Budget.CheckInterval == "" {
+		cfg.Budget.CheckInterval = defaultBalanceInterval.String()
+	}
+	if cfg.Budget.PauseLevel == "" {
+		cfg.Budget.PauseLevel = defaultPauseLevel
+	}
+	if cfg.PublishMode == "" {
+		cfg.PublishMode = defaultPublishMode
+	}
+	if len(cfg.Quotes) == 0 {
+		cfg.Quotes = []string{DenomUSD}
+	}
+	if cfg.Push.Heartbeat == "" {
+		cfg.Push.Heartbeat = defaultPushHeartbeat.String()
+	}
+	if cfg.Push.Threshold == "" {
+		cfg.Push.Threshold = defaultPushThreshold
+	}
+
+	accountNames := map[string]struct{}{}
+	for i, account := range cfg.Account {
+		if account.Name == "" {
+			cfg.Account[i].Name = account.OperatorID
+		}
+		if cfg.Account[i].Name == "" {
+			cfg.Account[i].Name = defaultAccountName
+		}
+		if account.VotePeriod == "" {
+			cfg.Account[i].VotePeriod = cfg.VotePeriod
+		}
+		if account.PublishMode == "" {
+			cfg.Account[i].PublishMode = cfg.PublishMode
+		}
+		if len(account.Quotes) == 0 {
+			cfg.Account[i].Quotes = cfg.Quotes
+		}
+		if _, ok := push.SupportedModes[cfg.Account[i].PublishMode]; !ok {
+			return cfg, fmt.Errorf("unsupported publish mode: %s", cfg.Account[i].PublishMode)
+		}
+		if account.MirrorNodeURL == "" {
+			cfg.Account[i].MirrorNodeURL = cfg.MirrorNode.URL
+		}
+		if _, ok := accountNames[cfg.Account[i].Name]; ok {
+			return cfg, fmt.Errorf("duplicate account name: %s", cfg.Account[i].Name)
+		}
+		accountNames[cfg.Account[i].Name] = struct{}{}
+	}
 
 	derivativeDenoms := map[string]struct{}{}
 	derivativeBases := map[string]struct{}{}
@@ -318,14 +776,76 @@ func ParseConfig(configPath string) (Config, error) {
 		}
 	}
 
-	for _, deviation := range cfg.Deviations {
+	if err := validateQuotes(cfg.Quotes, pairs); err != nil {
+		return cfg, err
+	}
+	for _, account := range cfg.Account {
+		if err := validateQuotes(account.Quotes, pairs); err != nil {
+			return cfg, err
+		}
+	}
+
+	for i, deviation := range cfg.Deviations {
+		if deviation.Method == "" {
+			cfg.Deviations[i].Method = DeviationStdDev
+		}
+		if _, ok := SupportedDeviationMethods[cfg.Deviations[i].Method]; !ok {
+			return cfg, fmt.Errorf("unsupported deviation method: %s", deviation.Method)
+		}
+
 		threshold, err := sdk.NewDecFromStr(deviation.Threshold)
 		if err != nil {
 			return cfg, fmt.Errorf("deviation thresholds must be numeric: %w", err)
 		}
 
-		if threshold.GT(maxDeviationThreshold) {
-			return cfg, fmt.Errorf("deviation thresholds must not exceed 3.0")
+		switch cfg.Deviations[i].Method {
+		case DeviationStdDev:
+			if threshold.GT(maxDeviationThreshold) {
+				return cfg, fmt.Errorf("deviation thresholds must not exceed 3.0")
+			}
+		case DeviationMAD:
+			if !threshold.IsPositive() {
+				return cfg, fmt.Errorf("mad deviation thresholds must be positive")
+			}
+		case DeviationPercentage:
+			if !threshold.IsPositive() || threshold.GTE(sdk.OneDec()) {
+				return cfg, fmt.Errorf("percentage deviation thresholds must be between 0 and 1")
+			}
+		}
+
+		if deviation.Agreement != "" {
+			agreement, err := sdk.NewDecFromStr(deviation.Agreement)
+			if err != nil {
+				return cfg, fmt.Errorf("deviation agreements must be numeric: %w", err)
+			}
+			if !agreement.IsPositive() || agreement.GTE(sdk.OneDec()) {
+				return cfg, fmt.Errorf("deviation agreements must be between 0 and 1")
+			}
+		}
+	}
+
+	votePeriods := []string{cfg.VotePeriod}
+	for _, account := range cfg.Account {
+		votePeriods = append(votePeriods, account.VotePeriod)
+	}
+	for _, period := range votePeriods {
+		if period == "" {
+			continue
+		}
+		votePeriod, err := time.ParseDuration(period)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to parse vote period: %w", err)
+		}
+		prevoteOffset, err := time.ParseDuration(cfg.PrevoteOffset)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to parse prevote offset: %w", err)
+		}
+		voteOffset, err := time.ParseDuration(cfg.VoteOffset)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to parse vote offset: %w", err)
+		}
+		if _, err := voting.NewVoteRounds(votePeriod, prevoteOffset, voteOffset); err != nil {
+			return cfg, err
 		}
 	}
 
@@ -335,5 +855,115 @@ func ParseConfig(configPath string) (Config, error) {
 		}
 	}
 
+	weights := map[provider.Name]struct{}{}
+	for _, weight := range cfg.ProviderWeights {
+		value, err := sdk.NewDecFromStr(weight.Weight)
+		if err != nil {
+			return cfg, fmt.Errorf("provider weights must be numeric: %w", err)
+		}
+		if !value.IsPositive() {
+			return cfg, fmt.Errorf("provider weights must be positive")
+		}
+		for _, name := range weight.Providers {
+			if _, ok := SupportedProviders[name]; !ok {
+				return cfg, fmt.Errorf("unsupported provider: %s", name)
+			}
+			if _, ok := weights[name]; ok {
+				return cfg, fmt.Errorf("weight already set for provider: %s", name)
+			}
+			weights[name] = struct{}{}
+		}
+	}
+
+	if cfg.VolumeShareCap != "" {
+		shareCap, err := sdk.NewDecFromStr(cfg.VolumeShareCap)
+		if err != nil || !shareCap.IsPositive() || shareCap.GT(sdk.OneDec()) {
+			return cfg, fmt.Errorf("volume share cap must be between 0 and 1")
+		}
+	}
+
+	if cfg.ConversionTolerance != "" {
+		tolerance, err := sdk.NewDecFromStr(cfg.ConversionTolerance)
+		if err != nil || !tolerance.IsPositive() || tolerance.GTE(sdk.OneDec()) {
+			return cfg, fmt.Errorf("conversion tolerance must be between 0 and 1")
+		}
+	}
+
+	if cfg.Reputation.Smoothing == "" {
+		cfg.Reputation.Smoothing = defaultReputationSmooth
+	}
+	if cfg.Reputation.Tolerance == "" {
+		cfg.Reputation.Tolerance = defaultReputationTol
+	}
+	smoothing, err := sdk.NewDecFromStr(cfg.Reputation.Smoothing)
+	if err != nil || !smoothing.IsPositive() || smoothing.GT(sdk.OneDec()) {
+		return cfg, fmt.Errorf("reputation smoothing must be between 0 and 1")
+	}
+	tolerance, err := sdk.NewDecFromStr(cfg.Reputation.Tolerance)
+	if err != nil || !tolerance.IsPositive() {
+		return cfg, fmt.Errorf("reputation tolerance must be positive")
+	}
+
+	aggregations := map[string]struct{}{}
+	for _, aggregation := range cfg.Aggregations {
+		if _, ok := SupportedAggregations[aggregation.Strategy]; !ok {
+			return cfg, fmt.Errorf("unsupported aggregation strategy: %s", aggregation.Strategy)
+		}
+		for _, denom := range aggregation.Denoms {
+			if _, ok := aggregations[denom]; ok {
+				return cfg, fmt.Errorf("aggregation strategy already set for denom: %s", denom)
+			}
+			aggregations[denom] = struct{}{}
+		}
+	}
+
+	if _, err := cfg.Budget.ToBudgetConfig(); err != nil {
+		return cfg, err
+	}
+	if _, err := cfg.Push.ToPushConfig(); err != nil {
+		return cfg, err
+	}
+
 	return cfg, cfg.Validate()
 }
+
+// decodeConfig decodes the TOML config. Configs written before multiple
+// accounts were supported define a single [account] table instead of an
+// array of [[account]] tables, which is decoded as the only account.
+func decodeConfig(data string, cfg *Config) error {
+	var raw map[string]interface{}
+	if _, err := toml.Decode(data, &raw); err != nil {
+		return err
+	}
+	if _, ok := raw["account"].(map[string]interface{}); !ok {
+		_, err := toml.Decode(data, cfg)
+		return err
+	}
+
+	var legacy struct {
+		Config
+		Account Account `toml:"account"`
+	}
+	if _, err := toml.Decode(data, &legacy); err != nil {
+		return err
+	}
+	*cfg = legacy.Config
+	cfg.Account = []Account{legacy.Account}
+	return nil
+}
+
+// validateQuotes checks that the prices can be quoted in all quotes, i.e. that
+// every quote other than USD is priced itself.
+func validateQuotes(quotes []string, pairs map[string]map[provider.Name]struct{}) error {
+	seen := map[string]struct{}{}
+	for _, quote := range quotes {
+		if _, ok := seen[quote]; ok {
+			return fmt.Errorf("duplicate quote: %s", quote)
+		}
+		seen[quote] = struct{}{}
+		if _, ok := pairs[quote]; !ok && quote != DenomUSD {
+			return fmt.Errorf("quote is not priced by any currency pair: %s", quote)
+		}
+	}
+	return nil
+}
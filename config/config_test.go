@@ -3,7 +3,9 @@ package config_test
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"price-feeder/config"
 	"price-feeder/oracle/provider"
@@ -22,11 +24,13 @@ func TestValidate(t *testing.T) {
 			CurrencyPairs: []config.CurrencyPair{
 				{Base: "ATOM", Quote: "USDT", Providers: []provider.Name{provider.ProviderKraken}},
 			},
-			Account: config.Account{
-				NetworkName:  "testnet",
-				OperatorID:   "0.0.12213",
-				OperatorSeed: "lorem ipsum",
-				TopicID:      "0.0.123",
+			Account: []config.Account{
+				{
+					NetworkName:  "testnet",
+					OperatorID:   "0.0.12213",
+					OperatorSeed: "lorem ipsum",
+					TopicID:      "0.0.123",
+				},
 			},
 
 			Telemetry: config.Telemetry{
@@ -79,6 +83,63 @@ func TestValidate(t *testing.T) {
 		},
 	}
 
+	invalidPublisher := validConfig()
+	invalidPublisher.Publisher = config.Publisher{Type: "foo"}
+
+	fileWithoutPath := validConfig()
+	fileWithoutPath.Publisher = config.Publisher{Type: "file"}
+
+	webhookPublisher := validConfig()
+	webhookPublisher.Publisher = config.Publisher{Type: "webhook", URL: "http://localhost"}
+
+	unknownNetwork := validConfig()
+	unknownNetwork.Account[0].NetworkName = "local"
+
+	customNetwork := validConfig()
+	customNetwork.Account[0].NetworkName = "local"
+	customNetwork.Account[0].Nodes = map[string]string{"127.0.0.1:50211": "0.0.3"}
+
+	invalidEncoding := validConfig()
+	invalidEncoding.Publisher = config.Publisher{Type: "stdout", Encoding: "xml"}
+
+	noKey := validConfig()
+	noKey.Account[0].OperatorSeed = ""
+
+	multipleKeys := validConfig()
+	multipleKeys.Account[0].OperatorKeyEnv = "OPERATOR_KEY"
+
+	keystore := validConfig()
+	keystore.Account[0].OperatorSeed = ""
+	keystore.Account[0].OperatorKeystore = "operator.json"
+
+	invalidKeyType := validConfig()
+	invalidKeyType.Account[0].KeyType = "rsa"
+
+	noAccounts := validConfig()
+	noAccounts.Account = []config.Account{}
+
+	noTopic := validConfig()
+	noTopic.Account[0].TopicID = ""
+
+	stdoutNoAccounts := validConfig()
+	stdoutNoAccounts.Publisher = config.Publisher{Type: "stdout"}
+	stdoutNoAccounts.Account = []config.Account{}
+
+	stdoutNoOperator := validConfig()
+	stdoutNoOperator.Publisher = config.Publisher{Type: "stdout"}
+	stdoutNoOperator.Account = []config.Account{{Name: "local", OperatorKeyEnv: "OPERATOR_KEY"}}
+
+	stdoutNoKey := validConfig()
+	stdoutNoKey.Publisher = config.Publisher{Type: "stdout"}
+	stdoutNoKey.Account = []config.Account{{Name: "local"}}
+
+	stdoutMultipleKeys := validConfig()
+	stdoutMultipleKeys.Publisher = config.Publisher{Type: "stdout"}
+	stdoutMultipleKeys.Account[0].OperatorKeyEnv = "OPERATOR_KEY"
+
+	invalidPauseLevel := validConfig()
+	invalidPauseLevel.Budget.PauseLevel = "ok"
+
 	testCases := []struct {
 		name      string
 		cfg       config.Config
@@ -119,6 +180,91 @@ func TestValidate(t *testing.T) {
 			invalidEndpointsProvider,
 			true,
 		},
+		{
+			"invalid publisher",
+			invalidPublisher,
+			true,
+		},
+		{
+			"file publisher without path",
+			fileWithoutPath,
+			true,
+		},
+		{
+			"webhook publisher",
+			webhookPublisher,
+			false,
+		},
+		{
+			"unknown network",
+			unknownNetwork,
+			true,
+		},
+		{
+			"custom network",
+			customNetwork,
+			false,
+		},
+		{
+			"invalid encoding",
+			invalidEncoding,
+			true,
+		},
+		{
+			"no operator key",
+			noKey,
+			true,
+		},
+		{
+			"multiple operator keys",
+			multipleKeys,
+			true,
+		},
+		{
+			"operator keystore",
+			keystore,
+			false,
+		},
+		{
+			"invalid key type",
+			invalidKeyType,
+			true,
+		},
+		{
+			"no accounts",
+			noAccounts,
+			true,
+		},
+		{
+			"no topic",
+			noTopic,
+			true,
+		},
+		{
+			"stdout publisher without accounts",
+			stdoutNoAccounts,
+			true,
+		},
+		{
+			"stdout publisher without operator",
+			stdoutNoOperator,
+			false,
+		},
+		{
+			"stdout publisher without operator key",
+			stdoutNoKey,
+			true,
+		},
+		{
+			"stdout publisher with multiple operator keys",
+			stdoutMultipleKeys,
+			true,
+		},
+		{
+			"invalid pause level",
+			invalidPauseLevel,
+			true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -177,7 +323,7 @@ providers = ["osmosis"]
 derivative = "twap"
 derivative_period = "30m"
 
-[account]
+[[account]]
 network_name = "testnet"
 operator_id="0.0.5700506"
 operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
@@ -214,6 +360,289 @@ timeout = "200ms"
 	require.Equal(t, provider.ProviderKraken, cfg.CurrencyPairs[0].Providers[0])
 	require.Equal(t, provider.ProviderBinance, cfg.CurrencyPairs[0].Providers[1])
 	require.Equal(t, "twap", cfg.CurrencyPairs[3].Derivative)
+	require.Equal(t, "hcs", cfg.Publisher.Type)
+	require.Equal(t, "json", cfg.Publisher.Encoding)
+	require.Equal(t, 1024, cfg.Publisher.MaxMessageSize)
+}
+
+func TestParseConfig_CustomNetwork(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[[account]]
+network_name = "local"
+nodes = { "127.0.0.1:50211" = "0.0.3" }
+mirror_nodes = ["127.0.0.1:5600"]
+transport_security = false
+operator_id="0.0.2"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.1001"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	cfg, err := config.ParseConfig(tmpFile.Name())
+	require.NoError(t, err)
+
+	network := cfg.Account[0].ToNetwork()
+	require.Equal(t, map[string]string{"127.0.0.1:50211": "0.0.3"}, network.Nodes)
+	require.Equal(t, []string{"127.0.0.1:5600"}, network.MirrorNodes)
+	require.NotNil(t, network.TransportSecurity)
+	require.False(t, *network.TransportSecurity)
+	require.Nil(t, network.VerifyCertificates)
+}
+
+func TestParseConfig_Budget(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.2"
+operator_key_env = "OPERATOR_KEY"
+topic_id="0.0.1001"
+max_tx_fee = "0.5"
+
+[budget]
+warning_balance = "100"
+critical_balance = "10"
+pause_level = "warning"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	cfg, err := config.ParseConfig(tmpFile.Name())
+	require.NoError(t, err)
+
+	maxTxFee, err := cfg.Account[0].ToMaxTxFee()
+	require.NoError(t, err)
+	require.Equal(t, int64(50_000_000), maxTxFee.AsTinybar())
+
+	budgetConfig, err := cfg.Budget.ToBudgetConfig()
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, budgetConfig.CheckInterval)
+	require.Equal(t, int64(10_000_000_000), budgetConfig.Warning.AsTinybar())
+	require.Equal(t, int64(1_000_000_000), budgetConfig.Critical.AsTinybar())
+	require.Equal(t, "warning", budgetConfig.PauseLevel)
+
+	invalid := []byte(strings.Replace(string(content), `warning_balance = "100"`, `warning_balance = "5"`, 1))
+	require.NoError(t, ioutil.WriteFile(tmpFile.Name(), invalid, 0o600))
+	_, err = config.ParseConfig(tmpFile.Name())
+	require.ErrorContains(t, err, "warning balance must not be below the critical balance")
+}
+
+func TestParseConfig_MultipleAccounts(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[mirror_node]
+url = "https://testnet.mirrornode.hedera.com"
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.2"
+operator_key_env = "TESTNET_OPERATOR_KEY"
+topic_id="0.0.1001"
+
+[[account]]
+name = "mainnet"
+network_name = "mainnet"
+operator_id="0.0.3"
+operator_key_env = "MAINNET_OPERATOR_KEY"
+topic_id="0.0.2002"
+vote_period = "1m"
+mirror_node_url = "https://mainnet-public.mirrornode.hedera.com"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	cfg, err := config.ParseConfig(tmpFile.Name())
+	require.NoError(t, err)
+	require.Len(t, cfg.Account, 2)
+
+	require.Equal(t, "0.0.2", cfg.Account[0].Name)
+	require.Equal(t, "10s", cfg.Account[0].VotePeriod)
+	require.Equal(t, "https://testnet.mirrornode.hedera.com", cfg.Account[0].MirrorNodeURL)
+
+	require.Equal(t, "mainnet", cfg.Account[1].Name)
+	require.Equal(t, "1m", cfg.Account[1].VotePeriod)
+	require.Equal(t, "https://mainnet-public.mirrornode.hedera.com", cfg.Account[1].MirrorNodeURL)
+}
+
+func TestParseConfig_LegacyAccount(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[account]
+network_name = "testnet"
+operator_id="0.0.2"
+operator_key_env = "OPERATOR_KEY"
+topic_id="0.0.1001"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	cfg, err := config.ParseConfig(tmpFile.Name())
+	require.NoError(t, err)
+	require.Len(t, cfg.Account, 1)
+	require.Equal(t, "0.0.2", cfg.Account[0].Name)
+	require.Equal(t, "0.0.1001", cfg.Account[0].TopicID)
+	require.Len(t, cfg.CurrencyPairs, 1)
+	require.Equal(t, "10s", cfg.VotePeriod)
+}
+
+func TestParseConfig_DuplicateAccounts(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.2"
+operator_key_env = "OPERATOR_KEY"
+topic_id="0.0.1001"
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.2"
+operator_key_env = "OPERATOR_KEY"
+topic_id="0.0.1002"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	_, err = config.ParseConfig(tmpFile.Name())
+	require.ErrorContains(t, err, "duplicate account name")
+}
+
+func TestParseConfig_PublisherWithoutAccount(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[publisher]
+type = "stdout"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	// envelopes are always signed with a configured key
+	_, err = config.ParseConfig(tmpFile.Name())
+	require.Error(t, err)
+
+	content = append(content, []byte(`
+[[account]]
+operator_key_env = "OPERATOR_KEY"
+`)...)
+	require.NoError(t, os.WriteFile(tmpFile.Name(), content, 0o600))
+
+	cfg, err := config.ParseConfig(tmpFile.Name())
+	require.NoError(t, err)
+	require.Len(t, cfg.Account, 1)
+	require.Equal(t, "default", cfg.Account[0].Name)
+	require.Equal(t, "10s", cfg.Account[0].VotePeriod)
+}
+
+func TestParseConfig_PublishMode(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	content := []byte(`
+vote_period="10s"
+publish_mode = "push_on_deviation"
+
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken"]
+
+[push]
+heartbeat = "5m"
+thresholds = { BTC = "0.1" }
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.2"
+operator_key_env = "OPERATOR_KEY"
+topic_id="0.0.1001"
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.3"
+operator_key_env = "OPERATOR_KEY"
+topic_id="0.0.1002"
+publish_mode = "commit_reveal"
+`)
+	_, err = tmpFile.Write(content)
+	require.NoError(t, err)
+
+	cfg, err := config.ParseConfig(tmpFile.Name())
+	require.NoError(t, err)
+	require.Equal(t, "push_on_deviation", cfg.Account[0].PublishMode)
+	require.Equal(t, "commit_reveal", cfg.Account[1].PublishMode)
+
+	pushConfig, err := cfg.Push.ToPushConfig()
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Minute, pushConfig.Heartbeat)
+	require.Equal(t, "0.500000000000000000", pushConfig.Threshold.String())
+	require.Equal(t, "0.100000000000000000", pushConfig.Thresholds["BTC"].String())
+
+	cfg.Push.Threshold = "-1"
+	_, err = cfg.Push.ToPushConfig()
+	require.Error(t, err)
 }
 
 func TestParseConfig_Valid_NoTelemetry(t *testing.T) {
@@ -257,7 +686,7 @@ providers = [
 	"huobi"
 ]
 
-[account]
+[[account]]
 network_name = "testnet"
 operator_id="0.0.5700506"
 operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
@@ -395,7 +824,7 @@ providers = [
 	"huobi"
 ]
 
-[account]
+[[account]]
 network_name = "testnet"
 operator_id="0.0.5700506"
 operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
@@ -480,7 +909,7 @@ providers = [
 	"huobi"
 ]
 
-[account]
+[[account]]
 network_name = "testnet"
 operator_id="0.0.5700506"
 operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
@@ -501,3 +930,356 @@ global_labels = [["chain-id", "umee-local-testnet"]]
 	_, err = config.ParseConfig(tmpFile.Name())
 	require.Error(t, err)
 }
+
+func TestParseConfig_Aggregations(t *testing.T) {
+	pairs := `
+[[currency_pairs]]
+base = "ATOM"
+quote = "USD"
+providers = ["kraken", "binance", "huobi"]
+
+[[currency_pairs]]
+base = "KUJI"
+quote = "USD"
+providers = ["kraken", "binance", "huobi"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.5700506"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.5700596"
+`
+
+	testCases := []struct {
+		name        string
+		aggregation string
+		expectErr   bool
+	}{
+		{
+			"valid",
+			`
+[[aggregation]]
+denoms = ["ATOM"]
+strategy = "volume_weighted_median"
+
+[[aggregation]]
+denoms = ["KUJI"]
+strategy = "median"
+`,
+			false,
+		},
+		{
+			"unsupported strategy",
+			`
+[[aggregation]]
+denoms = ["ATOM"]
+strategy = "mode"
+`,
+			true,
+		},
+		{
+			"duplicate denom",
+			`
+[[aggregation]]
+denoms = ["ATOM"]
+strategy = "median"
+
+[[aggregation]]
+denoms = ["ATOM"]
+strategy = "trimmed_mean"
+`,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+			require.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			_, err = tmpFile.Write([]byte("vote_period=\"10s\"\n" + tc.aggregation + pairs))
+			require.NoError(t, err)
+
+			cfg, err := config.ParseConfig(tmpFile.Name())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, cfg.Aggregations, 2)
+			require.Equal(t, config.AggregationVolumeWeightedMedian, cfg.Aggregations[0].Strategy)
+			require.Equal(t, []string{"ATOM"}, cfg.Aggregations[0].Denoms)
+		})
+	}
+}
+
+func TestParseConfig_DeviationMethods(t *testing.T) {
+	pairs := `
+[[currency_pairs]]
+base = "ATOM"
+quote = "USD"
+providers = ["kraken", "binance", "huobi"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.5700506"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.5700596"
+`
+
+	testCases := []struct {
+		name      string
+		deviation string
+		expectErr bool
+	}{
+		{"default method", `threshold = "2"`, false},
+		{"mad", "threshold = \"5\"\nmethod = \"mad\"", false},
+		{"percentage", "threshold = \"0.05\"\nmethod = \"percentage\"\nagreement = \"0.01\"", false},
+		{"unsupported method", "threshold = \"2\"\nmethod = \"iqr\"", true},
+		{"stddev too large", "threshold = \"5\"\nmethod = \"stddev\"", true},
+		{"percentage too large", "threshold = \"1.5\"\nmethod = \"percentage\"", true},
+		{"invalid agreement", "threshold = \"2\"\nagreement = \"2\"", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+			require.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			content := "vote_period=\"10s\"\n[[deviation_thresholds]]\nbase = \"ATOM\"\n" + tc.deviation + "\n" + pairs
+			_, err = tmpFile.Write([]byte(content))
+			require.NoError(t, err)
+
+			cfg, err := config.ParseConfig(tmpFile.Name())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotEmpty(t, cfg.Deviations[0].Method)
+		})
+	}
+}
+
+func TestParseConfig_ProviderWeights(t *testing.T) {
+	pairs := `
+[[currency_pairs]]
+base = "ATOM"
+quote = "USD"
+providers = ["kraken", "binance", "huobi"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.5700506"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.5700596"
+`
+
+	testCases := []struct {
+		name      string
+		weights   string
+		expectErr bool
+	}{
+		{
+			"valid",
+			"[[provider_weights]]\nproviders = [\"huobi\"]\nweight = \"0.5\"\n[reputation]\nenabled = true\n",
+			false,
+		},
+		{
+			"zero weight",
+			"[[provider_weights]]\nproviders = [\"huobi\"]\nweight = \"0\"\n",
+			true,
+		},
+		{
+			"unsupported provider",
+			"[[provider_weights]]\nproviders = [\"foo\"]\nweight = \"0.5\"\n",
+			true,
+		},
+		{
+			"duplicate provider",
+			"[[provider_weights]]\nproviders = [\"huobi\"]\nweight = \"0.5\"\n[[provider_weights]]\nproviders = [\"huobi\"]\nweight = \"2\"\n",
+			true,
+		},
+		{
+			"invalid smoothing",
+			"[reputation]\nenabled = true\nsmoothing = \"1.5\"\n",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+			require.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			_, err = tmpFile.Write([]byte("vote_period=\"10s\"\n" + pairs + tc.weights))
+			require.NoError(t, err)
+
+			cfg, err := config.ParseConfig(tmpFile.Name())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "0.5", cfg.ProviderWeights[0].Weight)
+			require.True(t, cfg.Reputation.Enabled)
+			require.Equal(t, "0.1", cfg.Reputation.Smoothing)
+			require.Equal(t, "0.01", cfg.Reputation.Tolerance)
+		})
+	}
+}
+
+func TestParseConfig_VolumeShareCap(t *testing.T) {
+	pairs := `
+[[currency_pairs]]
+base = "ATOM"
+quote = "USD"
+providers = ["kraken", "binance", "huobi"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.5700506"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.5700596"
+`
+
+	testCases := []struct {
+		name      string
+		shareCap  string
+		expectErr bool
+	}{
+		{"valid", "0.4", false},
+		{"no cap", "1", false},
+		{"zero", "0", true},
+		{"above one", "1.5", true},
+		{"not numeric", "half", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+			require.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			content := "vote_period=\"10s\"\nvolume_share_cap=\"" + tc.shareCap + "\"\n" + pairs
+			_, err = tmpFile.Write([]byte(content))
+			require.NoError(t, err)
+
+			cfg, err := config.ParseConfig(tmpFile.Name())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.shareCap, cfg.VolumeShareCap)
+		})
+	}
+}
+
+func TestParseConfig_ConversionTolerance(t *testing.T) {
+	pairs := `
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken", "binance", "huobi"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.5700506"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.5700596"
+`
+
+	testCases := []struct {
+		name      string
+		tolerance string
+		expectErr bool
+	}{
+		{"valid", "0.02", false},
+		{"zero", "0", true},
+		{"one", "1", true},
+		{"not numeric", "2%", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+			require.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			content := "vote_period=\"10s\"\nconversion_tolerance=\"" + tc.tolerance + "\"\n" + pairs
+			_, err = tmpFile.Write([]byte(content))
+			require.NoError(t, err)
+
+			cfg, err := config.ParseConfig(tmpFile.Name())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.tolerance, cfg.ConversionTolerance)
+		})
+	}
+}
+
+func TestParseConfig_Quotes(t *testing.T) {
+	pairs := `
+[[currency_pairs]]
+base = "ATOM"
+quote = "USDT"
+providers = ["kraken", "binance", "huobi"]
+
+[[currency_pairs]]
+base = "HBAR"
+quote = "USDT"
+providers = ["kraken", "binance", "huobi"]
+
+[[account]]
+network_name = "testnet"
+operator_id="0.0.5700506"
+operator_seed = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+topic_id="0.0.5700596"
+`
+
+	testCases := []struct {
+		name      string
+		quotes    string
+		expected  []string
+		expectErr bool
+	}{
+		{"default", "", []string{"USD"}, false},
+		{"multiple", `quotes = ["USD", "HBAR"]`, []string{"USD", "HBAR"}, false},
+		{"duplicate", `quotes = ["HBAR", "HBAR"]`, nil, true},
+		{"not priced", `quotes = ["EUR"]`, nil, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tmpFile, err := ioutil.TempFile("", "price-feeder.toml")
+			require.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			content := "vote_period=\"10s\"\n" + tc.quotes + "\n" + pairs
+			_, err = tmpFile.Write([]byte(content))
+			require.NoError(t, err)
+
+			cfg, err := config.ParseConfig(tmpFile.Name())
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, cfg.Quotes)
+			require.Equal(t, tc.expected, cfg.Account[0].Quotes)
+		})
+	}
+}
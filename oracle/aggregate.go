@@ -0,0 +1,305 @@
+package oracle
+
+import (
+	"fmt"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/config"
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+var (
+	_ Aggregator = VWAPAggregator{}
+	_ Aggregator = MedianAggregator{}
+	_ Aggregator = VolumeWeightedMedianAggregator{}
+	_ Aggregator = TrimmedMeanAggregator{}
+	_ Aggregator = LiquidityWeightedAggregator{}
+
+	// defaultTrimFraction is the share of the lowest and of the highest
+	// prices dropped by the trimmed mean.
+	defaultTrimFraction = sdk.MustNewDecFromStr("0.25")
+)
+
+type (
+	// Aggregator defines a strategy to compute the price of a denom from the
+	// USD prices of its providers which passed the deviation filter. The
+	// volumes of the prices are their USD notional volumes over 24h. The
+	// trust weights scale the contribution of each provider, providers
+	// without a trust weight count fully.
+	Aggregator interface {
+		// Aggregate returns the price and the share of each provider price
+		// in it. Provider prices which don't contribute have no weight.
+		Aggregate(
+			prices map[provider.Name]types.TickerPrice,
+			trust map[provider.Name]sdk.Dec,
+		) (sdk.Dec, map[provider.Name]sdk.Dec, error)
+	}
+
+	// VWAPAggregator computes the average price weighted by the base
+	// volume of each provider, which suits deep markets.
+	VWAPAggregator struct{}
+
+	// MedianAggregator computes the median price, ignoring volumes. It is
+	// robust against single faulty providers of thin markets.
+	MedianAggregator struct{}
+
+	// VolumeWeightedMedianAggregator computes the price at which half of
+	// the volume is traded below and half above.
+	VolumeWeightedMedianAggregator struct{}
+
+	// TrimmedMeanAggregator computes the mean price after dropping the given
+	// fraction of the lowest and of the highest prices.
+	TrimmedMeanAggregator struct {
+		Fraction sdk.Dec
+	}
+
+	// LiquidityWeightedAggregator computes the average price weighted by the
+	// USD notional volume of each provider.
+	LiquidityWeightedAggregator struct{}
+
+	weightedTicker struct {
+		name   provider.Name
+		ticker types.TickerPrice
+		weight sdk.Dec
+	}
+)
+
+// NewAggregator returns the aggregator of the given strategy.
+func NewAggregator(strategy string) (Aggregator, error) {
+	switch strategy {
+	case config.AggregationVWAP:
+		return VWAPAggregator{}, nil
+	case config.AggregationMedian:
+		return MedianAggregator{}, nil
+	case config.AggregationVolumeWeightedMedian:
+		return VolumeWeightedMedianAggregator{}, nil
+	case config.AggregationTrimmedMean:
+		return TrimmedMeanAggregator{Fraction: defaultTrimFraction}, nil
+	case config.AggregationLiquidityWeighted:
+		return LiquidityWeightedAggregator{}, nil
+	}
+	return nil, fmt.Errorf("unsupported aggregation strategy: %s", strategy)
+}
+
+// aggregatorFor returns the aggregator of the denom, VWAP by default.
+func aggregatorFor(aggregators map[string]Aggregator, denom string) Aggregator {
+	if aggregator, ok := aggregators[denom]; ok {
+		return aggregator
+	}
+	return VWAPAggregator{}
+}
+
+func (VWAPAggregator) Aggregate(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	return weightedAverage(prices, trust, baseVolume)
+}
+
+func (MedianAggregator) Aggregate(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	return weightedMedian(prices, trust, func(types.TickerPrice) sdk.Dec {
+		return sdk.OneDec()
+	})
+}
+
+func (VolumeWeightedMedianAggregator) Aggregate(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	return weightedMedian(prices, trust, baseVolume)
+}
+
+func (a TrimmedMeanAggregator) Aggregate(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	tickers := sortedTickers(prices, trust, func(types.TickerPrice) sdk.Dec {
+		return sdk.OneDec()
+	})
+	if len(tickers) == 0 {
+		return sdk.Dec{}, nil, fmt.Errorf("no tickers supplied")
+	}
+
+	fraction := a.Fraction
+	if fraction.IsNil() {
+		fraction = defaultTrimFraction
+	}
+	trim := fraction.MulInt64(int64(len(tickers))).TruncateInt64()
+	if 2*trim >= int64(len(tickers)) {
+		trim = int64(len(tickers)-1) / 2
+	}
+
+	kept := make(map[provider.Name]types.TickerPrice, len(tickers))
+	for _, t := range tickers[trim : int64(len(tickers))-trim] {
+		kept[t.name] = t.ticker
+	}
+	price, keptWeights, err := weightedAverage(kept, trust, func(types.TickerPrice) sdk.Dec {
+		return sdk.OneDec()
+	})
+	if err != nil {
+		return sdk.Dec{}, nil, err
+	}
+
+	weights := zeroWeights(prices)
+	for name, weight := range keptWeights {
+		weights[name] = weight
+	}
+	return price, weights, nil
+}
+
+func (LiquidityWeightedAggregator) Aggregate(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	return weightedAverage(prices, trust, func(ticker types.TickerPrice) sdk.Dec {
+		return ticker.Volume
+	})
+}
+
+// trustOf returns the trust weight of the provider, 1 by default.
+func trustOf(trust map[provider.Name]sdk.Dec, name provider.Name) sdk.Dec {
+	if weight, ok := trust[name]; ok && !weight.IsNil() {
+		return weight
+	}
+	return sdk.OneDec()
+}
+
+// sortedTickers returns the provider prices sorted by price along with their
+// weight times their trust, ties are sorted by provider to keep the result
+// deterministic.
+func sortedTickers(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+	weight func(types.TickerPrice) sdk.Dec,
+) []weightedTicker {
+	tickers := make([]weightedTicker, 0, len(prices))
+	for name, ticker := range prices {
+		tickers = append(tickers, weightedTicker{
+			name:   name,
+			ticker: ticker,
+			weight: weight(ticker).Mul(trustOf(trust, name)),
+		})
+	}
+	sort.Slice(tickers, func(i, j int) bool {
+		if tickers[i].ticker.Price.Equal(tickers[j].ticker.Price) {
+			return tickers[i].name < tickers[j].name
+		}
+		return tickers[i].ticker.Price.LT(tickers[j].ticker.Price)
+	})
+	return tickers
+}
+
+// weightedAverage returns the average of the prices weighted by their weight
+// times their trust. If the weights sum up to zero, e.g. because there is no
+// volume at all, the prices are only weighted by their trust.
+func weightedAverage(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+	weight func(types.TickerPrice) sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	if len(prices) == 0 {
+		return sdk.Dec{}, nil, fmt.Errorf("no tickers supplied")
+	}
+
+	weights := make(map[provider.Name]sdk.Dec, len(prices))
+	weightSum := sdk.ZeroDec()
+	for name, ticker := range prices {
+		weights[name] = weight(ticker).Mul(trustOf(trust, name))
+		weightSum = weightSum.Add(weights[name])
+	}
+	if weightSum.IsZero() {
+		for name := range prices {
+			weights[name] = trustOf(trust, name)
+			weightSum = weightSum.Add(weights[name])
+		}
+	}
+	if weightSum.IsZero() {
+		return sdk.Dec{}, nil, fmt.Errorf("no trusted tickers supplied")
+	}
+
+	weightedPrice := sdk.ZeroDec()
+	for name, ticker := range prices {
+		weightedPrice = weightedPrice.Add(ticker.Price.Mul(weights[name]))
+	}
+	for name := range weights {
+		weights[name] = weights[name].Quo(weightSum)
+	}
+	return weightedPrice.Quo(weightSum), weights, nil
+}
+
+// weightedMedian returns the price at which half of the weight, which is the
+// weight of a price times its trust, lies below and half above. If exactly
+// half of the weight lies at or below a price, the median is the mean of it
+// and the next price. If the weights sum up to zero, the prices are only
+// weighted by their trust.
+func weightedMedian(
+	prices map[provider.Name]types.TickerPrice,
+	trust map[provider.Name]sdk.Dec,
+	weight func(types.TickerPrice) sdk.Dec,
+) (sdk.Dec, map[provider.Name]sdk.Dec, error) {
+	all := sortedTickers(prices, trust, weight)
+	if len(all) == 0 {
+		return sdk.Dec{}, nil, fmt.Errorf("no tickers supplied")
+	}
+
+	weightSum := sdk.ZeroDec()
+	for _, t := range all {
+		weightSum = weightSum.Add(t.weight)
+	}
+	if weightSum.IsZero() {
+		all = sortedTickers(prices, trust, func(types.TickerPrice) sdk.Dec {
+			return sdk.OneDec()
+		})
+		for _, t := range all {
+			weightSum = weightSum.Add(t.weight)
+		}
+	}
+	if weightSum.IsZero() {
+		return sdk.Dec{}, nil, fmt.Errorf("no trusted tickers supplied")
+	}
+
+	// prices without weight can't be the median
+	tickers := make([]weightedTicker, 0, len(all))
+	for _, t := range all {
+		if t.weight.IsPositive() {
+			tickers = append(tickers, t)
+		}
+	}
+
+	weights := zeroWeights(prices)
+	half := weightSum.QuoInt64(2)
+	cumulative := sdk.ZeroDec()
+	for i, t := range tickers {
+		cumulative = cumulative.Add(t.weight)
+		if cumulative.LT(half) {
+			continue
+		}
+		if cumulative.Equal(half) && i+1 < len(tickers) {
+			next := tickers[i+1]
+			weights[t.name] = sdk.NewDecWithPrec(5, 1)
+			weights[next.name] = sdk.NewDecWithPrec(5, 1)
+			return t.ticker.Price.Add(next.ticker.Price).QuoInt64(2), weights, nil
+		}
+		weights[t.name] = sdk.OneDec()
+		return t.ticker.Price, weights, nil
+	}
+
+	// only reached due to rounding, the cumulative weight reaches the total
+	last := tickers[len(tickers)-1]
+	weights[last.name] = sdk.OneDec()
+	return last.ticker.Price, weights, nil
+}
+
+func zeroWeights(prices map[provider.Name]types.TickerPrice) map[provider.Name]sdk.Dec {
+	weights := make(map[provider.Name]sdk.Dec, len(prices))
+	for name := range prices {
+		weights[name] = sdk.ZeroDec()
+	}
+	return weights
+}
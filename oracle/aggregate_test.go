@@ -0,0 +1,186 @@
+package oracle
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/config"
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+func ticker(price, volume string) types.TickerPrice {
+	return types.TickerPrice{
+		Price:  sdk.MustNewDecFromStr(price),
+		Volume: sdk.MustNewDecFromStr(volume),
+	}
+}
+
+func TestAggregators(t *testing.T) {
+	// USD notional volumes of 100, 10, 1 and 10 traded denoms
+	prices := map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance:  ticker("10", "1000"),
+		provider.ProviderKraken:   ticker("11", "110"),
+		provider.ProviderOsmosis:  ticker("13", "13"),
+		provider.ProviderCoinbase: ticker("12", "120"),
+	}
+
+	testCases := []struct {
+		strategy string
+		price    string
+		weights  map[provider.Name]string
+	}{
+		{
+			config.AggregationVWAP,
+			"10.272727272727272727",
+			map[provider.Name]string{
+				provider.ProviderBinance:  "0.826446280991735537",
+				provider.ProviderKraken:   "0.082644628099173554",
+				provider.ProviderOsmosis:  "0.008264462809917355",
+				provider.ProviderCoinbase: "0.082644628099173554",
+			},
+		},
+		{
+			config.AggregationMedian,
+			"11.5",
+			map[provider.Name]string{
+				provider.ProviderBinance:  "0",
+				provider.ProviderKraken:   "0.5",
+				provider.ProviderOsmosis:  "0",
+				provider.ProviderCoinbase: "0.5",
+			},
+		},
+		{
+			config.AggregationVolumeWeightedMedian,
+			"10",
+			map[provider.Name]string{
+				provider.ProviderBinance:  "1",
+				provider.ProviderKraken:   "0",
+				provider.ProviderOsmosis:  "0",
+				provider.ProviderCoinbase: "0",
+			},
+		},
+		{
+			config.AggregationTrimmedMean,
+			"11.5",
+			map[provider.Name]string{
+				provider.ProviderBinance:  "0",
+				provider.ProviderKraken:   "0.5",
+				provider.ProviderOsmosis:  "0",
+				provider.ProviderCoinbase: "0.5",
+			},
+		},
+		{
+			config.AggregationLiquidityWeighted,
+			"10.312952534191472245",
+			map[provider.Name]string{
+				provider.ProviderBinance:  "0.804505229283990346",
+				provider.ProviderKraken:   "0.088495575221238938",
+				provider.ProviderOsmosis:  "0.010458567980691874",
+				provider.ProviderCoinbase: "0.096540627514078842",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.strategy, func(t *testing.T) {
+			aggregator, err := NewAggregator(tc.strategy)
+			require.NoError(t, err)
+
+			price, weights, err := aggregator.Aggregate(prices, nil)
+			require.NoError(t, err)
+			require.Equal(t, sdk.MustNewDecFromStr(tc.price), price)
+			require.Len(t, weights, len(tc.weights))
+			for name, weight := range tc.weights {
+				require.Equal(t, sdk.MustNewDecFromStr(weight), weights[name], name)
+			}
+		})
+	}
+
+	_, err := NewAggregator("mode")
+	require.Error(t, err)
+}
+
+func TestAggregators_Edges(t *testing.T) {
+	for strategy := range config.SupportedAggregations {
+		aggregator, err := NewAggregator(strategy)
+		require.NoError(t, err)
+
+		_, _, err = aggregator.Aggregate(map[provider.Name]types.TickerPrice{}, nil)
+		require.Error(t, err, strategy)
+
+		// a single price is always the aggregated price
+		price, weights, err := aggregator.Aggregate(map[provider.Name]types.TickerPrice{
+			provider.ProviderBinance: ticker("10", "0"),
+		}, nil)
+		require.NoError(t, err, strategy)
+		require.Equal(t, sdk.MustNewDecFromStr("10"), price, strategy)
+		require.Equal(t, sdk.OneDec(), weights[provider.ProviderBinance], strategy)
+	}
+
+	// half of the volume is traded at or below 10
+	price, _, err := VolumeWeightedMedianAggregator{}.Aggregate(map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: ticker("10", "50"),
+		provider.ProviderKraken:  ticker("12", "60"),
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("11"), price)
+
+	// without volume the median is used
+	price, _, err = VolumeWeightedMedianAggregator{}.Aggregate(map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: ticker("10", "0"),
+		provider.ProviderKraken:  ticker("12", "0"),
+		provider.ProviderOsmosis: ticker("20", "0"),
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("12"), price)
+}
+
+func TestConvertTickersToUSD_Aggregators(t *testing.T) {
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderBinance: {
+			"ATOMUSD": ticker("10", "1000"),
+			"OSMOUSD": ticker("1", "1000"),
+		},
+		provider.ProviderKraken: {
+			"ATOMUSD": ticker("10.2", "1"),
+			"OSMOUSD": ticker("1.2", "1"),
+		},
+		provider.ProviderOsmosis: {
+			"ATOMUSD": ticker("10.4", "1"),
+			"OSMOUSD": ticker("1.1", "1"),
+		},
+	}
+	atomUsd := types.CurrencyPair{Base: "ATOM", Quote: "USD"}
+	osmoUsd := types.CurrencyPair{Base: "OSMO", Quote: "USD"}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderBinance: {atomUsd, osmoUsd},
+		provider.ProviderKraken:  {atomUsd, osmoUsd},
+		provider.ProviderOsmosis: {atomUsd, osmoUsd},
+	}
+
+	rates, _, err := convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters: map[string]DeviationFilter{
+				"ATOM": {Threshold: sdk.MustNewDecFromStr("3")},
+				"OSMO": {Threshold: sdk.MustNewDecFromStr("3")},
+			},
+			providerMinOverrides: map[string]int{},
+			aggregators:          map[string]Aggregator{"OSMO": MedianAggregator{}},
+			now:                  time.Now(),
+		},
+	)
+	require.NoError(t, err)
+
+	// ATOM uses the default VWAP, OSMO the median
+	require.Equal(t, sdk.MustNewDecFromStr("10.000598802395209581"), rates["ATOM"])
+	require.Equal(t, sdk.MustNewDecFromStr("1.1"), rates["OSMO"])
+}
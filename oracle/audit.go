@@ -0,0 +1,162 @@
+package oracle
+
+import (
+	"sort"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+// auditPruneInterval is how often audits older than the retention are
+// pruned, or once per retention if it is shorter.
+const auditPruneInterval = time.Hour
+
+// priceAudit collects the explanations of all prices computed in a tick. A
+// nil priceAudit records nothing.
+type priceAudit struct {
+	time    time.Time
+	denoms  map[string]*types.PriceAudit
+	sources map[string]map[provider.Name]int
+}
+
+func newPriceAudit(now time.Time) *priceAudit {
+	return &priceAudit{
+		time:    now,
+		denoms:  map[string]*types.PriceAudit{},
+		sources: map[string]map[provider.Name]int{},
+	}
+}
+
+func (a *priceAudit) denom(denom string) *types.PriceAudit {
+	audit, ok := a.denoms[denom]
+	if !ok {
+		audit = &types.PriceAudit{
+			Denom:   denom,
+			Time:    a.time,
+			Sources: []types.AuditSource{},
+		}
+		a.denoms[denom] = audit
+		a.sources[denom] = map[provider.Name]int{}
+	}
+	return audit
+}
+
+// addSource records the provider price of the denom, the symbols it was
+// converted along and the USD rate of its quote. Only the first price of a
+// provider is used for a denom, i.e. the one of its best path, any further
+// ones are recorded as filtered.
+func (a *priceAudit) addSource(
+	denom string,
+	path []string,
+	providerName provider.Name,
+	ticker types.TickerPrice,
+	rate sdk.Dec,
+) {
+	if a == nil {
+		return
+	}
+
+	audit := a.denom(denom)
+	source := types.AuditSource{
+		Provider: providerName.String(),
+		Path:     path,
+		Price:    ticker.Price,
+		Rate:     rate,
+		USDPrice: ticker.Price.Mul(rate),
+		Volume:   ticker.Volume,
+		Weight:   sdk.ZeroDec(),
+	}
+
+	if _, found := a.sources[denom][providerName]; found {
+		source.Filtered = "provider priced on a better path"
+	} else {
+		a.sources[denom][providerName] = len(audit.Sources)
+	}
+	audit.Sources = append(audit.Sources, source)
+}
+
+// filter records that the USD price of the provider was filtered.
+func (a *priceAudit) filter(denom string, providerName provider.Name, reason string) {
+	if a == nil {
+		return
+	}
+	audit := a.denom(denom)
+	if i, found := a.sources[denom][providerName]; found {
+		audit.Sources[i].Filtered = reason
+	}
+}
+
+// fail records why no price could be computed for the denom.
+func (a *priceAudit) fail(denom string, reason string) {
+	if a == nil {
+		return
+	}
+	a.denom(denom).Error = reason
+}
+
+// result records the price of the denom and the weights of the provider
+// prices it was computed from.
+func (a *priceAudit) result(denom string, price sdk.Dec, weights map[provider.Name]sdk.Dec) {
+	if a == nil {
+		return
+	}
+	audit := a.denom(denom)
+	audit.Price = price
+	for providerName, weight := range weights {
+		if i, found := a.sources[denom][providerName]; found {
+			audit.Sources[i].Weight = weight
+		}
+	}
+}
+
+// audits returns the explanations of all denoms sorted by denom, with their
+// sources sorted by provider.
+func (a *priceAudit) audits() []types.PriceAudit {
+	if a == nil {
+		return nil
+	}
+	audits := make([]types.PriceAudit, 0, len(a.denoms))
+	for _, audit := range a.denoms {
+		sort.SliceStable(audit.Sources, func(i, j int) bool {
+			return audit.Sources[i].Provider < audit.Sources[j].Provider
+		})
+		audits = append(audits, *audit)
+	}
+	sort.Slice(audits, func(i, j int) bool {
+		return audits[i].Denom < audits[j].Denom
+	})
+	return audits
+}
+
+// storeAudits persists the explanations of the prices computed in a tick and
+// periodically prunes the ones older than the audit retention.
+func (o *Oracle) storeAudits(audit *priceAudit) {
+	if audit == nil {
+		return
+	}
+	if err := o.history.AddPriceAudits(audit.audits()); err != nil {
+		o.logger.Warn().Err(err).Msg("failed to store price audits")
+	}
+
+	interval := auditPruneInterval
+	if o.auditRetention < interval {
+		interval = o.auditRetention
+	}
+	if audit.time.Sub(o.auditsPruned) < interval {
+		return
+	}
+	if err := o.history.PrunePriceAudits(audit.time.Add(-o.auditRetention)); err != nil {
+		o.logger.Warn().Err(err).Msg("failed to prune price audits")
+		return
+	}
+	o.auditsPruned = audit.time
+}
+
+// GetPriceAudits returns the latest explanations of the computed prices of
+// the denom, newest first.
+func (o *Oracle) GetPriceAudits(denom string, limit int) ([]types.PriceAudit, error) {
+	return o.history.GetPriceAudits(denom, limit)
+}
@@ -0,0 +1,147 @@
+package oracle
+
+import (
+	"testing"
+	"time"
+
+	"price-feeder/oracle/history"
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertTickersToUSDAudit(t *testing.T) {
+	btcUsdt := types.CurrencyPair{Base: "BTC", Quote: "USDT"}
+	usdtUsd := types.CurrencyPair{Base: "USDT", Quote: "USD"}
+
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderKraken: {
+			"BTCUSDT": {Price: sdk.MustNewDecFromStr("30000"), Volume: sdk.MustNewDecFromStr("10")},
+		},
+		provider.ProviderKucoin: {
+			"BTCUSDT": {Price: sdk.MustNewDecFromStr("30020"), Volume: sdk.MustNewDecFromStr("30")},
+		},
+		provider.ProviderCoinbase: {
+			"BTCUSDT": {Price: sdk.MustNewDecFromStr("30450"), Volume: sdk.MustNewDecFromStr("10000")},
+			"USDTUSD": {Price: sdk.MustNewDecFromStr("1"), Volume: sdk.MustNewDecFromStr("10000")},
+		},
+	}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderKraken:   {btcUsdt},
+		provider.ProviderKucoin:   {btcUsdt},
+		provider.ProviderCoinbase: {btcUsdt, usdtUsd},
+	}
+
+	now := time.Now()
+	audit := newPriceAudit(now)
+	_, _, err := convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: map[string]int{"USDT": 1, "BTC": 1},
+			now:                  time.Now(),
+			audit:                audit,
+		},
+	)
+	require.NoError(t, err)
+
+	audits := audit.audits()
+	require.Len(t, audits, 2)
+
+	btc := audits[0]
+	require.Equal(t, "BTC", btc.Denom)
+	require.Equal(t, now, btc.Time)
+	require.Equal(t, sdk.MustNewDecFromStr("30015"), btc.Price)
+	require.Empty(t, btc.Error)
+	require.Len(t, btc.Sources, 3)
+
+	coinbase := btc.Sources[0]
+	require.Equal(t, provider.ProviderCoinbase.String(), coinbase.Provider)
+	require.Equal(t, []string{"BTCUSDT", "USDTUSD"}, coinbase.Path)
+	require.Equal(t, sdk.OneDec(), coinbase.Rate)
+	require.Equal(t, "deviating price", coinbase.Filtered)
+	require.True(t, coinbase.Weight.IsZero())
+
+	kraken := btc.Sources[1]
+	require.Equal(t, provider.ProviderKraken.String(), kraken.Provider)
+	require.Empty(t, kraken.Filtered)
+	require.Equal(t, sdk.MustNewDecFromStr("0.25"), kraken.Weight)
+
+	kucoin := btc.Sources[2]
+	require.Equal(t, provider.ProviderKucoin.String(), kucoin.Provider)
+	require.Equal(t, sdk.MustNewDecFromStr("30020"), kucoin.USDPrice)
+	require.Equal(t, sdk.MustNewDecFromStr("0.75"), kucoin.Weight)
+
+	usdt := audits[1]
+	require.Equal(t, "USDT", usdt.Denom)
+	require.Equal(t, sdk.OneDec(), usdt.Price)
+	require.Len(t, usdt.Sources, 1)
+	require.Equal(t, []string{"USDTUSD"}, usdt.Sources[0].Path)
+	require.Equal(t, sdk.OneDec(), usdt.Sources[0].Weight)
+}
+
+func TestConvertTickersToUSDAuditNotEnoughTickers(t *testing.T) {
+	audit := newPriceAudit(time.Now())
+	rates, _, err := convertTickersToUSD(
+		zerolog.Nop(),
+		provider.AggregatedProviderPrices{
+			provider.ProviderKraken: {
+				"ATOMUSD": {Price: sdk.MustNewDecFromStr("10"), Volume: sdk.MustNewDecFromStr("1")},
+			},
+		},
+		map[provider.Name][]types.CurrencyPair{
+			provider.ProviderKraken: {{Base: "ATOM", Quote: "USD"}},
+		},
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: map[string]int{"ATOM": 2},
+			now:                  time.Now(),
+			audit:                audit,
+		},
+	)
+	require.NoError(t, err)
+	require.Empty(t, rates)
+
+	audits := audit.audits()
+	require.Len(t, audits, 1)
+	require.Equal(t, "not enough tickers", audits[0].Error)
+	require.True(t, audits[0].Price.IsNil())
+	require.Len(t, audits[0].Sources, 1)
+}
+
+func TestStoreAudits_prune(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	o := &Oracle{
+		logger:         zerolog.Nop(),
+		history:        h,
+		auditRetention: 2 * time.Hour,
+	}
+	store := func(now time.Time) {
+		audit := newPriceAudit(now)
+		audit.result("ATOM", sdk.OneDec(), nil)
+		o.storeAudits(audit)
+	}
+	count := func() int {
+		audits, err := h.GetPriceAudits("ATOM", 10)
+		require.NoError(t, err)
+		return len(audits)
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	store(now)
+	require.NoError(t, h.AddPriceAudits([]types.PriceAudit{{Denom: "ATOM", Time: now.Add(-3 * time.Hour)}}))
+
+	// expired audits are kept until the prune interval elapsed
+	store(now.Add(30 * time.Minute))
+	require.Equal(t, 3, count())
+
+	store(now.Add(time.Hour))
+	require.Equal(t, 3, count())
+}
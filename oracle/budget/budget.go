@@ -0,0 +1,208 @@
+package budget
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+)
+
+const (
+	LevelOK       = "ok"
+	LevelWarning  = "warning"
+	LevelCritical = "critical"
+)
+
+// SupportedPauseLevels defines the balance levels non-essential publishing
+// can be paused at.
+var SupportedPauseLevels = map[string]struct{}{
+	LevelWarning:  {},
+	LevelCritical: {},
+}
+
+// levelValues defines the gauge values of the balance levels.
+var levelValues = map[string]int{
+	LevelOK:       0,
+	LevelWarning:  1,
+	LevelCritical: 2,
+}
+
+type (
+	// Account defines the account paying for the submitted messages.
+	Account interface {
+		GetBalance() (hedera.Hbar, error)
+	}
+
+	// Config defines the balance thresholds of the Monitor. A zero
+	// threshold is disabled.
+	Config struct {
+		CheckInterval time.Duration
+		Warning       hedera.Hbar
+		Critical      hedera.Hbar
+		// PauseLevel is the level at and above which non-essential
+		// publishing is paused.
+		PauseLevel string
+	}
+
+	// Monitor periodically checks the balance of the operator account and
+	// sums up the fees charged for submitted messages. Once the balance
+	// drops below the configured pause level, non-essential publishing is
+	// paused until the account is funded again. Its metrics are labeled
+	// with the publish target the account pays for.
+	Monitor struct {
+		logger  zerolog.Logger
+		target  string
+		account Account
+		cfg     Config
+
+		mtx     sync.RWMutex
+		balance hedera.Hbar
+		fees    hedera.Hbar
+		level   string
+	}
+)
+
+// NewMonitor returns a new Monitor for the given account of a publish target.
+func NewMonitor(logger zerolog.Logger, target string, account Account, cfg Config) *Monitor {
+	return &Monitor{
+		logger:  logger.With().Str("module", "budget").Logger(),
+		target:  target,
+		account: account,
+		cfg:     cfg,
+		level:   LevelOK,
+	}
+}
+
+// Start checks the balance in the configured interval until the context is
+// cancelled.
+func (m *Monitor) Start(ctx context.Context) error {
+	m.check()
+
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// AddFee adds the fee charged for a submitted message to the cumulative
+// fees. It is a no-op on a nil Monitor.
+func (m *Monitor) AddFee(fee hedera.Hbar) {
+	if m == nil {
+		return
+	}
+
+	m.mtx.Lock()
+	m.fees = hedera.HbarFromTinybar(m.fees.AsTinybar() + fee.AsTinybar())
+	fees := m.fees
+	m.mtx.Unlock()
+
+	telemetry.SetGaugeWithLabels([]string{"operator", "fees"}, float32(fees.As(hedera.HbarUnits.Hbar)), m.labels())
+}
+
+// Fees returns the cumulative fees charged since the start.
+func (m *Monitor) Fees() hedera.Hbar {
+	if m == nil {
+		return hedera.ZeroHbar
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.fees
+}
+
+// Balance returns the balance of the last check.
+func (m *Monitor) Balance() hedera.Hbar {
+	if m == nil {
+		return hedera.ZeroHbar
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.balance
+}
+
+// Level returns the level of the last checked balance.
+func (m *Monitor) Level() string {
+	if m == nil {
+		return LevelOK
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	return m.level
+}
+
+// Paused reports whether non-essential publishing is paused. A nil Monitor
+// never pauses publishing.
+func (m *Monitor) Paused() bool {
+	switch m.Level() {
+	case LevelCritical:
+		return m.cfg.PauseLevel == LevelCritical || m.cfg.PauseLevel == LevelWarning
+	case LevelWarning:
+		return m.cfg.PauseLevel == LevelWarning
+	default:
+		return false
+	}
+}
+
+func (m *Monitor) check() {
+	balance, err := m.account.GetBalance()
+	if err != nil {
+		m.logger.Warn().Err(err).Msg("failed to query operator balance")
+		telemetry.IncrCounterWithLabels([]string{"failure", "balance"}, 1, m.labels())
+		return
+	}
+
+	level := m.levelOf(balance)
+
+	m.mtx.Lock()
+	previous := m.level
+	m.balance = balance
+	m.level = level
+	m.mtx.Unlock()
+
+	labels := m.labels()
+	telemetry.SetGaugeWithLabels([]string{"operator", "balance"}, float32(balance.As(hedera.HbarUnits.Hbar)), labels)
+	telemetry.SetGaugeWithLabels([]string{"operator", "balance", "level"}, float32(levelValues[level]), labels)
+
+	if level == previous {
+		return
+	}
+
+	event := m.logger.Info()
+	if level != LevelOK {
+		event = m.logger.Warn()
+	}
+	event.
+		Str("balance", balance.String()).
+		Str("level", level).
+		Bool("paused", m.Paused()).
+		Msg("operator balance level changed")
+}
+
+func (m *Monitor) labels() []metrics.Label {
+	return []metrics.Label{telemetry.NewLabel("target", m.target)}
+}
+
+func (m *Monitor) levelOf(balance hedera.Hbar) string {
+	tinybar := balance.AsTinybar()
+	switch {
+	case m.cfg.Critical.AsTinybar() > 0 && tinybar <= m.cfg.Critical.AsTinybar():
+		return LevelCritical
+	case m.cfg.Warning.AsTinybar() > 0 && tinybar <= m.cfg.Warning.AsTinybar():
+		return LevelWarning
+	default:
+		return LevelOK
+	}
+}
@@ -0,0 +1,84 @@
+package budget
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+type mockAccount struct {
+	balance hedera.Hbar
+	err     error
+}
+
+func (a *mockAccount) GetBalance() (hedera.Hbar, error) {
+	return a.balance, a.err
+}
+
+func TestMonitor(t *testing.T) {
+	account := &mockAccount{balance: hedera.NewHbar(200)}
+	monitor := NewMonitor(zerolog.Nop(), "test", account, Config{
+		Warning:    hedera.NewHbar(100),
+		Critical:   hedera.NewHbar(10),
+		PauseLevel: LevelCritical,
+	})
+
+	monitor.check()
+	require.Equal(t, LevelOK, monitor.Level())
+	require.Equal(t, hedera.NewHbar(200), monitor.Balance())
+	require.False(t, monitor.Paused())
+
+	account.balance = hedera.NewHbar(50)
+	monitor.check()
+	require.Equal(t, LevelWarning, monitor.Level())
+	require.False(t, monitor.Paused())
+
+	account.balance = hedera.NewHbar(10)
+	monitor.check()
+	require.Equal(t, LevelCritical, monitor.Level())
+	require.True(t, monitor.Paused())
+
+	// failed checks keep the last level
+	account.err = fmt.Errorf("unavailable")
+	monitor.check()
+	require.Equal(t, LevelCritical, monitor.Level())
+
+	account.err = nil
+	account.balance = hedera.NewHbar(1000)
+	monitor.check()
+	require.Equal(t, LevelOK, monitor.Level())
+	require.False(t, monitor.Paused())
+
+	monitor.AddFee(hedera.HbarFromTinybar(100))
+	monitor.AddFee(hedera.HbarFromTinybar(20))
+	require.Equal(t, int64(120), monitor.Fees().AsTinybar())
+}
+
+func TestMonitor_pauseLevel(t *testing.T) {
+	account := &mockAccount{balance: hedera.NewHbar(50)}
+	monitor := NewMonitor(zerolog.Nop(), "test", account, Config{
+		Warning:    hedera.NewHbar(100),
+		PauseLevel: LevelWarning,
+	})
+
+	monitor.check()
+	require.Equal(t, LevelWarning, monitor.Level())
+	require.True(t, monitor.Paused())
+
+	// without thresholds the balance is only reported
+	monitor = NewMonitor(zerolog.Nop(), "test", account, Config{PauseLevel: LevelWarning})
+	monitor.check()
+	require.Equal(t, LevelOK, monitor.Level())
+	require.False(t, monitor.Paused())
+}
+
+func TestMonitor_nil(t *testing.T) {
+	var monitor *Monitor
+	monitor.AddFee(hedera.NewHbar(1))
+	require.False(t, monitor.Paused())
+	require.Equal(t, LevelOK, monitor.Level())
+	require.Equal(t, hedera.ZeroHbar, monitor.Fees())
+}
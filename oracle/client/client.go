@@ -2,9 +2,7 @@ package client
 
 import (
 	"context"
-	"fmt"
 	"github.com/hashgraph/hedera-sdk-go/v2"
-	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -17,7 +15,11 @@ type (
 		NetworkName     string
 		HederaClient    *hedera.Client
 		OperatorAccount hedera.AccountID
+		OperatorKey     hedera.PrivateKey
 		VotePeriod      time.Duration
+		// MaxTxFee caps the fee of every submitted message, the SDK default
+		// applies if zero.
+		MaxTxFee hedera.Hbar
 		//ChainHeight     *ChainHeight
 		topicID hedera.TopicID
 	}
@@ -26,14 +28,15 @@ type (
 func NewOracleClient(
 	ctx context.Context,
 	logger zerolog.Logger,
-	networkName string,
+	network Network,
 	operatorID string,
-	operatorSeed string,
+	operatorKey hedera.PrivateKey,
 	topicID string,
+	maxTxFee hedera.Hbar,
 	votePeriod time.Duration,
 	heightPollInterval time.Duration,
 ) (OracleClient, error) {
-	hederaClient, err := hedera.ClientForName(networkName)
+	hederaClient, err := NewHederaClient(network)
 	if err != nil {
 		return OracleClient{}, err
 	}
@@ -41,14 +44,6 @@ func NewOracleClient(
 	if err != nil {
 		return OracleClient{}, err
 	}
-	mnemonic, err := hedera.NewMnemonic(strings.Split(operatorSeed, " "))
-	if err != nil {
-		return OracleClient{}, err
-	}
-	operatorKey, err := mnemonic.ToStandardEd25519PrivateKey("", 0)
-	if err != nil {
-		return OracleClient{}, err
-	}
 	hederaClient.SetOperator(operatorAccountID, operatorKey)
 	topicIDParsed, err := hedera.TopicIDFromString(topicID)
 	if err != nil {
@@ -57,10 +52,12 @@ func NewOracleClient(
 
 	oracleClient := OracleClient{
 		Logger:          logger.With().Str("module", "oracle_client").Logger(),
-		NetworkName:     networkName,
+		NetworkName:     network.Name,
 		HederaClient:    hederaClient,
 		OperatorAccount: operatorAccountID,
+		OperatorKey:     operatorKey,
 		VotePeriod:      votePeriod,
+		MaxTxFee:        maxTxFee,
 		topicID:         topicIDParsed,
 	}
 
@@ -73,22 +70,50 @@ func NewOracleClient(
 
 	return oracleClient, nil
 }
-func (oc *OracleClient) PutTx(content []byte) error {
-	submitTxn, err := hedera.NewTopicMessageSubmitTransaction().
+
+// NewTransactionID returns a new id for a transaction paid by the operator.
+func (oc *OracleClient) NewTransactionID() hedera.TransactionID {
+	return hedera.TransactionIDGenerate(oc.OperatorAccount)
+}
+
+// PutTx submits a message to the topic under the given transaction id and
+// returns it. Submitting a message again under the same id fails with a
+// duplicate transaction status, if the first submission reached the network.
+func (oc *OracleClient) PutTx(content []byte, txID hedera.TransactionID) (hedera.TransactionID, error) {
+	txn := hedera.NewTopicMessageSubmitTransaction().
+		SetTransactionID(txID).
 		// The message we are submitting
 		SetMessage(content).
 		// To which topic ID
-		SetTopicID(oc.topicID).
-		Execute(oc.HederaClient)
+		SetTopicID(oc.topicID)
+	if oc.MaxTxFee.AsTinybar() > 0 {
+		txn.SetMaxTransactionFee(oc.MaxTxFee)
+	}
 
+	submitTxn, err := txn.Execute(oc.HederaClient)
 	if err != nil {
-		panic(fmt.Sprintf("%v : error submitting topic", err))
-		return err
-
+		oc.Logger.Warn().
+			Err(err).
+			Str("TopicID", oc.topicID.String()).
+			Bool("retryable", IsRetryable(err)).
+			Msg("error submitting topic message")
+		return hedera.TransactionID{}, err
 	}
 	oc.Logger.Debug().Bytes("hash", submitTxn.Hash).
 		Str("TopicID", oc.topicID.String()).
+		Str("TransactionID", submitTxn.TransactionID.String()).
 		Msg("Submitted TXN to topic")
 
-	return nil
+	return submitTxn.TransactionID, nil
+}
+
+// GetBalance returns the balance of the operator account.
+func (oc *OracleClient) GetBalance() (hedera.Hbar, error) {
+	balance, err := hedera.NewAccountBalanceQuery().
+		SetAccountID(oc.OperatorAccount).
+		Execute(oc.HederaClient)
+	if err != nil {
+		return hedera.ZeroHbar, err
+	}
+	return balance.Hbars, nil
 }
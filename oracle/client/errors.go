@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// retryableStatus lists the Hedera response codes that describe a transient
+// condition of the network rather than a problem with the transaction itself.
+var retryableStatus = map[hedera.Status]struct{}{
+	hedera.StatusBusy:                          {},
+	hedera.StatusPlatformNotActive:             {},
+	hedera.StatusPlatformTransactionNotCreated: {},
+	hedera.StatusTransactionExpired:            {},
+	hedera.StatusInvalidTransactionStart:       {},
+}
+
+// IsDuplicate reports whether a transaction was rejected because a
+// transaction with the same id was already submitted.
+func IsDuplicate(err error) bool {
+	var precheck hedera.ErrHederaPreCheckStatus
+	if errors.As(err, &precheck) {
+		return precheck.Status == hedera.StatusDuplicateTransaction
+	}
+	var receipt hedera.ErrHederaReceiptStatus
+	if errors.As(err, &receipt) {
+		return receipt.Status == hedera.StatusDuplicateTransaction
+	}
+	return false
+}
+
+// IsExpired reports whether a transaction was rejected because its id is no
+// longer valid.
+func IsExpired(err error) bool {
+	var precheck hedera.ErrHederaPreCheckStatus
+	if errors.As(err, &precheck) {
+		return precheck.Status == hedera.StatusTransactionExpired
+	}
+	return false
+}
+
+// IsRetryable reports whether a failed topic submission may succeed if it is
+// submitted again. Errors the SDK reports as exceptional pre-check or receipt
+// status, key and local validation errors are considered fatal unless the
+// status is known to be transient. Transport and unknown errors are retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var precheck hedera.ErrHederaPreCheckStatus
+	if errors.As(err, &precheck) {
+		_, found := retryableStatus[precheck.Status]
+		return found
+	}
+
+	var receipt hedera.ErrHederaReceiptStatus
+	if errors.As(err, &receipt) {
+		_, found := retryableStatus[receipt.Status]
+		return found
+	}
+
+	var network hedera.ErrHederaNetwork
+	if errors.As(err, &network) {
+		return true
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var badKey hedera.ErrBadKey
+	var validation hedera.ErrLocalValidation
+	var maxChunks hedera.ErrMaxChunksExceeded
+	if errors.As(err, &badKey) || errors.As(err, &validation) || errors.As(err, &maxChunks) {
+		return false
+	}
+
+	return true
+}
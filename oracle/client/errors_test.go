@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"nil", nil, false},
+		{"busy", hedera.ErrHederaPreCheckStatus{Status: hedera.StatusBusy}, true},
+		{"invalid topic", hedera.ErrHederaPreCheckStatus{Status: hedera.StatusInvalidTopicID}, false},
+		{"insufficient balance", hedera.ErrHederaPreCheckStatus{Status: hedera.StatusInsufficientPayerBalance}, false},
+		{"receipt not created", hedera.ErrHederaReceiptStatus{Status: hedera.StatusPlatformTransactionNotCreated}, true},
+		{"network", hedera.ErrHederaNetwork{}, true},
+		{"deadline", fmt.Errorf("submit: %w", context.DeadlineExceeded), true},
+		{"max chunks", hedera.ErrMaxChunksExceeded{Chunks: 30, MaxChunks: 20}, false},
+		{"unknown", fmt.Errorf("connection reset"), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.retryable, IsRetryable(tc.err))
+		})
+	}
+}
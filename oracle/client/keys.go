@@ -0,0 +1,153 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+const (
+	KeyTypeEd25519 = "ed25519"
+	KeyTypeECDSA   = "ecdsa"
+
+	// ed25519DerPrefix is the DER prefix of ed25519 private keys.
+	ed25519DerPrefix = "302e020100300506032b657004220420"
+)
+
+// SupportedKeyTypes defines the supported operator key types.
+var SupportedKeyTypes = map[string]struct{}{
+	KeyTypeEd25519: {},
+	KeyTypeECDSA:   {},
+}
+
+// KeySource defines where the operator key is loaded from. Exactly one of
+// Mnemonic, Key, File, Env and Keystore must be set.
+type KeySource struct {
+	// Mnemonic is a 24-word seed phrase, the key is derived at
+	// MnemonicIndex.
+	Mnemonic      string
+	MnemonicIndex uint32
+	// Key is a hex encoded raw or DER private key.
+	Key string
+	// File is the path of a PEM file or a file containing a hex encoded key
+	// or a mnemonic.
+	File string
+	// Env is the name of an environment variable containing a hex encoded
+	// key or a mnemonic.
+	Env string
+	// Keystore is the path of an encrypted keystore file.
+	Keystore string
+	// Type is the key type used to parse raw keys and to derive keys from a
+	// mnemonic, ed25519 if empty.
+	Type string
+}
+
+// LoadPrivateKey loads the private key defined by the source. password is
+// only called for keystores and encrypted PEM files.
+func LoadPrivateKey(src KeySource, password func() (string, error)) (hedera.PrivateKey, error) {
+	if _, ok := SupportedKeyTypes[src.Type]; src.Type != "" && !ok {
+		return hedera.PrivateKey{}, fmt.Errorf("unsupported key type: %s", src.Type)
+	}
+
+	switch {
+	case src.Mnemonic != "":
+		return DeriveKey(src.Mnemonic, src.Type, src.MnemonicIndex)
+
+	case src.Key != "":
+		return ParsePrivateKey(src.Key, src.Type, src.MnemonicIndex)
+
+	case src.Env != "":
+		value := os.Getenv(src.Env)
+		if value == "" {
+			return hedera.PrivateKey{}, fmt.Errorf("environment variable %s is not set", src.Env)
+		}
+		return ParsePrivateKey(value, src.Type, src.MnemonicIndex)
+
+	case src.File != "":
+		bz, err := os.ReadFile(src.File)
+		if err != nil {
+			return hedera.PrivateKey{}, err
+		}
+		if !strings.Contains(string(bz), "-----BEGIN") {
+			return ParsePrivateKey(string(bz), src.Type, src.MnemonicIndex)
+		}
+
+		var pass string
+		if strings.Contains(string(bz), "ENCRYPTED") {
+			if pass, err = password(); err != nil {
+				return hedera.PrivateKey{}, err
+			}
+		}
+		key, err := hedera.PrivateKeyFromPem(bz, pass)
+		if err != nil {
+			return hedera.PrivateKey{}, fmt.Errorf("failed to parse PEM file %s: %w", src.File, err)
+		}
+		return key, nil
+
+	case src.Keystore != "":
+		pass, err := password()
+		if err != nil {
+			return hedera.PrivateKey{}, err
+		}
+		return ReadKeystore(src.Keystore, pass)
+
+	default:
+		return hedera.PrivateKey{}, fmt.Errorf("no operator key provided")
+	}
+}
+
+// ParsePrivateKey parses a hex encoded raw or DER private key, or derives
+// the key at the given index if s is a mnemonic. DER keys carry their type,
+// raw keys are parsed as keyType.
+func ParsePrivateKey(s, keyType string, index uint32) (hedera.PrivateKey, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, " ") {
+		return DeriveKey(s, keyType, index)
+	}
+	s = strings.TrimPrefix(s, "0x")
+
+	// raw keys are 32 bytes, ed25519 keys may also include the public key
+	if len(s) == 64 || len(s) == 128 {
+		switch keyType {
+		case KeyTypeECDSA:
+			return hedera.PrivateKeyFromStringECDSA(s)
+		case KeyTypeEd25519, "":
+			return hedera.PrivateKeyFromStringEd25519(s)
+		default:
+			return hedera.PrivateKey{}, fmt.Errorf("unsupported key type: %s", keyType)
+		}
+	}
+
+	key, err := hedera.PrivateKeyFromStringDer(s)
+	if err != nil {
+		return hedera.PrivateKey{}, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return key, nil
+}
+
+// DeriveKey derives the key of the given type at index from a mnemonic.
+func DeriveKey(seed, keyType string, index uint32) (hedera.PrivateKey, error) {
+	mnemonic, err := hedera.NewMnemonic(strings.Fields(seed))
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+
+	switch keyType {
+	case KeyTypeECDSA:
+		return mnemonic.ToStandardECDSAsecp256k1PrivateKey("", index)
+	case KeyTypeEd25519, "":
+		return mnemonic.ToStandardEd25519PrivateKey("", index)
+	default:
+		return hedera.PrivateKey{}, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+// KeyType returns the type of a private key.
+func KeyType(key hedera.PrivateKey) string {
+	if strings.HasPrefix(key.StringDer(), ed25519DerPrefix) {
+		return KeyTypeEd25519
+	}
+	return KeyTypeECDSA
+}
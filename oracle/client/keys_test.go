@@ -0,0 +1,110 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "toss despair choice giraffe baby beach current glass blouse rice obtain kitten goddess zebra busy balcony inflict hill barely deputy eternal asset paper sword"
+
+func TestParsePrivateKey(t *testing.T) {
+	ed25519Key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+	ecdsaKey, err := hedera.PrivateKeyGenerateEcdsa()
+	require.NoError(t, err)
+
+	for _, key := range []hedera.PrivateKey{ed25519Key, ecdsaKey} {
+		keyType := KeyType(key)
+
+		parsed, err := ParsePrivateKey(key.StringDer(), "", 0)
+		require.NoError(t, err, keyType)
+		require.Equal(t, key.StringDer(), parsed.StringDer())
+
+		parsed, err = ParsePrivateKey("0x"+key.StringRaw(), keyType, 0)
+		require.NoError(t, err, keyType)
+		require.Equal(t, key.StringDer(), parsed.StringDer())
+	}
+	require.Equal(t, KeyTypeEd25519, KeyType(ed25519Key))
+	require.Equal(t, KeyTypeECDSA, KeyType(ecdsaKey))
+
+	_, err = ParsePrivateKey("foo", "", 0)
+	require.Error(t, err)
+	_, err = ParsePrivateKey(ed25519Key.StringRaw(), "rsa", 0)
+	require.Error(t, err)
+}
+
+func TestDeriveKey(t *testing.T) {
+	first, err := ParsePrivateKey(testMnemonic, "", 0)
+	require.NoError(t, err)
+	second, err := DeriveKey(testMnemonic, KeyTypeEd25519, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, first.StringDer(), second.StringDer())
+
+	ecdsaKey, err := DeriveKey(testMnemonic, KeyTypeECDSA, 0)
+	require.NoError(t, err)
+	require.Equal(t, KeyTypeECDSA, KeyType(ecdsaKey))
+
+	_, err = DeriveKey("lorem ipsum", KeyTypeEd25519, 0)
+	require.Error(t, err)
+}
+
+func TestLoadPrivateKey(t *testing.T) {
+	key, err := DeriveKey(testMnemonic, KeyTypeEd25519, 0)
+	require.NoError(t, err)
+	password := func() (string, error) { return "secret", nil }
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "operator.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(key.StringDer()+"\n"), 0o600))
+	keystoreFile := filepath.Join(dir, "operator.json")
+	require.NoError(t, WriteKeystore(keystoreFile, key, "secret"))
+	t.Setenv("TEST_OPERATOR_KEY", key.StringRaw())
+
+	for name, src := range map[string]KeySource{
+		"mnemonic": {Mnemonic: testMnemonic},
+		"key":      {Key: key.StringDer()},
+		"env":      {Env: "TEST_OPERATOR_KEY"},
+		"file":     {File: keyFile},
+		"keystore": {Keystore: keystoreFile},
+	} {
+		loaded, err := LoadPrivateKey(src, password)
+		require.NoError(t, err, name)
+		require.Equal(t, key.StringDer(), loaded.StringDer(), name)
+	}
+
+	_, err = LoadPrivateKey(KeySource{}, password)
+	require.Error(t, err)
+	_, err = LoadPrivateKey(KeySource{Env: "TEST_OPERATOR_KEY_UNSET"}, password)
+	require.Error(t, err)
+	_, err = LoadPrivateKey(KeySource{Mnemonic: testMnemonic, Type: "rsa"}, password)
+	require.Error(t, err)
+}
+
+func TestKeystore(t *testing.T) {
+	ed25519Key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+	ecdsaKey, err := hedera.PrivateKeyGenerateEcdsa()
+	require.NoError(t, err)
+
+	for _, key := range []hedera.PrivateKey{ed25519Key, ecdsaKey} {
+		bz, err := EncryptKey(key, "secret")
+		require.NoError(t, err)
+		require.NotContains(t, string(bz), key.StringRaw())
+
+		decrypted, err := DecryptKey(bz, "secret")
+		require.NoError(t, err)
+		require.Equal(t, key.StringDer(), decrypted.StringDer())
+
+		_, err = DecryptKey(bz, "wrong")
+		require.Error(t, err)
+	}
+
+	// existing keystores are not overwritten
+	path := filepath.Join(t.TempDir(), "operator.json")
+	require.NoError(t, WriteKeystore(path, ed25519Key, "secret"))
+	require.Error(t, WriteKeystore(path, ecdsaKey, "secret"))
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreKDF     = "scrypt"
+
+	// scrypt parameters recommended for interactive logins
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// keystore defines the encrypted keystore file format. The raw private key
+// is encrypted with AES-256-GCM using a key derived from the password with
+// scrypt. Unlike the keystore of the Hedera SDK, ECDSA keys are supported.
+type keystore struct {
+	Version    int    `json:"version"`
+	Type       string `json:"type"`
+	PublicKey  string `json:"public_key"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptKey returns the keystore of a private key encrypted with the
+// password.
+func EncryptKey(key hedera.PrivateKey, password string) ([]byte, error) {
+	ks := keystore{
+		Version:   keystoreVersion,
+		Type:      KeyType(key),
+		PublicKey: key.PublicKey().StringDer(),
+		KDF:       keystoreKDF,
+		N:         scryptN,
+		R:         scryptR,
+		P:         scryptP,
+		Salt:      make([]byte, 32),
+	}
+	if _, err := rand.Read(ks.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := ks.cipher(password)
+	if err != nil {
+		return nil, err
+	}
+	ks.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(ks.Nonce); err != nil {
+		return nil, err
+	}
+	ks.Ciphertext = aead.Seal(nil, ks.Nonce, key.BytesRaw(), nil)
+
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptKey returns the private key of a keystore.
+func DecryptKey(bz []byte, password string) (hedera.PrivateKey, error) {
+	var ks keystore
+	if err := json.Unmarshal(bz, &ks); err != nil {
+		return hedera.PrivateKey{}, fmt.Errorf("failed to parse keystore: %w", err)
+	}
+	if ks.Version != keystoreVersion {
+		return hedera.PrivateKey{}, fmt.Errorf("unsupported keystore version: %d", ks.Version)
+	}
+	if ks.KDF != keystoreKDF {
+		return hedera.PrivateKey{}, fmt.Errorf("unsupported keystore kdf: %s", ks.KDF)
+	}
+
+	aead, err := ks.cipher(password)
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+	raw, err := aead.Open(nil, ks.Nonce, ks.Ciphertext, nil)
+	if err != nil {
+		return hedera.PrivateKey{}, fmt.Errorf("failed to decrypt keystore: invalid password")
+	}
+
+	var key hedera.PrivateKey
+	switch ks.Type {
+	case KeyTypeEd25519:
+		key, err = hedera.PrivateKeyFromBytesEd25519(raw)
+	case KeyTypeECDSA:
+		key, err = hedera.PrivateKeyFromBytesECDSA(raw)
+	default:
+		return hedera.PrivateKey{}, fmt.Errorf("unsupported key type: %s", ks.Type)
+	}
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+	if key.PublicKey().StringDer() != ks.PublicKey {
+		return hedera.PrivateKey{}, fmt.Errorf("keystore public key mismatch")
+	}
+
+	return key, nil
+}
+
+// WriteKeystore encrypts a private key and writes it to path. Existing
+// files are not overwritten.
+func WriteKeystore(path string, key hedera.PrivateKey, password string) error {
+	bz, err := EncryptKey(key, password)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(bz); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadKeystore reads and decrypts the keystore at path.
+func ReadKeystore(path string, password string) (hedera.PrivateKey, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return hedera.PrivateKey{}, err
+	}
+	return DecryptKey(bz, password)
+}
+
+func (ks keystore) cipher(password string) (cipher.AEAD, error) {
+	derived, err := scrypt.Key([]byte(password), ks.Salt, ks.N, ks.R, ks.P, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
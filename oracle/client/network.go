@@ -0,0 +1,56 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+// Network defines the Hedera network the oracle client connects to. Either
+// Name selects one of the public networks, or Nodes defines the address
+// book of a custom network, e.g. a local node or a private network.
+type Network struct {
+	Name string
+	// Nodes maps consensus node addresses to their node account ids.
+	Nodes map[string]string
+	// MirrorNodes defines the gRPC addresses of the mirror network.
+	MirrorNodes []string
+	// TransportSecurity and VerifyCertificates override the SDK defaults
+	// if set.
+	TransportSecurity  *bool
+	VerifyCertificates *bool
+}
+
+// NewHederaClient creates a client for the given network.
+func NewHederaClient(network Network) (*hedera.Client, error) {
+	var hederaClient *hedera.Client
+	if len(network.Nodes) > 0 {
+		nodes := make(map[string]hedera.AccountID, len(network.Nodes))
+		for address, accountID := range network.Nodes {
+			nodeAccountID, err := hedera.AccountIDFromString(accountID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid account id of node %s: %w", address, err)
+			}
+			nodes[address] = nodeAccountID
+		}
+		hederaClient = hedera.ClientForNetwork(nodes)
+	} else {
+		c, err := hedera.ClientForName(network.Name)
+		if err != nil {
+			return nil, err
+		}
+		hederaClient = c
+	}
+
+	if len(network.MirrorNodes) > 0 {
+		hederaClient.SetMirrorNetwork(network.MirrorNodes)
+	}
+	if network.TransportSecurity != nil {
+		hederaClient.SetTransportSecurity(*network.TransportSecurity)
+	}
+	if network.VerifyCertificates != nil {
+		hederaClient.SetCertificateVerification(*network.VerifyCertificates)
+	}
+
+	return hederaClient, nil
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHederaClient(t *testing.T) {
+	// public networks are not created here, as their address book is
+	// fetched on creation
+	_, err := NewHederaClient(Network{Name: "local"})
+	require.Error(t, err)
+
+	tls := false
+	hederaClient, err := NewHederaClient(Network{
+		Name:              "local",
+		Nodes:             map[string]string{"127.0.0.1:50211": "0.0.3"},
+		MirrorNodes:       []string{"127.0.0.1:5600"},
+		TransportSecurity: &tls,
+	})
+	require.NoError(t, err)
+	require.Len(t, hederaClient.GetNetwork(), 1)
+	require.Equal(t, []string{"127.0.0.1:5600"}, hederaClient.GetMirrorNetwork())
+
+	_, err = NewHederaClient(Network{Nodes: map[string]string{"127.0.0.1:50211": "foo"}})
+	require.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package oracle
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+// confidenceDrift is the assumed volatility of prices per square root of a
+// second, about 80% a year. It widens the confidence of prices with their age.
+var confidenceDrift = sdk.MustNewDecFromStr("0.00015")
+
+// priceConfidence estimates the uncertainty of the rate aggregated from the
+// filtered tickers, similar to the confidence interval of Pyth. It is the
+// root mean square of the distances of the tickers from the rate, including
+// the confidence reported for the tickers themselves, divided by the square
+// root of the number of tickers, so it shrinks with more sources. The drift
+// of the market since the average time of the tickers is added on top. A
+// single ticker without a confidence of its own only gets the drift.
+func priceConfidence(
+	rate sdk.Dec,
+	tickers map[provider.Name]types.TickerPrice,
+	now time.Time,
+) sdk.Dec {
+	if len(tickers) == 0 || !rate.IsPositive() {
+		return sdk.ZeroDec()
+	}
+
+	// distances relative to the rate keep the precision for small prices
+	squares := sdk.ZeroDec()
+	age := time.Duration(0)
+	for _, ticker := range tickers {
+		distance := ticker.Price.Sub(rate).Quo(rate)
+		squares = squares.Add(distance.Mul(distance))
+		if !ticker.Confidence.IsNil() {
+			confidence := ticker.Confidence.Quo(rate)
+			squares = squares.Add(confidence.Mul(confidence))
+		}
+		if !ticker.Time.IsZero() && now.After(ticker.Time) {
+			age += now.Sub(ticker.Time)
+		}
+	}
+	age /= time.Duration(len(tickers))
+
+	dispersion := sqrtDec(squares).QuoInt64(int64(len(tickers)))
+	drift := confidenceDrift.Mul(sqrtDec(sdk.NewDec(int64(age / time.Millisecond)).QuoInt64(1000)))
+
+	return dispersion.Add(drift).Mul(rate)
+}
+
+// sqrtDec returns the square root of a non-negative decimal.
+func sqrtDec(d sdk.Dec) sdk.Dec {
+	root, err := d.ApproxSqrt()
+	if err != nil {
+		return sdk.ZeroDec()
+	}
+	return root
+}
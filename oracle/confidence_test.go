@@ -0,0 +1,70 @@
+package oracle
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+func TestPriceConfidence(t *testing.T) {
+	now := time.Now()
+	rate := sdk.NewDec(10)
+
+	require.True(t, priceConfidence(rate, nil, now).IsZero())
+
+	// the spread of 1 shrinks with the square root of the number of sources
+	confidence := priceConfidence(rate, map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: {Price: sdk.NewDec(9), Time: now},
+		provider.ProviderKraken:  {Price: sdk.NewDec(11), Time: now},
+	}, now)
+	require.InDelta(t, 0.7071, confidence.MustFloat64(), 0.0001)
+
+	// a single source keeps its own confidence
+	confidence = priceConfidence(rate, map[provider.Name]types.TickerPrice{
+		provider.ProviderPyth: {Price: rate, Confidence: sdk.MustNewDecFromStr("0.2"), Time: now},
+	}, now)
+	require.InDelta(t, 0.2, confidence.MustFloat64(), 0.0001)
+
+	// and widens with its age
+	confidence = priceConfidence(rate, map[provider.Name]types.TickerPrice{
+		provider.ProviderPyth: {Price: rate, Time: now.Add(-100 * time.Second)},
+	}, now)
+	require.InDelta(t, 0.015, confidence.MustFloat64(), 0.0001)
+}
+
+func TestConvertTickersToUSD_Confidences(t *testing.T) {
+	atomUsdt := types.CurrencyPair{Base: "ATOM", Quote: "USDT"}
+	usdtUsd := types.CurrencyPair{Base: "USDT", Quote: "USD"}
+	usdtTicker := ticker("1", "0")
+	usdtTicker.Confidence = sdk.MustNewDecFromStr("0.01")
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderBinance: {"ATOMUSDT": ticker("10", "1000")},
+		provider.ProviderPyth:    {"USDTUSD": usdtTicker},
+	}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderBinance: {atomUsdt},
+		provider.ProviderPyth:    {usdtUsd},
+	}
+	minimums := map[string]int{"ATOM": 1, "USDT": 1}
+
+	rates, confidences, err := convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			providerMinOverrides: minimums,
+			now:                  time.Now(),
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDec(10), rates["ATOM"])
+	require.InDelta(t, 0.01, confidences["USDT"].MustFloat64(), 0.0001)
+	// the relative confidence of USDT is carried over to ATOM
+	require.InDelta(t, 0.1, confidences["ATOM"].MustFloat64(), 0.001)
+}
@@ -0,0 +1,342 @@
+package consumer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+
+	oracletypes "github.com/Team-Kujira/core/x/oracle/types"
+
+	"price-feeder/oracle"
+	"price-feeder/oracle/envelope"
+	"price-feeder/oracle/mirror"
+	"price-feeder/oracle/voting"
+)
+
+type (
+	// Aggregator replays the commit-reveal procedure of x/oracle from topic
+	// messages. Every feeder has at most one outstanding prevote, which is
+	// revealed by a vote for the same round. Revealed votes and pushed
+	// prices are collected per round and tallied into weighted median
+	// reference prices.
+	Aggregator struct {
+		logger    zerolog.Logger
+		keys      Keys
+		rounds    voting.VoteRounds
+		powers    map[string]int64
+		threshold sdk.Dec
+
+		chunks   *envelope.Assembler
+		prevotes map[string]prevote
+		ballots  map[uint64]map[string]oracletypes.ExchangeRateTuples
+	}
+
+	// Result defines the tally of a single round.
+	Result struct {
+		Round   uint64             `json:"round"`
+		Feeders []string           `json:"feeders"`
+		Prices  map[string]sdk.Dec `json:"prices"`
+	}
+
+	prevote struct {
+		hash           []byte
+		confidenceHash []byte
+		round          uint64
+		sequence       uint64
+	}
+
+	// message contains the fields of prevotes, votes and pushes, a prevote
+	// carries a hash, a vote the revealed salt and a push only the rates.
+	message struct {
+		Hash           string `json:"hash"`
+		ConfidenceHash string `json:"confidence_hash"`
+		Salt           string `json:"salt"`
+		ExchangeRates  string `json:"exchange_rates"`
+		Confidences    string `json:"confidences"`
+		Feeder         string `json:"feeder"`
+		Round          uint64 `json:"round"`
+		Timestamp      int64  `json:"timestamp"`
+	}
+)
+
+// NewAggregator returns a new Aggregator. Messages must be wrapped in
+// envelopes signed with the feeder key returned by keys. powers maps feeder
+// accounts to their voting power, if it is empty every feeder votes with a
+// power of one. A denom needs a ballot power of at least threshold times the
+// total power to be tallied. rounds is only used to check the consensus time
+// of messages if its period is set.
+func NewAggregator(
+	logger zerolog.Logger,
+	keys Keys,
+	rounds voting.VoteRounds,
+	powers map[string]int64,
+	threshold sdk.Dec,
+) *Aggregator {
+	return &Aggregator{
+		logger:    logger.With().Str("module", "consumer").Logger(),
+		keys:      keys,
+		rounds:    rounds,
+		powers:    powers,
+		threshold: threshold,
+		chunks:    envelope.NewAssembler(),
+		prevotes:  map[string]prevote{},
+		ballots:   map[uint64]map[string]oracletypes.ExchangeRateTuples{},
+	}
+}
+
+// Add processes a topic message. Messages must be added in the order of
+// their sequence numbers. An error is returned for messages which are not
+// valid prevotes, votes or pushes. Chunked messages are processed once their last
+// chunk has been added, their consensus time is the one of the last chunk.
+func (a *Aggregator) Add(msg mirror.TopicMessage) error {
+	env, err := envelope.Unmarshal(msg.Message)
+	if err != nil {
+		return fmt.Errorf("invalid envelope %d: %w", msg.SequenceNumber, err)
+	}
+	if env.Feeder == "" {
+		return fmt.Errorf("message %d has no feeder", msg.SequenceNumber)
+	}
+	// the payer is authenticated by the network, so a feeder can only
+	// submit messages in its own name
+	if msg.PayerAccountID != "" && msg.PayerAccountID != env.Feeder {
+		return fmt.Errorf(
+			"message %d of feeder %s was paid by %s",
+			msg.SequenceNumber, env.Feeder, msg.PayerAccountID,
+		)
+	}
+	if len(a.powers) > 0 {
+		if _, ok := a.powers[env.Feeder]; !ok {
+			return fmt.Errorf("message %d of unknown feeder %s", msg.SequenceNumber, env.Feeder)
+		}
+	}
+
+	key, err := a.keys.PublicKey(env.Feeder)
+	if err != nil {
+		return fmt.Errorf("failed to get key of feeder %s: %w", env.Feeder, err)
+	}
+	if err := env.Verify(key); err != nil {
+		return fmt.Errorf("message %d: %w", msg.SequenceNumber, err)
+	}
+
+	env, complete, err := a.chunks.Add(env)
+	if err != nil {
+		return fmt.Errorf("message %d: %w", msg.SequenceNumber, err)
+	}
+	if !complete {
+		return nil
+	}
+
+	var m message
+	if err := json.Unmarshal(env.Payload, &m); err != nil {
+		return fmt.Errorf("invalid message %d: %w", msg.SequenceNumber, err)
+	}
+	if env.Feeder != m.Feeder || env.Round != m.Round {
+		return fmt.Errorf("envelope %d does not match its message", msg.SequenceNumber)
+	}
+
+	switch {
+	case m.Hash != "":
+		return a.addPrevote(msg, m)
+	case m.Salt != "":
+		return a.addVote(msg, m)
+	case m.ExchangeRates != "":
+		return a.addPush(msg, m)
+	default:
+		return fmt.Errorf("message %d is neither a prevote, a vote nor a push", msg.SequenceNumber)
+	}
+}
+
+func (a *Aggregator) addPrevote(msg mirror.TopicMessage, m message) error {
+	hash, err := hex.DecodeString(m.Hash)
+	if err != nil {
+		return fmt.Errorf("invalid prevote hash of feeder %s: %w", m.Feeder, err)
+	}
+	confidenceHash, err := hex.DecodeString(m.ConfidenceHash)
+	if err != nil {
+		return fmt.Errorf("invalid prevote confidence hash of feeder %s: %w", m.Feeder, err)
+	}
+	if err := a.checkRound(msg, m.Round); err != nil {
+		return err
+	}
+
+	// a new prevote replaces the previous one, as on chain
+	a.prevotes[m.Feeder] = prevote{
+		hash:           hash,
+		confidenceHash: confidenceHash,
+		round:          m.Round,
+		sequence:       msg.SequenceNumber,
+	}
+	return nil
+}
+
+func (a *Aggregator) addVote(msg mirror.TopicMessage, m message) error {
+	p, ok := a.prevotes[m.Feeder]
+	if !ok {
+		return fmt.Errorf("no prevote found for vote of feeder %s", m.Feeder)
+	}
+	if p.round != m.Round {
+		return fmt.Errorf(
+			"vote of feeder %s for round %d does not match prevote round %d",
+			m.Feeder, m.Round, p.round,
+		)
+	}
+	if p.sequence >= msg.SequenceNumber {
+		return fmt.Errorf("vote of feeder %s precedes its prevote", m.Feeder)
+	}
+	if err := a.checkRound(msg, m.Round+1); err != nil {
+		return err
+	}
+
+	hash := oracle.GetAggregateVoteHash(m.Salt, m.ExchangeRates, m.Feeder)
+	if !bytes.Equal(hash, p.hash) {
+		return fmt.Errorf("vote of feeder %s does not match prevote hash", m.Feeder)
+	}
+	// confidences are optional, but must be committed to if revealed
+	if m.Confidences != "" || len(p.confidenceHash) > 0 {
+		confidenceHash := oracle.GetAggregateVoteHash(m.Salt, m.Confidences, m.Feeder)
+		if !bytes.Equal(confidenceHash, p.confidenceHash) {
+			return fmt.Errorf("vote of feeder %s does not match prevote confidence hash", m.Feeder)
+		}
+	}
+
+	// the prevote is consumed by the reveal, even if the rates are invalid
+	delete(a.prevotes, m.Feeder)
+
+	return a.addBallot(m)
+}
+
+// addPush adds prices pushed without commit-reveal. A feeder pushing more
+// than once per round replaces its earlier prices.
+func (a *Aggregator) addPush(msg mirror.TopicMessage, m message) error {
+	if err := a.checkRound(msg, m.Round); err != nil {
+		return err
+	}
+	return a.addBallot(m)
+}
+
+func (a *Aggregator) addBallot(m message) error {
+	tuples, err := oracletypes.ParseExchangeRateTuples(m.ExchangeRates)
+	if err != nil {
+		return fmt.Errorf("invalid exchange rates of feeder %s: %w", m.Feeder, err)
+	}
+
+	ballot, ok := a.ballots[m.Round]
+	if !ok {
+		ballot = map[string]oracletypes.ExchangeRateTuples{}
+		a.ballots[m.Round] = ballot
+	}
+	ballot[m.Feeder] = tuples
+	return nil
+}
+
+// checkRound verifies that a message reached consensus during the expected
+// round.
+func (a *Aggregator) checkRound(msg mirror.TopicMessage, round uint64) error {
+	if a.rounds.Period == 0 {
+		return nil
+	}
+	consensus, err := msg.ConsensusTime()
+	if err != nil {
+		return err
+	}
+	if actual := a.rounds.Round(consensus); actual != round {
+		return fmt.Errorf(
+			"message %d reached consensus in round %d, expected round %d",
+			msg.SequenceNumber, actual, round,
+		)
+	}
+	return nil
+}
+
+// Rounds returns all rounds with at least one revealed vote in ascending
+// order.
+func (a *Aggregator) Rounds() []uint64 {
+	rounds := make([]uint64, 0, len(a.ballots))
+	for round := range a.ballots {
+		rounds = append(rounds, round)
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i] < rounds[j] })
+	return rounds
+}
+
+// Tally computes the weighted median of every denom voted on in the given
+// round. Denoms whose ballot power is below the threshold are omitted.
+// Messages of the round still missing chunks are rejected.
+func (a *Aggregator) Tally(round uint64) (Result, error) {
+	for _, err := range a.chunks.Expire(round) {
+		a.logger.Warn().Err(err).Msg("rejecting incomplete message")
+	}
+
+	votes := a.ballots[round]
+
+	result := Result{
+		Round:   round,
+		Feeders: make([]string, 0, len(votes)),
+		Prices:  map[string]sdk.Dec{},
+	}
+
+	ballots := map[string]oracletypes.ExchangeRateBallot{}
+	for feeder, tuples := range votes {
+		result.Feeders = append(result.Feeders, feeder)
+		power := a.power(feeder)
+		for _, tuple := range tuples {
+			// zero rates are abstain votes
+			if !tuple.ExchangeRate.IsPositive() {
+				continue
+			}
+			ballots[tuple.Denom] = append(ballots[tuple.Denom], oracletypes.NewVoteForTally(
+				tuple.ExchangeRate, tuple.Denom, sdk.ValAddress(feeder), power,
+			))
+		}
+	}
+	sort.Strings(result.Feeders)
+
+	totalPower := a.totalPower(result.Feeders)
+	thresholdPower := a.threshold.MulInt64(totalPower).RoundInt64()
+
+	for denom, ballot := range ballots {
+		if ballot.Power() == 0 || ballot.Power() < thresholdPower {
+			a.logger.Debug().
+				Str("denom", denom).
+				Uint64("round", round).
+				Int64("power", ballot.Power()).
+				Int64("threshold", thresholdPower).
+				Msg("ballot power below threshold")
+			continue
+		}
+
+		sort.Sort(ballot)
+		median, err := ballot.WeightedMedian()
+		if err != nil {
+			return Result{}, err
+		}
+		result.Prices[denom] = median
+	}
+
+	return result, nil
+}
+
+func (a *Aggregator) power(feeder string) int64 {
+	if len(a.powers) == 0 {
+		return 1
+	}
+	return a.powers[feeder]
+}
+
+// totalPower returns the power of all known feeders, or of all voters if
+// no powers are configured.
+func (a *Aggregator) totalPower(voters []string) int64 {
+	if len(a.powers) == 0 {
+		return int64(len(voters))
+	}
+	total := int64(0)
+	for _, power := range a.powers {
+		total += power
+	}
+	return total
+}
@@ -0,0 +1,304 @@
+package consumer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/oracle"
+	"price-feeder/oracle/envelope"
+	"price-feeder/oracle/mirror"
+	"price-feeder/oracle/voting"
+)
+
+type staticKeys map[string]hedera.PrivateKey
+
+func (k staticKeys) PublicKey(feeder string) (hedera.PublicKey, error) {
+	key, ok := k[feeder]
+	if !ok {
+		return hedera.PublicKey{}, fmt.Errorf("unknown feeder %s", feeder)
+	}
+	return key.PublicKey(), nil
+}
+
+type testTopic struct {
+	keys     staticKeys
+	messages []mirror.TopicMessage
+	// maxMessageSize enables chunking if set
+	maxMessageSize int
+}
+
+func newTestTopic() *testTopic {
+	return &testTopic{keys: staticKeys{}}
+}
+
+// add submits a message signed by the payer and returns the number of
+// chunks submitted.
+func (tt *testTopic) add(t *testing.T, payer string, consensus time.Time, round uint64, msg interface{}) int {
+	key, ok := tt.keys[payer]
+	if !ok {
+		var err error
+		key, err = hedera.PrivateKeyGenerateEd25519()
+		require.NoError(t, err)
+		tt.keys[payer] = key
+	}
+	signer, err := envelope.NewSigner(payer, key, envelope.EncodingJSON, tt.maxMessageSize)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(msg)
+	require.NoError(t, err)
+	chunks, err := signer.Seal(round, payload)
+	require.NoError(t, err)
+
+	for _, bz := range chunks {
+		tt.messages = append(tt.messages, mirror.TopicMessage{
+			ConsensusTimestamp: fmt.Sprintf("%d.%09d", consensus.Unix(), consensus.Nanosecond()),
+			Message:            bz,
+			PayerAccountID:     payer,
+			SequenceNumber:     uint64(len(tt.messages) + 1),
+			TopicID:            "0.0.1234",
+		})
+	}
+	return len(chunks)
+}
+
+// commit adds the prevote of a feeder in the given round and returns the
+// matching vote.
+func (tt *testTopic) commit(
+	t *testing.T,
+	feeder string,
+	round uint64,
+	consensus time.Time,
+	rates string,
+) oracle.MsgAggregateExchangeRateVote {
+	return tt.commitConfidences(t, feeder, round, consensus, rates, "")
+}
+
+// commitConfidences is commit with the confidences of the rates.
+func (tt *testTopic) commitConfidences(
+	t *testing.T,
+	feeder string,
+	round uint64,
+	consensus time.Time,
+	rates string,
+	confidences string,
+) oracle.MsgAggregateExchangeRateVote {
+	salt, err := oracle.GenerateSalt(32)
+	require.NoError(t, err)
+	prevote := oracle.MsgAggregateExchangeRatePrevote{
+		Hash:   hex.EncodeToString(oracle.GetAggregateVoteHash(salt, rates, feeder)),
+		Feeder: feeder,
+		Round:  round,
+	}
+	if confidences != "" {
+		prevote.ConfidenceHash = hex.EncodeToString(oracle.GetAggregateVoteHash(salt, confidences, feeder))
+	}
+	tt.add(t, feeder, consensus, round, prevote)
+	return oracle.MsgAggregateExchangeRateVote{
+		Salt:          salt,
+		ExchangeRates: rates,
+		Confidences:   confidences,
+		Feeder:        feeder,
+		Round:         round,
+	}
+}
+
+func (tt *testTopic) serve(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/topics/0.0.1234/messages", r.URL.Path)
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": tt.messages,
+			"links":    map[string]interface{}{"next": nil},
+		}))
+	}))
+}
+
+func TestAggregator(t *testing.T) {
+	rounds, err := voting.NewVoteRounds(10*time.Second, 0, 0)
+	require.NoError(t, err)
+
+	round := uint64(170000000)
+	prevoteTime := rounds.Start(round).Add(time.Second)
+	voteTime := rounds.Start(round + 1).Add(time.Second)
+
+	topic := newTestTopic()
+	votes := []oracle.MsgAggregateExchangeRateVote{
+		topic.commit(t, "0.0.1", round, prevoteTime, "100.0BTC,10.0ETH"),
+		topic.commit(t, "0.0.2", round, prevoteTime, "102.0BTC,11.0ETH"),
+		topic.commitConfidences(t, "0.0.3", round, prevoteTime, "104.0BTC", "1.0BTC"),
+	}
+	// revealing different rates than committed to
+	cheater := topic.commit(t, "0.0.4", round, prevoteTime, "1.0BTC")
+	cheater.ExchangeRates = "1000.0BTC"
+	votes = append(votes, cheater)
+	// revealing different confidences than committed to
+	cheater = topic.commitConfidences(t, "0.0.7", round, prevoteTime, "100.0BTC", "1.0BTC")
+	cheater.Confidences = "0.1BTC"
+	votes = append(votes, cheater)
+	// submitted in the name of another feeder
+	topic.add(t, "0.0.5", prevoteTime, round, oracle.MsgAggregateExchangeRatePrevote{
+		Hash:   "00",
+		Feeder: "0.0.1",
+		Round:  round,
+	})
+
+	for _, vote := range votes {
+		topic.add(t, vote.Feeder, voteTime, vote.Round, vote)
+	}
+	// a vote without prevote
+	topic.add(t, "0.0.6", voteTime, round, oracle.MsgAggregateExchangeRateVote{
+		Salt:          "00",
+		ExchangeRates: "1.0BTC",
+		Feeder:        "0.0.6",
+		Round:         round,
+	})
+
+	server := topic.serve(t)
+	defer server.Close()
+
+	client, err := mirror.NewClient(server.URL, server.Client())
+	require.NoError(t, err)
+	messages, err := client.GetTopicMessages(context.Background(), "0.0.1234", 1)
+	require.NoError(t, err)
+
+	powers := map[string]int64{"0.0.1": 10, "0.0.2": 30, "0.0.3": 10, "0.0.4": 10, "0.0.6": 10, "0.0.7": 10}
+	aggregator := NewAggregator(zerolog.Nop(), topic.keys, rounds, powers, sdk.MustNewDecFromStr("0.5"))
+
+	errs := 0
+	for _, msg := range messages {
+		if err := aggregator.Add(msg); err != nil {
+			errs++
+		}
+	}
+	require.Equal(t, 4, errs)
+	require.Equal(t, []uint64{round}, aggregator.Rounds())
+
+	result, err := aggregator.Tally(round)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0.0.1", "0.0.2", "0.0.3"}, result.Feeders)
+	require.Equal(t, sdk.MustNewDecFromStr("102"), result.Prices["BTC"])
+	// ETH ballot power 40 is above the threshold of 35
+	require.Equal(t, sdk.MustNewDecFromStr("11"), result.Prices["ETH"])
+}
+
+func TestAggregator_rounds(t *testing.T) {
+	rounds, err := voting.NewVoteRounds(10*time.Second, 0, 0)
+	require.NoError(t, err)
+
+	round := uint64(170000000)
+	topic := newTestTopic()
+
+	// the vote has to be revealed in the following round
+	vote := topic.commit(t, "0.0.1", round, rounds.Start(round), "1.0BTC")
+	topic.add(t, "0.0.1", rounds.Start(round+2), vote.Round, vote)
+
+	// a vote for a different round than the prevote
+	vote = topic.commit(t, "0.0.2", round, rounds.Start(round), "1.0BTC")
+	vote.Round = round + 1
+	topic.add(t, "0.0.2", rounds.Start(round+1), vote.Round, vote)
+
+	// a prevote submitted outside its round
+	topic.commit(t, "0.0.3", round+1, rounds.Start(round), "1.0BTC")
+
+	aggregator := NewAggregator(zerolog.Nop(), topic.keys, rounds, nil, sdk.ZeroDec())
+	errs := 0
+	for _, msg := range topic.messages {
+		if err := aggregator.Add(msg); err != nil {
+			errs++
+		}
+	}
+	require.Equal(t, 3, errs)
+	require.Empty(t, aggregator.Rounds())
+}
+
+func TestAggregator_chunks(t *testing.T) {
+	rounds, err := voting.NewVoteRounds(10*time.Second, 0, 0)
+	require.NoError(t, err)
+
+	round := uint64(170000000)
+	prevoteTime := rounds.Start(round).Add(time.Second)
+	voteTime := rounds.Start(round + 1).Add(time.Second)
+
+	rates := ""
+	for i := 0; i < 50; i++ {
+		if i > 0 {
+			rates += ","
+		}
+		rates += fmt.Sprintf("%d.0DENOM%d", i+1, i)
+	}
+
+	topic := newTestTopic()
+	topic.maxMessageSize = 512
+	vote := topic.commit(t, "0.0.1", round, prevoteTime, rates)
+	require.Greater(t, topic.add(t, "0.0.1", voteTime, round, vote), 1)
+
+	// the last chunk of the vote is lost
+	incomplete := topic.commit(t, "0.0.2", round, prevoteTime, rates)
+	require.Greater(t, topic.add(t, "0.0.2", voteTime, round, incomplete), 1)
+	topic.messages = topic.messages[:len(topic.messages)-1]
+
+	aggregator := NewAggregator(zerolog.Nop(), topic.keys, rounds, nil, sdk.ZeroDec())
+	for _, msg := range topic.messages {
+		require.NoError(t, aggregator.Add(msg))
+	}
+
+	result, err := aggregator.Tally(round)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0.0.1"}, result.Feeders)
+	require.Len(t, result.Prices, 50)
+	require.Equal(t, sdk.MustNewDecFromStr("50"), result.Prices["DENOM49"])
+}
+
+func TestAggregator_push(t *testing.T) {
+	rounds, err := voting.NewVoteRounds(10*time.Second, 0, 0)
+	require.NoError(t, err)
+
+	round := uint64(170000000)
+	pushTime := rounds.Start(round).Add(time.Second)
+
+	topic := newTestTopic()
+	for feeder, rates := range map[string]string{"0.0.1": "100.0BTC", "0.0.2": "104.0BTC"} {
+		topic.add(t, feeder, pushTime, round, oracle.MsgPushExchangeRates{
+			ExchangeRates: rates,
+			Feeder:        feeder,
+			Round:         round,
+			Timestamp:     pushTime.UnixMilli(),
+		})
+	}
+	// a later push of the same round replaces the earlier one
+	topic.add(t, "0.0.1", pushTime.Add(time.Second), round, oracle.MsgPushExchangeRates{
+		ExchangeRates: "102.0BTC",
+		Feeder:        "0.0.1",
+		Round:         round,
+	})
+	// a push reaching consensus in another round
+	topic.add(t, "0.0.3", rounds.Start(round+1), round, oracle.MsgPushExchangeRates{
+		ExchangeRates: "1.0BTC",
+		Feeder:        "0.0.3",
+		Round:         round,
+	})
+
+	aggregator := NewAggregator(zerolog.Nop(), topic.keys, rounds, nil, sdk.ZeroDec())
+	errs := 0
+	for _, msg := range topic.messages {
+		if err := aggregator.Add(msg); err != nil {
+			errs++
+		}
+	}
+	require.Equal(t, 1, errs)
+
+	result, err := aggregator.Tally(round)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0.0.1", "0.0.2"}, result.Feeders)
+	require.Equal(t, sdk.MustNewDecFromStr("102"), result.Prices["BTC"])
+}
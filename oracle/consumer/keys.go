@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+
+	"price-feeder/oracle/mirror"
+)
+
+var _ Keys = (*MirrorKeys)(nil)
+
+type (
+	// Keys resolves the public keys envelopes of a feeder are signed with.
+	Keys interface {
+		PublicKey(feeder string) (hedera.PublicKey, error)
+	}
+
+	// MirrorKeys looks up the keys of feeder accounts on a mirror node and
+	// caches them.
+	MirrorKeys struct {
+		ctx    context.Context
+		client mirror.Client
+
+		mtx  sync.Mutex
+		keys map[string]hedera.PublicKey
+	}
+)
+
+func NewMirrorKeys(ctx context.Context, client mirror.Client) *MirrorKeys {
+	return &MirrorKeys{
+		ctx:    ctx,
+		client: client,
+		keys:   map[string]hedera.PublicKey{},
+	}
+}
+
+func (k *MirrorKeys) PublicKey(feeder string) (hedera.PublicKey, error) {
+	k.mtx.Lock()
+	defer k.mtx.Unlock()
+
+	if key, ok := k.keys[feeder]; ok {
+		return key, nil
+	}
+	key, err := k.client.GetAccountKey(k.ctx, feeder)
+	if err != nil {
+		return hedera.PublicKey{}, err
+	}
+	k.keys[feeder] = key
+	return key, nil
+}
@@ -1,29 +1,185 @@
 package oracle
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"price-feeder/config"
 	"price-feeder/oracle/provider"
 	"price-feeder/oracle/types"
-	"sort"
 
 	"github.com/rs/zerolog"
 )
 
-// convertTickersToUSD converts any tickers which are not quoted in USD to USD,
-// using the conversion rates of other tickers. It will also filter out any tickers
-// not within the deviation threshold set by the config.
+// more than 6 conversions for the USD price is probably not very accurate
+const maxConversions = 6
+
+type (
+	// conversionGraph models the currency pairs with prices as a graph with
+	// an edge from the base to the quote of every pair. Hops is the smallest
+	// number of conversions from a denom to USD. Only the edges which don't
+	// form cycles are kept, preferring the ones whose quote is closer to USD,
+	// so the USD rates of the denoms can be computed in order.
+	conversionGraph struct {
+		hops  map[string]int
+		edges map[string][]types.CurrencyPair
+		order []string
+	}
+
+	// conversionPath defines the conversion of the prices of a pair to USD,
+	// using the USD rate of its quote. Symbols lists the pairs along the
+	// most direct path of the quote, Liquidity is the USD notional volume of
+	// the least liquid of them. Confidence is the confidence of the rate
+	// relative to it.
+	conversionPath struct {
+		pair       types.CurrencyPair
+		symbols    []string
+		hops       int
+		liquidity  sdk.Dec
+		rate       sdk.Dec
+		confidence sdk.Dec
+		tickers    map[provider.Name]types.TickerPrice
+	}
+)
+
+// newConversionGraph builds the graph of the given pairs.
+func newConversionGraph(pairs []types.CurrencyPair) *conversionGraph {
+	g := &conversionGraph{
+		hops:  map[string]int{config.DenomUSD: 0},
+		edges: map[string][]types.CurrencyPair{},
+	}
+
+	for i := 0; i < maxConversions; i++ {
+		for _, pair := range pairs {
+			hops, found := g.hops[pair.Quote]
+			if !found {
+				continue
+			}
+			if current, found := g.hops[pair.Base]; !found || hops+1 < current {
+				g.hops[pair.Base] = hops + 1
+			}
+		}
+	}
+
+	candidates := []types.CurrencyPair{}
+	for _, pair := range pairs {
+		_, found := g.hops[pair.Quote]
+		if found && pair.Base != config.DenomUSD && pair.Base != pair.Quote {
+			candidates = append(candidates, pair)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return g.hops[candidates[i].Quote] < g.hops[candidates[j].Quote]
+	})
+	for _, pair := range candidates {
+		if !g.reaches(pair.Quote, pair.Base) {
+			g.edges[pair.Base] = append(g.edges[pair.Base], pair)
+		}
+	}
+
+	denoms := make([]string, 0, len(g.edges))
+	for denom := range g.edges {
+		denoms = append(denoms, denom)
+	}
+	sort.Strings(denoms)
+
+	visited := map[string]struct{}{}
+	var visit func(denom string)
+	visit = func(denom string) {
+		if _, ok := visited[denom]; ok {
+			return
+		}
+		visited[denom] = struct{}{}
+		for _, pair := range g.edges[denom] {
+			visit(pair.Quote)
+		}
+		if denom != config.DenomUSD {
+			g.order = append(g.order, denom)
+		}
+	}
+	for _, denom := range denoms {
+		visit(denom)
+	}
+
+	return g
+}
+
+// reaches reports whether the target can be reached from the denom along the
+// edges of the graph.
+func (g *conversionGraph) reaches(denom, target string) bool {
+	visited := map[string]struct{}{}
+	stack := []string{denom}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == target {
+			return true
+		}
+		if _, ok := visited[current]; ok {
+			continue
+		}
+		visited[current] = struct{}{}
+		for _, pair := range g.edges[current] {
+			stack = append(stack, pair.Quote)
+		}
+	}
+	return false
+}
+
+// conversionOptions defines how convertTickersToUSD filters and aggregates
+// the prices of the denoms. Now is the time the confidences are computed at,
+// previous are the last prices of the denoms, which are updated in place. All
+// options may be left empty.
+type conversionOptions struct {
+	deviationFilters     map[string]DeviationFilter
+	providerMinOverrides map[string]int
+	aggregators          map[string]Aggregator
+	trust                map[provider.Name]sdk.Dec
+	volumeShareCap       sdk.Dec
+	conversionTolerance  sdk.Dec
+	now                  time.Time
+	previous             map[string]sdk.Dec
+	reputation           *Reputation
+	audit                *priceAudit
+}
+
+// convertTickersToUSD converts any tickers which are not quoted in USD to USD.
+// The pairs are modelled as a conversion graph and the USD rates of the
+// denoms are computed in order, so every denom is converted with the final
+// rate of its quotes. A provider pricing a denom on several paths only
+// contributes the price of its best path, i.e. the one with the fewest
+// conversions and the most liquidity. The prices of all paths are combined
+// into a single rate, which is rejected if any path, including the prices of
+// providers which are priced on a better path, disagrees with it by more than
+// the conversion tolerance.
+//
+// It will also filter out any tickers detected as outliers by the deviation
+// filter set by the config, which checks one or two tickers against the
+// previous prices. The previous prices, if not nil, are updated with the
+// computed rates and with single prices rejected by the agreement check, so a
+// rejected jump is confirmed by the next tick instead of being compared to
+// nothing. The volumes of the tickers are converted to USD notional
+// volumes, whose share per provider is limited by the volume share cap. The
+// remaining prices of a denom are aggregated with the strategy configured for
+// it, scaled by the trust weights of the providers. The distance of the
+// tickers from the aggregated prices is observed by the reputation and the
+// audit records how each price was computed, both may be nil.
+//
+// Along with the rates it returns their confidence, see priceConfidence. The
+// confidence of the quote rates is carried along the conversions.
 //
 // Ref: https://github.com/umee-network/umee/blob/4348c3e433df8c37dd98a690e96fc275de609bc1/price-feeder/oracle/filter.go#L41
 func convertTickersToUSD(
 	logger zerolog.Logger,
 	providerPrices provider.AggregatedProviderPrices,
 	providerPairs map[provider.Name][]types.CurrencyPair,
-	deviationThresholds map[string]sdk.Dec,
-	providerMinOverrides map[string]int,
-) (map[string]sdk.Dec, error) {
+	opts conversionOptions,
+) (map[string]sdk.Dec, map[string]sdk.Dec, error) {
 
 	if len(providerPrices) == 0 {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	// group ticker prices by symbol
@@ -46,140 +202,134 @@ func convertTickersToUSD(
 		for _, currencyPair := range currencyPairs {
 			symbol := currencyPair.String()
 			_, found := symbols[symbol]
-			if !found {
+			if !found && len(providerPricesBySymbol[symbol]) > 0 {
 				symbols[symbol] = struct{}{}
 				pairs = append(pairs, currencyPair)
 			}
 		}
 	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].String() < pairs[j].String()
+	})
 
 	// calculate USD values
 
-	// more than 6 conversions for the USD price is probably not very accurate
-	maxConversions := 6
-	usdRates := map[string]map[provider.Name]types.TickerPrice{}
-
-	for i := 0; i < maxConversions; i++ {
-		// reorder pairs
-
-		sort.Slice(pairs, func(i, j int) bool {
-			return pairs[i].String() < pairs[j].String()
-		})
-
-		// Process denoms that currently have no USD rate yet at last. This allows
-		// to add more prices for already existing USD rates which are used to calculate
-		// the prices for the remaining denoms
-
-		reordered := []types.CurrencyPair{}
-		for _, pair := range pairs {
-			_, found := usdRates[pair.Base]
-			if found {
-				reordered = append([]types.CurrencyPair{pair}, reordered...)
-			} else {
-				reordered = append(reordered, pair)
+	graph := newConversionGraph(pairs)
+	ratesDec := map[string]sdk.Dec{}
+	confidences := map[string]sdk.Dec{}
+	bestPaths := map[string]conversionPath{}
+	liquidity := map[string]sdk.Dec{}
+
+	for _, denom := range graph.order {
+		paths := []conversionPath{}
+		for _, currencyPair := range graph.edges[denom] {
+			path := conversionPath{
+				pair:       currencyPair,
+				symbols:    []string{currencyPair.String()},
+				hops:       1,
+				liquidity:  sdk.ZeroDec(),
+				rate:       sdk.OneDec(),
+				confidence: sdk.ZeroDec(),
+				tickers:    map[provider.Name]types.TickerPrice{},
 			}
-		}
-
-		pairs = reordered
-
-		unresolved := []types.CurrencyPair{}
-
-		for _, currencyPair := range pairs {
-			symbol := currencyPair.String()
-			base := currencyPair.Base
-			quote := currencyPair.Quote
-
-			maxDeviation := deviationThresholds[quote]
-			tickerPrices := providerPricesBySymbol[symbol]
-
-			newRates := map[provider.Name]types.TickerPrice{}
-
-			if quote == "USD" {
-				for providerName, tickerPrice := range tickerPrices {
-					newRates[providerName] = tickerPrice
-				}
-			} else {
-				minProviders, found := providerMinOverrides[quote]
+			if currencyPair.Quote != config.DenomUSD {
+				rate, found := ratesDec[currencyPair.Quote]
 				if !found {
-					minProviders = 3
+					continue
 				}
-
-				// a minimum of 3 usd prices are needed
-				rates, found := usdRates[quote]
-				if !found || len(rates) < minProviders {
-					unresolved = append(unresolved, currencyPair)
+				quotePath := bestPaths[currencyPair.Quote]
+				path.rate = rate
+				path.confidence = confidences[currencyPair.Quote].Quo(rate)
+				path.symbols = append(path.symbols, quotePath.symbols...)
+				path.hops += quotePath.hops
+				if path.hops > maxConversions {
 					continue
 				}
+			}
 
-				filtered, err := FilterTickerDeviations(
-					logger, symbol, rates, maxDeviation,
-				)
-				if err != nil {
-					if len(rates) >= 3 {
-						unresolved = append(unresolved, currencyPair)
-						continue
-					}
+			for providerName, tickerPrice := range providerPricesBySymbol[currencyPair.String()] {
+				price := tickerPrice.Price.Mul(path.rate)
+				confidence := price.Mul(path.confidence)
+				if !tickerPrice.Confidence.IsNil() {
+					confidence = confidence.Add(tickerPrice.Confidence.Mul(path.rate))
 				}
-
-				rate, err := vwapRate(filtered)
-				if err != nil {
-					return nil, err
+				path.tickers[providerName] = types.TickerPrice{
+					Price:      price,
+					Volume:     tickerPrice.Volume.Mul(price),
+					Time:       tickerPrice.Time,
+					Confidence: confidence,
 				}
+				path.liquidity = path.liquidity.Add(tickerPrice.Volume.Mul(price))
+			}
+			if currencyPair.Quote != config.DenomUSD && liquidity[currencyPair.Quote].LT(path.liquidity) {
+				path.liquidity = liquidity[currencyPair.Quote]
+			}
+			paths = append(paths, path)
+		}
 
-				for providerName, tickerPrice := range tickerPrices {
-					newRates[providerName] = types.TickerPrice{
-						Price:  tickerPrice.Price.Mul(rate),
-						Volume: tickerPrice.Volume,
-						Time:   tickerPrice.Time,
-					}
-				}
+		// the most direct and liquid paths first
+		sort.SliceStable(paths, func(i, j int) bool {
+			if paths[i].hops != paths[j].hops {
+				return paths[i].hops < paths[j].hops
 			}
+			return paths[i].liquidity.GT(paths[j].liquidity)
+		})
 
-			if len(newRates) > 0 {
-				newRates, err := addRates(
-					logger,
-					symbol,
-					usdRates[base],
-					newRates,
+		tickers := map[provider.Name]types.TickerPrice{}
+		for _, path := range paths {
+			for _, providerName := range sortedProviders(path.tickers) {
+				opts.audit.addSource(
+					denom,
+					path.symbols,
+					providerName,
+					providerPricesBySymbol[path.pair.String()][providerName],
+					path.rate,
 				)
-				if err != nil {
-					return nil, err
+				if _, found := tickers[providerName]; found {
+					logger.Debug().
+						Str("provider", providerName.String()).
+						Str("symbol", path.pair.String()).
+						Msg("provider already priced on a better path")
+					continue
 				}
-				usdRates[base] = newRates
+				tickers[providerName] = path.tickers[providerName]
 			}
 		}
-
-		// Stop if there are no unresolved symbols left or no symbol could
-		// be converted, in which case the list of unresolved symbols is
-		// the same as the list of pairs
-		if len(unresolved) == 0 || len(unresolved) == len(pairs) {
-			break
+		if len(tickers) == 0 {
+			continue
 		}
 
-		// Try the next round with all unresolved symbols
-		pairs = []types.CurrencyPair{}
-		pairs = append(pairs, unresolved...)
-	}
-
-	ratesDec := map[string]sdk.Dec{}
-	for denom, tickers := range usdRates {
 		for name, ticker := range tickers {
 			provider.TelemetryProviderPrice(
 				provider.Name("_"+name.String()),
-				denom+"USD",
+				denom+config.DenomUSD,
 				float32(ticker.Price.MustFloat64()),
 				float32(ticker.Volume.MustFloat64()),
 			)
 		}
 
-		threshold := deviationThresholds[denom]
-		filtered, err := FilterTickerDeviations(
-			logger, denom, tickers, threshold,
+		filtered, err := opts.deviationFilters[denom].Filter(
+			logger, denom, tickers, opts.previous[denom],
 		)
+		if opts.previous != nil && len(tickers) == 1 && len(filtered) == 0 {
+			for _, ticker := range tickers {
+				opts.previous[denom] = ticker.Price
+			}
+		}
+		reason := "deviating price"
+		if len(tickers) < 3 {
+			reason = "disagreeing price"
+		}
+		for name := range tickers {
+			if _, ok := filtered[name]; !ok {
+				opts.audit.filter(denom, name, reason)
+			}
+		}
 		if err != nil {
-			minimum, found := providerMinOverrides[denom]
+			minimum, found := opts.providerMinOverrides[denom]
 			if !found {
 				logger.Err(err)
+				opts.audit.fail(denom, err.Error())
 				continue
 			}
 			if len(filtered) < minimum {
@@ -188,13 +338,18 @@ func convertTickersToUSD(
 					Int("minimum", minimum).
 					Int("available", len(filtered)).
 					Msg("not enough tickers")
+				opts.audit.fail(denom, "not enough tickers")
 				continue
 			}
 		}
 
-		rate, err := vwapRate(filtered)
+		aggregator := aggregatorFor(opts.aggregators, denom)
+		rate, weights, err := aggregator.Aggregate(
+			weighVolumes(filtered, opts.volumeShareCap), opts.trust,
+		)
 		if err != nil {
 			logger.Err(err)
+			opts.audit.fail(denom, err.Error())
 			continue
 		}
 
@@ -202,54 +357,103 @@ func convertTickersToUSD(
 			logger.Error().
 				Str("denom", denom).
 				Msg("rate is zero")
+			opts.audit.fail(denom, "rate is zero")
+			continue
+		}
+
+		if err := checkPaths(
+			paths, filtered, aggregator, opts.trust, opts.volumeShareCap, rate, opts.conversionTolerance,
+		); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("denom", denom).
+				Msg("conversion paths disagree")
+			opts.audit.fail(denom, err.Error())
 			continue
 		}
 
 		ratesDec[denom] = rate
+		confidences[denom] = priceConfidence(rate, filtered, opts.now)
+		if opts.previous != nil {
+			opts.previous[denom] = rate
+		}
+		opts.reputation.observe(rate, tickers)
+		opts.audit.result(denom, rate, weights)
+
+		for _, path := range paths {
+			if _, found := bestPaths[denom]; !found && len(path.tickers) > 0 {
+				bestPaths[denom] = path
+			}
+		}
+		total := sdk.ZeroDec()
+		for _, ticker := range filtered {
+			total = total.Add(ticker.Volume)
+		}
+		liquidity[denom] = total
 
 		provider.TelemetryProviderPrice(
 			"_final",
-			denom+"USD",
+			denom+config.DenomUSD,
 			float32(rate.MustFloat64()),
 			float32(1),
 		)
 	}
 
-	return ratesDec, nil
+	return ratesDec, confidences, nil
 }
 
-func addRates(
-	logger zerolog.Logger,
-	symbol string,
-	rates map[provider.Name]types.TickerPrice,
-	tickers map[provider.Name]types.TickerPrice,
-) (map[provider.Name]types.TickerPrice, error) {
-	if rates == nil {
-		rates = map[provider.Name]types.TickerPrice{}
-	}
-	for providerName, tickerPrice := range tickers {
-		// Don't add new calculated USD price if there is already one from
-		// the same provider
-		_, found := rates[providerName]
-		if found {
-			logger.Info().
-				Str("provider", providerName.String()).
-				Str("symbol", symbol).
-				Msg("rate already set for provider")
+// checkPaths aggregates the prices of every path of the providers which
+// passed the deviation filter, also the prices of providers which were chosen
+// on a better path, so a provider disagreeing with itself is detected. It
+// returns an error if any of them is further away from the rate than the
+// tolerance. Without a tolerance or with a single path all rates are
+// accepted.
+func checkPaths(
+	paths []conversionPath,
+	filtered map[provider.Name]types.TickerPrice,
+	aggregator Aggregator,
+	trust map[provider.Name]sdk.Dec,
+	volumeShareCap sdk.Dec,
+	rate sdk.Dec,
+	tolerance sdk.Dec,
+) error {
+	if tolerance.IsNil() || !tolerance.IsPositive() || len(paths) < 2 {
+		return nil
+	}
+
+	for _, path := range paths {
+		tickers := map[provider.Name]types.TickerPrice{}
+		for providerName, ticker := range path.tickers {
+			if _, kept := filtered[providerName]; kept {
+				tickers[providerName] = ticker
+			}
+		}
+		if len(tickers) == 0 {
 			continue
 		}
-		rates[providerName] = tickerPrice
+
+		price, _, err := aggregator.Aggregate(weighVolumes(tickers, volumeShareCap), trust)
+		if err != nil {
+			return err
+		}
+		if price.Sub(rate).Abs().Quo(rate).GT(tolerance) {
+			return fmt.Errorf(
+				"price %s of path %s deviates from %s by more than %s",
+				price, path.pair, rate, tolerance,
+			)
+		}
 	}
-	// Filter outliers
-	// return FilterTickerDeviations(logger, symbol, rates, threshold)
-	return rates, nil
+	return nil
 }
 
-func vwapRate(rates map[provider.Name]types.TickerPrice) (sdk.Dec, error) {
-	prices := []types.TickerPrice{}
-	for _, price := range rates {
-		prices = append(prices, price)
+// sortedProviders returns the providers of the tickers sorted by name.
+func sortedProviders(tickers map[provider.Name]types.TickerPrice) []provider.Name {
+	names := make([]provider.Name, 0, len(tickers))
+	for name := range tickers {
+		names = append(names, name)
 	}
-
-	return ComputeVWAP(prices)
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
 }
@@ -2,6 +2,7 @@ package oracle
 
 import (
 	"testing"
+	"time"
 
 	"price-feeder/oracle/provider"
 	"price-feeder/oracle/types"
@@ -75,12 +76,15 @@ func TestConvertTickersToUsdChaining(t *testing.T) {
 		"ATOM":   1,
 	}
 
-	convertedTickers, err := convertTickersToUSD(
+	convertedTickers, _, err := convertTickersToUSD(
 		zerolog.Nop(),
 		providerPrices,
 		providerPairs,
-		make(map[string]sdk.Dec),
-		providerMinOverrides,
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: providerMinOverrides,
+			now:                  time.Now(),
+		},
 	)
 	require.NoError(t, err)
 
@@ -158,12 +162,15 @@ func TestConvertTickersToUSDFiltering(t *testing.T) {
 		"BTC":  1,
 	}
 
-	rates, err := convertTickersToUSD(
+	rates, _, err := convertTickersToUSD(
 		zerolog.Nop(),
 		providerPrices,
 		providerPairs,
-		make(map[string]sdk.Dec),
-		prividerMinOverrides,
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: prividerMinOverrides,
+			now:                  time.Now(),
+		},
 	)
 	require.NoError(t, err)
 
@@ -221,12 +228,15 @@ func TestConvertTickersToUsdVwap(t *testing.T) {
 		"USDT": 1,
 	}
 
-	rates, err := convertTickersToUSD(
+	rates, _, err := convertTickersToUSD(
 		zerolog.Nop(),
 		providerPrices,
 		providerPairs,
-		make(map[string]sdk.Dec),
-		providerMinOverrides,
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: providerMinOverrides,
+			now:                  time.Now(),
+		},
 	)
 	require.NoError(t, err)
 
@@ -239,12 +249,12 @@ func TestConvertTickersToUsdVwap(t *testing.T) {
 		rates["BTC"],
 	)
 
-	// BTCUSD * ETHBTC
-	// 30050 * 0.066 = 1983.3
+	// ETHBTC is converted with the final BTC rate
+	// 30006 * 0.066 = 1980.396
 
 	require.Equal(
 		t,
-		sdk.MustNewDecFromStr("1983.3"),
+		sdk.MustNewDecFromStr("1980.396"),
 		rates["ETH"],
 	)
 }
@@ -260,12 +270,15 @@ func TestConvertTickersToUsdEmptyProvider(t *testing.T) {
 		},
 	}
 
-	rates, err := convertTickersToUSD(
+	rates, _, err := convertTickersToUSD(
 		zerolog.Nop(),
 		providerPrices,
 		providerPairs,
-		make(map[string]sdk.Dec),
-		make(map[string]int),
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: make(map[string]int),
+			now:                  time.Now(),
+		},
 	)
 	require.NoError(t, err)
 
@@ -277,14 +290,160 @@ func TestConvertTickersToUsdEmptyPrices(t *testing.T) {
 
 	providerPairs := map[provider.Name][]types.CurrencyPair{}
 
-	rates, err := convertTickersToUSD(
+	rates, _, err := convertTickersToUSD(
 		zerolog.Nop(),
 		providerPrices,
 		providerPairs,
-		make(map[string]sdk.Dec),
-		make(map[string]int),
+		conversionOptions{
+			deviationFilters:     make(map[string]DeviationFilter),
+			providerMinOverrides: make(map[string]int),
+			now:                  time.Now(),
+		},
 	)
 	require.NoError(t, err)
 
 	require.Equal(t, 0, len(rates))
 }
+
+func TestConversionGraph(t *testing.T) {
+	graph := newConversionGraph([]types.CurrencyPair{
+		{Base: "ATOM", Quote: "STATOM"},
+		{Base: "ATOM", Quote: "USD"},
+		{Base: "ATOM", Quote: "USDT"},
+		{Base: "STATOM", Quote: "ATOM"},
+		{Base: "USDT", Quote: "USD"},
+		{Base: "FOO", Quote: "BAR"},
+	})
+
+	require.Equal(t, map[string]int{"USD": 0, "ATOM": 1, "USDT": 1, "STATOM": 2}, graph.hops)
+
+	// ATOMSTATOM would form a cycle, as STATOM is converted via ATOM
+	require.Equal(t, []types.CurrencyPair{
+		{Base: "ATOM", Quote: "USD"},
+		{Base: "ATOM", Quote: "USDT"},
+	}, graph.edges["ATOM"])
+
+	// quotes are converted before their bases
+	require.Equal(t, []string{"USDT", "ATOM", "STATOM"}, graph.order)
+}
+
+func TestConvertTickersToUsdPaths(t *testing.T) {
+	atomUsd := types.CurrencyPair{Base: "ATOM", Quote: "USD"}
+	atomUsdt := types.CurrencyPair{Base: "ATOM", Quote: "USDT"}
+	usdtUsd := types.CurrencyPair{Base: "USDT", Quote: "USD"}
+
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderKraken: {
+			"ATOMUSD": {Price: sdk.MustNewDecFromStr("10"), Volume: sdk.OneDec()},
+			"USDTUSD": {Price: sdk.OneDec(), Volume: sdk.OneDec()},
+		},
+		provider.ProviderCoinbase: {
+			"ATOMUSD": {Price: sdk.MustNewDecFromStr("10.2"), Volume: sdk.OneDec()},
+			"USDTUSD": {Price: sdk.OneDec(), Volume: sdk.OneDec()},
+		},
+		provider.ProviderBinance: {
+			"ATOMUSD":  {Price: sdk.MustNewDecFromStr("10.1"), Volume: sdk.OneDec()},
+			"ATOMUSDT": {Price: sdk.MustNewDecFromStr("10.15"), Volume: sdk.OneDec()},
+			"USDTUSD":  {Price: sdk.OneDec(), Volume: sdk.OneDec()},
+		},
+		provider.ProviderKucoin: {
+			"ATOMUSDT": {Price: sdk.MustNewDecFromStr("10.1"), Volume: sdk.OneDec()},
+		},
+	}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderKraken:   {atomUsd, usdtUsd},
+		provider.ProviderCoinbase: {atomUsd, usdtUsd},
+		provider.ProviderBinance:  {atomUsd, atomUsdt, usdtUsd},
+		provider.ProviderKucoin:   {atomUsdt},
+	}
+	filters := map[string]DeviationFilter{"ATOM": {Threshold: sdk.NewDec(3)}}
+	tolerance := sdk.MustNewDecFromStr("0.01")
+
+	audit := newPriceAudit(time.Now())
+	rates, _, err := convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters:    filters,
+			conversionTolerance: tolerance,
+			now:                 time.Now(),
+			audit:               audit,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("10.1"), rates["ATOM"])
+
+	// binance only contributes the price of the direct path
+	audits := audit.audits()
+	require.Equal(t, "ATOM", audits[0].Denom)
+	require.Len(t, audits[0].Sources, 5)
+	for _, source := range audits[0].Sources {
+		switch {
+		case source.Provider == provider.ProviderBinance.String() && source.Filtered == "":
+			require.Equal(t, []string{"ATOMUSD"}, source.Path)
+		case source.Provider == provider.ProviderBinance.String():
+			require.Equal(t, []string{"ATOMUSDT", "USDTUSD"}, source.Path)
+			require.Equal(t, "provider priced on a better path", source.Filtered)
+		case source.Provider == provider.ProviderKucoin.String():
+			require.Equal(t, []string{"ATOMUSDT", "USDTUSD"}, source.Path)
+			require.Equal(t, sdk.MustNewDecFromStr("0.25"), source.Weight)
+		}
+	}
+
+	// the price is rejected once a provider disagrees with itself, although
+	// only its direct path is chosen
+	providerPrices[provider.ProviderBinance]["ATOMUSDT"] = types.TickerPrice{
+		Price: sdk.MustNewDecFromStr("11"), Volume: sdk.OneDec(),
+	}
+	audit = newPriceAudit(time.Now())
+	rates, _, err = convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters:    filters,
+			conversionTolerance: tolerance,
+			now:                 time.Now(),
+			audit:               audit,
+		},
+	)
+	require.NoError(t, err)
+	require.NotContains(t, rates, "ATOM")
+	require.Contains(t, audit.audits()[0].Error, "path ATOMUSDT deviates")
+
+	// the price is rejected once the USDT path disagrees with it
+	providerPrices[provider.ProviderBinance]["ATOMUSDT"] = types.TickerPrice{
+		Price: sdk.MustNewDecFromStr("10.15"), Volume: sdk.OneDec(),
+	}
+	providerPrices[provider.ProviderKucoin]["ATOMUSDT"] = types.TickerPrice{
+		Price: sdk.MustNewDecFromStr("10.5"), Volume: sdk.OneDec(),
+	}
+	audit = newPriceAudit(time.Now())
+	rates, _, err = convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters:    filters,
+			conversionTolerance: tolerance,
+			now:                 time.Now(),
+			audit:               audit,
+		},
+	)
+	require.NoError(t, err)
+	require.NotContains(t, rates, "ATOM")
+	require.Contains(t, audit.audits()[0].Error, "path ATOMUSDT deviates")
+
+	rates, _, err = convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters: filters,
+			now:              time.Now(),
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("10.2"), rates["ATOM"])
+}
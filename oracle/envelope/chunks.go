@@ -0,0 +1,104 @@
+package envelope
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+type (
+	// Assembler reassembles the payloads of chunked envelopes. Chunks must
+	// be verified before they are added, as the reassembled envelope carries
+	// no signature of its own.
+	Assembler struct {
+		sets map[string]*chunkSet
+	}
+
+	chunkSet struct {
+		first  Envelope
+		chunks map[uint32][]byte
+	}
+)
+
+func NewAssembler() *Assembler {
+	return &Assembler{
+		sets: map[string]*chunkSet{},
+	}
+}
+
+// Add adds an envelope. Once all chunks of a set have been added, the
+// envelope with the reassembled payload is returned and complete is true.
+// Envelopes which are not chunked are returned as is.
+func (a *Assembler) Add(e Envelope) (envelope Envelope, complete bool, err error) {
+	if !e.IsChunk() {
+		return e, true, nil
+	}
+	if e.CorrelationID == "" {
+		return Envelope{}, false, fmt.Errorf("chunk of feeder %s has no correlation id", e.Feeder)
+	}
+	if e.ChunkTotal > MaxChunks {
+		return Envelope{}, false, fmt.Errorf("chunk total %d exceeds %d", e.ChunkTotal, MaxChunks)
+	}
+	if e.ChunkIndex >= e.ChunkTotal {
+		return Envelope{}, false, fmt.Errorf("chunk index %d out of range of %d", e.ChunkIndex, e.ChunkTotal)
+	}
+
+	// correlation ids are chosen by the feeder, so they are only unique
+	// per feeder
+	key := e.Feeder + "/" + e.CorrelationID
+	set, ok := a.sets[key]
+	if !ok {
+		set = &chunkSet{first: e, chunks: map[uint32][]byte{}}
+		a.sets[key] = set
+	}
+	if set.first.Round != e.Round || set.first.ChunkTotal != e.ChunkTotal || set.first.Version != e.Version {
+		delete(a.sets, key)
+		return Envelope{}, false, fmt.Errorf("inconsistent chunks %s of feeder %s", e.CorrelationID, e.Feeder)
+	}
+	if _, ok := set.chunks[e.ChunkIndex]; ok {
+		return Envelope{}, false, fmt.Errorf("duplicate chunk %d of %s", e.ChunkIndex, e.CorrelationID)
+	}
+	set.chunks[e.ChunkIndex] = e.Payload
+
+	if len(set.chunks) < int(e.ChunkTotal) {
+		return Envelope{}, false, nil
+	}
+	delete(a.sets, key)
+
+	payload := make([][]byte, e.ChunkTotal)
+	for i, chunk := range set.chunks {
+		payload[i] = chunk
+	}
+
+	envelope = set.first
+	envelope.Payload = bytes.Join(payload, nil)
+	envelope.Signature = nil
+	envelope.CorrelationID = ""
+	envelope.ChunkIndex = 0
+	envelope.ChunkTotal = 0
+	return envelope, true, nil
+}
+
+// Expire rejects all incomplete sets of chunks for rounds up to the given
+// round and returns an error for each of them.
+func (a *Assembler) Expire(round uint64) []error {
+	keys := []string{}
+	for key, set := range a.sets {
+		if set.first.Round <= round {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	errs := make([]error, 0, len(keys))
+	for _, key := range keys {
+		set := a.sets[key]
+		errs = append(errs, fmt.Errorf(
+			"incomplete chunks %s of feeder %s for round %d: received %d of %d",
+			set.first.CorrelationID, set.first.Feeder, set.first.Round,
+			len(set.chunks), set.first.ChunkTotal,
+		))
+		delete(a.sets, key)
+	}
+	return errs
+}
@@ -0,0 +1,207 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+const (
+	// Version is the current schema version of the envelope. Consumers
+	// reject envelopes of unknown versions.
+	Version uint32 = 1
+
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+
+	// MaxChunks bounds the number of chunks a payload is split into.
+	MaxChunks = 20
+)
+
+// SupportedEncodings defines the encodings an envelope can be published in.
+var SupportedEncodings = map[string]struct{}{
+	EncodingJSON:     {},
+	EncodingProtobuf: {},
+}
+
+type (
+	// Envelope wraps a topic message with the metadata needed to
+	// authenticate it independently of the HCS payer. The signature covers
+	// the protobuf encoding of all other fields, so it is the same for both
+	// encodings. Payloads too large for a single message are split into
+	// chunks sharing a correlation ID, every chunk is signed on its own.
+	Envelope struct {
+		Version       uint32    `json:"version"`
+		Round         uint64    `json:"round"`
+		Feeder        string    `json:"feeder"`
+		Timestamp     time.Time `json:"timestamp"`
+		Payload       []byte    `json:"payload"`
+		Signature     []byte    `json:"signature"`
+		CorrelationID string    `json:"correlation_id,omitempty"`
+		ChunkIndex    uint32    `json:"chunk_index,omitempty"`
+		ChunkTotal    uint32    `json:"chunk_total,omitempty"`
+	}
+
+	// Signer seals payloads of a feeder into signed envelopes.
+	Signer struct {
+		feeder         string
+		key            hedera.PrivateKey
+		encoding       string
+		maxMessageSize int
+	}
+)
+
+// NewSigner returns a Signer for the given feeder. Encoded envelopes larger
+// than maxMessageSize are split into chunks, chunking is disabled if it is
+// zero.
+func NewSigner(
+	feeder string,
+	key hedera.PrivateKey,
+	encoding string,
+	maxMessageSize int,
+) (Signer, error) {
+	if _, ok := SupportedEncodings[encoding]; !ok {
+		return Signer{}, fmt.Errorf("unsupported envelope encoding: %s", encoding)
+	}
+	if maxMessageSize < 0 {
+		return Signer{}, fmt.Errorf("invalid max message size: %d", maxMessageSize)
+	}
+	return Signer{
+		feeder:         feeder,
+		key:            key,
+		encoding:       encoding,
+		maxMessageSize: maxMessageSize,
+	}, nil
+}
+
+// Seal wraps the payload into an envelope for the given round, signs and
+// encodes it. If the encoded envelope exceeds the max message size, the
+// payload is split into the least number of chunks that fit.
+func (s Signer) Seal(round uint64, payload []byte) ([][]byte, error) {
+	envelope := Envelope{
+		Version:   Version,
+		Round:     round,
+		Feeder:    s.feeder,
+		Timestamp: time.Now().UTC(),
+		Payload:   payload,
+	}
+	bz, err := s.seal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	if s.maxMessageSize == 0 || len(bz) <= s.maxMessageSize {
+		return [][]byte{bz}, nil
+	}
+
+	correlationID, err := newCorrelationID()
+	if err != nil {
+		return nil, err
+	}
+	envelope.CorrelationID = correlationID
+
+	for total := 2; total <= MaxChunks && total <= len(payload); total++ {
+		size := (len(payload) + total - 1) / total
+		chunks := make([][]byte, 0, total)
+		for i := 0; i < total; i++ {
+			chunk := envelope
+			chunk.Payload = payload[i*size : min((i+1)*size, len(payload))]
+			chunk.ChunkIndex = uint32(i)
+			chunk.ChunkTotal = uint32(total)
+
+			bz, err := s.seal(chunk)
+			if err != nil {
+				return nil, err
+			}
+			if len(bz) > s.maxMessageSize {
+				break
+			}
+			chunks = append(chunks, bz)
+		}
+		if len(chunks) == total {
+			return chunks, nil
+		}
+	}
+
+	return nil, fmt.Errorf(
+		"payload of %d bytes does not fit into %d messages of %d bytes",
+		len(payload), MaxChunks, s.maxMessageSize,
+	)
+}
+
+func (s Signer) seal(envelope Envelope) ([]byte, error) {
+	envelope.Sign(s.key)
+	return envelope.Marshal(s.encoding)
+}
+
+// IsChunk reports whether the envelope carries a chunk of a larger payload.
+func (e Envelope) IsChunk() bool {
+	return e.ChunkTotal > 0
+}
+
+// SignBytes returns the bytes covered by the signature.
+func (e Envelope) SignBytes() []byte {
+	e.Signature = nil
+	return e.MarshalProto()
+}
+
+// Sign signs the envelope with the given key.
+func (e *Envelope) Sign(key hedera.PrivateKey) {
+	e.Signature = key.Sign(e.SignBytes())
+}
+
+// Verify checks the schema version of the envelope and that it was signed
+// by the given key.
+func (e Envelope) Verify(key hedera.PublicKey) error {
+	if e.Version != Version {
+		return fmt.Errorf("unsupported envelope version: %d", e.Version)
+	}
+	if len(e.Signature) == 0 {
+		return fmt.Errorf("envelope of feeder %s is not signed", e.Feeder)
+	}
+	if !key.Verify(e.SignBytes(), e.Signature) {
+		return fmt.Errorf("invalid signature of feeder %s", e.Feeder)
+	}
+	return nil
+}
+
+// Marshal encodes the envelope with the given encoding.
+func (e Envelope) Marshal(encoding string) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON:
+		return json.Marshal(e)
+	case EncodingProtobuf:
+		return e.MarshalProto(), nil
+	default:
+		return nil, fmt.Errorf("unsupported envelope encoding: %s", encoding)
+	}
+}
+
+// Unmarshal decodes an envelope of either encoding. JSON envelopes are
+// detected by their leading brace, which is never the first byte of the
+// protobuf encoding.
+func Unmarshal(bz []byte) (Envelope, error) {
+	var e Envelope
+	if bytes.HasPrefix(bytes.TrimSpace(bz), []byte("{")) {
+		if err := json.Unmarshal(bz, &e); err != nil {
+			return Envelope{}, err
+		}
+		return e, nil
+	}
+	if err := e.UnmarshalProto(bz); err != nil {
+		return Envelope{}, err
+	}
+	return e, nil
+}
+
+func newCorrelationID() (string, error) {
+	bz := make([]byte, 8)
+	if _, err := rand.Read(bz); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bz), nil
+}
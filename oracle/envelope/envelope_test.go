@@ -0,0 +1,166 @@
+package envelope
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_Seal(t *testing.T) {
+	key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+	other, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+
+	_, err = NewSigner("0.0.1", key, "xml", 0)
+	require.Error(t, err)
+	_, err = NewSigner("0.0.1", key, EncodingJSON, -1)
+	require.Error(t, err)
+
+	for encoding := range SupportedEncodings {
+		signer, err := NewSigner("0.0.1", key, encoding, 1024)
+		require.NoError(t, err)
+
+		chunks, err := signer.Seal(42, []byte(`{"feeder":"0.0.1"}`))
+		require.NoError(t, err)
+		require.Len(t, chunks, 1)
+
+		e, err := Unmarshal(chunks[0])
+		require.NoError(t, err, encoding)
+		require.Equal(t, Version, e.Version)
+		require.Equal(t, uint64(42), e.Round)
+		require.Equal(t, "0.0.1", e.Feeder)
+		require.False(t, e.Timestamp.IsZero())
+		require.Equal(t, []byte(`{"feeder":"0.0.1"}`), e.Payload)
+		require.False(t, e.IsChunk())
+
+		require.NoError(t, e.Verify(key.PublicKey()), encoding)
+		require.Error(t, e.Verify(other.PublicKey()), encoding)
+
+		tampered := e
+		tampered.Round = 43
+		require.Error(t, tampered.Verify(key.PublicKey()), encoding)
+
+		unsigned := e
+		unsigned.Signature = nil
+		require.Error(t, unsigned.Verify(key.PublicKey()), encoding)
+	}
+}
+
+func TestEnvelope_Verify_version(t *testing.T) {
+	key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+
+	e := Envelope{Version: Version + 1, Feeder: "0.0.1"}
+	e.Sign(key)
+	require.Error(t, e.Verify(key.PublicKey()))
+}
+
+func TestEnvelope_UnmarshalProto(t *testing.T) {
+	e := Envelope{Version: Version, Round: 1, Feeder: "0.0.1", Payload: []byte("foo")}
+	bz := e.MarshalProto()
+
+	var decoded Envelope
+	require.NoError(t, decoded.UnmarshalProto(bz))
+	require.Equal(t, e, decoded)
+
+	// unknown fields are skipped
+	require.NoError(t, decoded.UnmarshalProto(append(bz, 0x50, 0x01)))
+	require.Equal(t, e, decoded)
+
+	chunk := Envelope{Version: Version, CorrelationID: "abc", ChunkIndex: 1, ChunkTotal: 2}
+	require.NoError(t, decoded.UnmarshalProto(chunk.MarshalProto()))
+	require.Equal(t, chunk, decoded)
+
+	require.Error(t, decoded.UnmarshalProto(bz[:len(bz)-1]))
+}
+
+func TestSigner_Seal_chunks(t *testing.T) {
+	key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+
+	payload := []byte(strings.Repeat("0123456789", 300))
+	for encoding := range SupportedEncodings {
+		signer, err := NewSigner("0.0.1", key, encoding, 1024)
+		require.NoError(t, err)
+
+		chunks, err := signer.Seal(42, payload)
+		require.NoError(t, err)
+		require.Greater(t, len(chunks), 1, encoding)
+
+		assembler := NewAssembler()
+		var assembled Envelope
+		for i, bz := range chunks {
+			require.LessOrEqual(t, len(bz), 1024, encoding)
+
+			e, err := Unmarshal(bz)
+			require.NoError(t, err)
+			require.NoError(t, e.Verify(key.PublicKey()))
+			require.Equal(t, uint32(i), e.ChunkIndex)
+			require.Equal(t, uint32(len(chunks)), e.ChunkTotal)
+
+			var complete bool
+			assembled, complete, err = assembler.Add(e)
+			require.NoError(t, err)
+			require.Equal(t, i == len(chunks)-1, complete)
+		}
+		require.Equal(t, payload, assembled.Payload)
+		require.Equal(t, uint64(42), assembled.Round)
+		require.False(t, assembled.IsChunk())
+	}
+
+	signer, err := NewSigner("0.0.1", key, EncodingJSON, 256)
+	require.NoError(t, err)
+	_, err = signer.Seal(42, []byte(strings.Repeat("0123456789", 1000)))
+	require.Error(t, err)
+}
+
+func TestAssembler(t *testing.T) {
+	chunk := func(id string, index, total uint32, round uint64) Envelope {
+		return Envelope{
+			Version:       Version,
+			Round:         round,
+			Feeder:        "0.0.1",
+			Payload:       []byte{byte(index)},
+			CorrelationID: id,
+			ChunkIndex:    index,
+			ChunkTotal:    total,
+		}
+	}
+
+	assembler := NewAssembler()
+
+	// chunks may arrive out of order
+	_, complete, err := assembler.Add(chunk("a", 1, 2, 1))
+	require.NoError(t, err)
+	require.False(t, complete)
+	_, _, err = assembler.Add(chunk("a", 1, 2, 1))
+	require.Error(t, err)
+	e, complete, err := assembler.Add(chunk("a", 0, 2, 1))
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.Equal(t, []byte{0, 1}, e.Payload)
+
+	_, _, err = assembler.Add(chunk("b", 2, 2, 1))
+	require.Error(t, err)
+	_, _, err = assembler.Add(chunk("", 0, 2, 1))
+	require.Error(t, err)
+	_, _, err = assembler.Add(chunk("c", 0, MaxChunks+1, 1))
+	require.Error(t, err)
+
+	_, _, err = assembler.Add(chunk("d", 0, 3, 1))
+	require.NoError(t, err)
+	_, _, err = assembler.Add(chunk("d", 1, 2, 1))
+	require.Error(t, err)
+
+	// incomplete sets are rejected once their round is over
+	_, _, err = assembler.Add(chunk("e", 0, 2, 1))
+	require.NoError(t, err)
+	_, _, err = assembler.Add(chunk("f", 0, 2, 2))
+	require.NoError(t, err)
+	require.Len(t, assembler.Expire(1), 1)
+	require.Empty(t, assembler.Expire(1))
+	require.Len(t, assembler.Expire(2), 1)
+}
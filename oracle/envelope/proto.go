@@ -0,0 +1,164 @@
+package envelope
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The protobuf encoding follows this schema:
+//
+//	message Envelope {
+//	  uint32 version   = 1;
+//	  uint64 round     = 2;
+//	  string feeder    = 3;
+//	  int64  timestamp = 4; // unix nanoseconds
+//	  bytes  payload   = 5;
+//	  bytes  signature = 6;
+//	  string correlation_id = 7;
+//	  uint32 chunk_index    = 8;
+//	  uint32 chunk_total    = 9;
+//	}
+//
+// Fields are written in field order and zero values are omitted, so the
+// encoding is deterministic and can be signed.
+const (
+	fieldVersion protowire.Number = iota + 1
+	fieldRound
+	fieldFeeder
+	fieldTimestamp
+	fieldPayload
+	fieldSignature
+	fieldCorrelationID
+	fieldChunkIndex
+	fieldChunkTotal
+)
+
+// MarshalProto returns the protobuf encoding of the envelope.
+func (e Envelope) MarshalProto() []byte {
+	var bz []byte
+	if e.Version != 0 {
+		bz = protowire.AppendTag(bz, fieldVersion, protowire.VarintType)
+		bz = protowire.AppendVarint(bz, uint64(e.Version))
+	}
+	if e.Round != 0 {
+		bz = protowire.AppendTag(bz, fieldRound, protowire.VarintType)
+		bz = protowire.AppendVarint(bz, e.Round)
+	}
+	if e.Feeder != "" {
+		bz = protowire.AppendTag(bz, fieldFeeder, protowire.BytesType)
+		bz = protowire.AppendString(bz, e.Feeder)
+	}
+	if !e.Timestamp.IsZero() {
+		bz = protowire.AppendTag(bz, fieldTimestamp, protowire.VarintType)
+		bz = protowire.AppendVarint(bz, uint64(e.Timestamp.UnixNano()))
+	}
+	if len(e.Payload) > 0 {
+		bz = protowire.AppendTag(bz, fieldPayload, protowire.BytesType)
+		bz = protowire.AppendBytes(bz, e.Payload)
+	}
+	if len(e.Signature) > 0 {
+		bz = protowire.AppendTag(bz, fieldSignature, protowire.BytesType)
+		bz = protowire.AppendBytes(bz, e.Signature)
+	}
+	if e.CorrelationID != "" {
+		bz = protowire.AppendTag(bz, fieldCorrelationID, protowire.BytesType)
+		bz = protowire.AppendString(bz, e.CorrelationID)
+	}
+	if e.ChunkIndex != 0 {
+		bz = protowire.AppendTag(bz, fieldChunkIndex, protowire.VarintType)
+		bz = protowire.AppendVarint(bz, uint64(e.ChunkIndex))
+	}
+	if e.ChunkTotal != 0 {
+		bz = protowire.AppendTag(bz, fieldChunkTotal, protowire.VarintType)
+		bz = protowire.AppendVarint(bz, uint64(e.ChunkTotal))
+	}
+	return bz
+}
+
+// UnmarshalProto decodes the protobuf encoding of an envelope. Unknown
+// fields are skipped.
+func (e *Envelope) UnmarshalProto(bz []byte) error {
+	*e = Envelope{}
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return fmt.Errorf("invalid envelope: %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+
+		switch {
+		case num == fieldVersion && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope version: %w", protowire.ParseError(n))
+			}
+			e.Version = uint32(v)
+			bz = bz[n:]
+		case num == fieldRound && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope round: %w", protowire.ParseError(n))
+			}
+			e.Round = v
+			bz = bz[n:]
+		case num == fieldFeeder && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope feeder: %w", protowire.ParseError(n))
+			}
+			e.Feeder = v
+			bz = bz[n:]
+		case num == fieldTimestamp && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope timestamp: %w", protowire.ParseError(n))
+			}
+			e.Timestamp = time.Unix(0, int64(v)).UTC()
+			bz = bz[n:]
+		case num == fieldPayload && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope payload: %w", protowire.ParseError(n))
+			}
+			e.Payload = append([]byte(nil), v...)
+			bz = bz[n:]
+		case num == fieldSignature && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope signature: %w", protowire.ParseError(n))
+			}
+			e.Signature = append([]byte(nil), v...)
+			bz = bz[n:]
+		case num == fieldCorrelationID && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope correlation id: %w", protowire.ParseError(n))
+			}
+			e.CorrelationID = v
+			bz = bz[n:]
+		case num == fieldChunkIndex && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope chunk index: %w", protowire.ParseError(n))
+			}
+			e.ChunkIndex = uint32(v)
+			bz = bz[n:]
+		case num == fieldChunkTotal && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope chunk total: %w", protowire.ParseError(n))
+			}
+			e.ChunkTotal = uint32(v)
+			bz = bz[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, bz)
+			if n < 0 {
+				return fmt.Errorf("invalid envelope: %w", protowire.ParseError(n))
+			}
+			bz = bz[n:]
+		}
+	}
+	return nil
+}
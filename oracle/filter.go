@@ -1,7 +1,11 @@
 package oracle
 
 import (
+	"fmt"
+	"sort"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"price-feeder/config"
 	"price-feeder/oracle/provider"
 
 	"price-feeder/oracle/types"
@@ -10,10 +14,77 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// defaultDeviationThreshold defines how many 𝜎 a provider can be away
-// from the mean without being considered faulty. This can be overridden
-// in the config.
-var defaultDeviationThreshold = sdk.MustNewDecFromStr("1.0")
+var (
+	// defaultDeviationThreshold defines how many 𝜎 a provider can be away
+	// from the mean without being considered faulty. This can be overridden
+	// in the config.
+	defaultDeviationThreshold = sdk.MustNewDecFromStr("1.0")
+
+	// defaultMADThreshold defines how many scaled MADs a provider can be
+	// away from the median without being considered faulty.
+	defaultMADThreshold = sdk.MustNewDecFromStr("3.0")
+
+	// defaultPercentageThreshold defines the share of the median a provider
+	// can be away from it without being considered faulty.
+	defaultPercentageThreshold = sdk.MustNewDecFromStr("0.05")
+
+	// madScale scales the MAD to be comparable to the standard deviation of
+	// normally distributed prices.
+	madScale = sdk.MustNewDecFromStr("1.4826")
+
+	// minMADMargin defines the share of the median the MAD margin is never
+	// below. It keeps the MAD filter from dropping every price that differs
+	// at all once more than half of the prices are equal.
+	minMADMargin = sdk.MustNewDecFromStr("0.001")
+)
+
+// DeviationFilter defines how the outliers among the USD prices of a denom
+// are detected. Method selects the standard deviation around the mean, the
+// scaled median absolute deviation around the median or a percentage band
+// around the median, Threshold is the accepted distance in the unit of the
+// method. The methods need at least three prices, fewer prices are only
+// checked against each other if Agreement is set, see filterAgreement.
+type DeviationFilter struct {
+	Method    string
+	Threshold sdk.Dec
+	Agreement sdk.Dec
+}
+
+// stdDevFilters returns standard deviation filters with the given thresholds.
+func stdDevFilters(deviations map[string]sdk.Dec) map[string]DeviationFilter {
+	filters := make(map[string]DeviationFilter, len(deviations))
+	for denom, threshold := range deviations {
+		filters[denom] = DeviationFilter{Method: config.DeviationStdDev, Threshold: threshold}
+	}
+	return filters
+}
+
+// Filter returns the prices which are not outliers. Like
+// FilterTickerDeviations it returns an error if there are fewer than three
+// prices, along with the prices which passed the agreement check. The
+// previous price of the denom is used to check a single price, it may be nil.
+func (f DeviationFilter) Filter(
+	logger zerolog.Logger,
+	symbol string,
+	tickerPrices map[provider.Name]types.TickerPrice,
+	previous sdk.Dec,
+) (map[provider.Name]types.TickerPrice, error) {
+	if len(tickerPrices) < 3 {
+		if !f.Agreement.IsNil() {
+			tickerPrices = filterAgreement(logger, symbol, tickerPrices, previous, f.Agreement)
+		}
+		return tickerPrices, fmt.Errorf("not enough values to calculate deviation")
+	}
+
+	switch f.Method {
+	case config.DeviationMAD:
+		return filterMedianDeviations(logger, symbol, tickerPrices, f.Threshold, defaultMADThreshold, true)
+	case config.DeviationPercentage:
+		return filterMedianDeviations(logger, symbol, tickerPrices, f.Threshold, defaultPercentageThreshold, false)
+	default:
+		return FilterTickerDeviations(logger, symbol, tickerPrices, f.Threshold)
+	}
+}
 
 // FilterTickerDeviations finds the standard deviations of the prices of
 // all assets, and filters out any providers that are not within 2𝜎 of the mean.
@@ -64,3 +135,117 @@ func FilterTickerDeviations(
 
 	return filteredPrices, nil
 }
+
+// filterMedianDeviations filters all prices which are further away from the
+// median than the threshold times the scaled MAD, or times the median for a
+// percentage band. The MAD margin is at least minMADMargin times the median,
+// as the MAD is zero if more than half of the prices are equal.
+func filterMedianDeviations(
+	logger zerolog.Logger,
+	symbol string,
+	tickerPrices map[provider.Name]types.TickerPrice,
+	threshold sdk.Dec,
+	defaultThreshold sdk.Dec,
+	mad bool,
+) (map[provider.Name]types.TickerPrice, error) {
+	if threshold.IsNil() {
+		threshold = defaultThreshold
+	}
+
+	prices := make([]sdk.Dec, 0, len(tickerPrices))
+	for _, tickerPrice := range tickerPrices {
+		prices = append(prices, tickerPrice.Price)
+	}
+	median := medianDec(prices)
+
+	var margin sdk.Dec
+	if mad {
+		deviations := make([]sdk.Dec, len(prices))
+		for i, price := range prices {
+			deviations[i] = price.Sub(median).Abs()
+		}
+		margin = sdk.MaxDec(
+			medianDec(deviations).Mul(madScale).Mul(threshold),
+			median.Mul(minMADMargin),
+		)
+	} else {
+		margin = median.Mul(threshold)
+	}
+
+	filteredPrices := map[provider.Name]types.TickerPrice{}
+	for providerName, tickerPrice := range tickerPrices {
+		if isBetween(tickerPrice.Price, median, margin) {
+			filteredPrices[providerName] = tickerPrice
+		} else {
+			telemetry.IncrCounter(1, "failure", "provider", "type", "ticker")
+			logger.Debug().
+				Str("symbol", symbol).
+				Str("provider", providerName.String()).
+				Str("price", tickerPrice.Price.String()).
+				Str("median", median.String()).
+				Str("margin", margin.String()).
+				Msg("deviating price")
+		}
+	}
+
+	return filteredPrices, nil
+}
+
+// filterAgreement checks one or two prices, which are too few to detect
+// outliers statistically. Two prices must not differ by more than the
+// agreement, relative to their mean. If they do, only a price within the
+// agreement of the previous price is accepted, or none if there is no
+// previous price. A single price is accepted if there is no previous price or
+// it is within the agreement of it, so a jump is only accepted once it is
+// confirmed in the following tick.
+func filterAgreement(
+	logger zerolog.Logger,
+	symbol string,
+	tickerPrices map[provider.Name]types.TickerPrice,
+	previous sdk.Dec,
+	agreement sdk.Dec,
+) map[provider.Name]types.TickerPrice {
+	if len(tickerPrices) == 2 {
+		prices := make([]sdk.Dec, 0, 2)
+		for _, tickerPrice := range tickerPrices {
+			prices = append(prices, tickerPrice.Price)
+		}
+		mean := prices[0].Add(prices[1]).QuoInt64(2)
+		if mean.IsPositive() && prices[0].Sub(prices[1]).Abs().Quo(mean).LTE(agreement) {
+			return tickerPrices
+		}
+	} else if previous.IsNil() || !previous.IsPositive() {
+		return tickerPrices
+	}
+
+	filteredPrices := map[provider.Name]types.TickerPrice{}
+	for providerName, tickerPrice := range tickerPrices {
+		if !previous.IsNil() && previous.IsPositive() &&
+			tickerPrice.Price.Sub(previous).Abs().Quo(previous).LTE(agreement) {
+			filteredPrices[providerName] = tickerPrice
+			continue
+		}
+		telemetry.IncrCounter(1, "failure", "provider", "type", "ticker")
+		logger.Debug().
+			Str("symbol", symbol).
+			Str("provider", providerName.String()).
+			Str("price", tickerPrice.Price.String()).
+			Msg("disagreeing price")
+	}
+	return filteredPrices
+}
+
+// medianDec returns the median of the values.
+func medianDec(values []sdk.Dec) sdk.Dec {
+	sorted := make([]sdk.Dec, len(values))
+	copy(sorted, values)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LT(sorted[j])
+	})
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[middle]
+	}
+	return sorted[middle-1].Add(sorted[middle]).QuoInt64(2)
+}
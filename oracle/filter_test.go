@@ -2,7 +2,9 @@ package oracle
 
 import (
 	"testing"
+	"time"
 
+	"price-feeder/config"
 	"price-feeder/oracle/provider"
 	"price-feeder/oracle/types"
 
@@ -104,3 +106,263 @@ func TestSuccessFilterTickerDeviations2(t *testing.T) {
 	}
 
 }
+
+func TestDeviationFilter_Methods(t *testing.T) {
+	tickerPrices := map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance:  {Price: sdk.MustNewDecFromStr("10")},
+		provider.ProviderHuobi:    {Price: sdk.MustNewDecFromStr("10.1")},
+		provider.ProviderKraken:   {Price: sdk.MustNewDecFromStr("9.9")},
+		provider.ProviderCoinbase: {Price: sdk.MustNewDecFromStr("1000")},
+	}
+
+	testCases := []struct {
+		name     string
+		filter   DeviationFilter
+		filtered bool
+	}{
+		{
+			// the wrong price skews the mean and standard deviation it is
+			// compared against
+			"stddev",
+			DeviationFilter{Method: config.DeviationStdDev, Threshold: sdk.NewDec(2)},
+			false,
+		},
+		{
+			"mad",
+			DeviationFilter{Method: config.DeviationMAD, Threshold: sdk.NewDec(3)},
+			true,
+		},
+		{
+			"mad default threshold",
+			DeviationFilter{Method: config.DeviationMAD},
+			true,
+		},
+		{
+			"percentage",
+			DeviationFilter{Method: config.DeviationPercentage, Threshold: sdk.MustNewDecFromStr("0.05")},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, err := tc.filter.Filter(zerolog.Nop(), "ATOM", tickerPrices, sdk.Dec{})
+			require.NoError(t, err)
+
+			_, found := filtered[provider.ProviderCoinbase]
+			require.Equal(t, tc.filtered, !found)
+			require.Contains(t, filtered, provider.ProviderBinance)
+			require.Contains(t, filtered, provider.ProviderHuobi)
+			require.Contains(t, filtered, provider.ProviderKraken)
+		})
+	}
+}
+
+func TestDeviationFilter_MADZero(t *testing.T) {
+	filtered, err := DeviationFilter{Method: config.DeviationMAD}.Filter(
+		zerolog.Nop(),
+		"USDT",
+		map[provider.Name]types.TickerPrice{
+			provider.ProviderBinance:  {Price: sdk.MustNewDecFromStr("1")},
+			provider.ProviderHuobi:    {Price: sdk.MustNewDecFromStr("1")},
+			provider.ProviderKraken:   {Price: sdk.MustNewDecFromStr("1")},
+			provider.ProviderCoinbase: {Price: sdk.MustNewDecFromStr("1.5")},
+		},
+		sdk.Dec{},
+	)
+	require.NoError(t, err)
+	require.Len(t, filtered, 3)
+	require.NotContains(t, filtered, provider.ProviderCoinbase)
+
+	// prices close to the equal ones are within the minimum margin
+	filtered, err = DeviationFilter{Method: config.DeviationMAD}.Filter(
+		zerolog.Nop(),
+		"USDT",
+		map[provider.Name]types.TickerPrice{
+			provider.ProviderBinance: {Price: sdk.MustNewDecFromStr("1")},
+			provider.ProviderHuobi:   {Price: sdk.MustNewDecFromStr("1")},
+			provider.ProviderKraken:  {Price: sdk.MustNewDecFromStr("1.0001")},
+		},
+		sdk.Dec{},
+	)
+	require.NoError(t, err)
+	require.Len(t, filtered, 3)
+}
+
+func TestDeviationFilter_Agreement(t *testing.T) {
+	agreement := sdk.MustNewDecFromStr("0.02")
+	ten := types.TickerPrice{Price: sdk.MustNewDecFromStr("10")}
+	tenish := types.TickerPrice{Price: sdk.MustNewDecFromStr("10.1")}
+	twelve := types.TickerPrice{Price: sdk.MustNewDecFromStr("12")}
+
+	testCases := []struct {
+		name     string
+		filter   DeviationFilter
+		prices   map[provider.Name]types.TickerPrice
+		previous sdk.Dec
+		expected []provider.Name
+	}{
+		{
+			"no agreement configured",
+			DeviationFilter{},
+			map[provider.Name]types.TickerPrice{provider.ProviderBinance: ten, provider.ProviderKraken: twelve},
+			sdk.Dec{},
+			[]provider.Name{provider.ProviderBinance, provider.ProviderKraken},
+		},
+		{
+			"two agreeing prices",
+			DeviationFilter{Agreement: agreement},
+			map[provider.Name]types.TickerPrice{provider.ProviderBinance: ten, provider.ProviderKraken: tenish},
+			sdk.Dec{},
+			[]provider.Name{provider.ProviderBinance, provider.ProviderKraken},
+		},
+		{
+			"two disagreeing prices",
+			DeviationFilter{Agreement: agreement},
+			map[provider.Name]types.TickerPrice{provider.ProviderBinance: ten, provider.ProviderKraken: twelve},
+			sdk.Dec{},
+			[]provider.Name{},
+		},
+		{
+			"two disagreeing prices with previous price",
+			DeviationFilter{Agreement: agreement},
+			map[provider.Name]types.TickerPrice{provider.ProviderBinance: ten, provider.ProviderKraken: twelve},
+			sdk.MustNewDecFromStr("10.05"),
+			[]provider.Name{provider.ProviderBinance},
+		},
+		{
+			"single price without previous price",
+			DeviationFilter{Agreement: agreement},
+			map[provider.Name]types.TickerPrice{provider.ProviderKraken: twelve},
+			sdk.Dec{},
+			[]provider.Name{provider.ProviderKraken},
+		},
+		{
+			"single price jumping from previous price",
+			DeviationFilter{Agreement: agreement},
+			map[provider.Name]types.TickerPrice{provider.ProviderKraken: twelve},
+			sdk.MustNewDecFromStr("10"),
+			[]provider.Name{},
+		},
+		{
+			"single price close to previous price",
+			DeviationFilter{Agreement: agreement},
+			map[provider.Name]types.TickerPrice{provider.ProviderBinance: tenish},
+			sdk.MustNewDecFromStr("10"),
+			[]provider.Name{provider.ProviderBinance},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, err := tc.filter.Filter(zerolog.Nop(), "ATOM", tc.prices, tc.previous)
+			require.Error(t, err)
+			require.Len(t, filtered, len(tc.expected))
+			for _, name := range tc.expected {
+				require.Contains(t, filtered, name)
+			}
+		})
+	}
+}
+
+func TestConvertTickersToUSD_Agreement(t *testing.T) {
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderBinance: {"ATOMUSD": {Price: sdk.MustNewDecFromStr("10"), Volume: sdk.OneDec()}},
+		provider.ProviderKraken:  {"ATOMUSD": {Price: sdk.MustNewDecFromStr("12"), Volume: sdk.OneDec()}},
+	}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderBinance: {{Base: "ATOM", Quote: "USD"}},
+		provider.ProviderKraken:  {{Base: "ATOM", Quote: "USD"}},
+	}
+	filters := map[string]DeviationFilter{
+		"ATOM": {Agreement: sdk.MustNewDecFromStr("0.02")},
+	}
+	minOverrides := map[string]int{"ATOM": 1}
+
+	audit := newPriceAudit(time.Now())
+	rates, _, err := convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters:     filters,
+			providerMinOverrides: minOverrides,
+			now:                  time.Now(),
+			audit:                audit,
+		},
+	)
+	require.NoError(t, err)
+	require.NotContains(t, rates, "ATOM")
+
+	audits := audit.audits()
+	require.Len(t, audits, 1)
+	require.Equal(t, "not enough tickers", audits[0].Error)
+	require.Equal(t, "disagreeing price", audits[0].Sources[0].Filtered)
+	require.Equal(t, "disagreeing price", audits[0].Sources[1].Filtered)
+
+	// the previous price decides which of the prices is right
+	rates, _, err = convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters:     filters,
+			providerMinOverrides: minOverrides,
+			now:                  time.Now(),
+			previous:             map[string]sdk.Dec{"ATOM": sdk.MustNewDecFromStr("10.1")},
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("10"), rates["ATOM"])
+}
+
+func TestConvertTickersToUSD_AgreementTicks(t *testing.T) {
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderBinance: {{Base: "ATOM", Quote: "USD"}},
+	}
+	filters := map[string]DeviationFilter{
+		"ATOM": {Agreement: sdk.MustNewDecFromStr("0.02")},
+	}
+	minOverrides := map[string]int{"ATOM": 1}
+	previous := map[string]sdk.Dec{}
+
+	tick := func(price string) (sdk.Dec, bool) {
+		providerPrices := provider.AggregatedProviderPrices{
+			provider.ProviderBinance: {"ATOMUSD": {Price: sdk.MustNewDecFromStr(price), Volume: sdk.OneDec()}},
+		}
+		rates, _, err := convertTickersToUSD(
+			zerolog.Nop(),
+			providerPrices,
+			providerPairs,
+			conversionOptions{
+				deviationFilters:     filters,
+				providerMinOverrides: minOverrides,
+				now:                  time.Now(),
+				previous:             previous,
+			},
+		)
+		require.NoError(t, err)
+		rate, ok := rates["ATOM"]
+		return rate, ok
+	}
+
+	// the first price is accepted without previous price
+	rate, ok := tick("10")
+	require.True(t, ok)
+	require.Equal(t, sdk.MustNewDecFromStr("10"), rate)
+
+	// a jump is rejected
+	_, ok = tick("20")
+	require.False(t, ok)
+
+	// another jump is rejected, as the rejected price is remembered
+	_, ok = tick("30")
+	require.False(t, ok)
+
+	// the jump is accepted once it is confirmed
+	rate, ok = tick("30")
+	require.True(t, ok)
+	require.Equal(t, sdk.MustNewDecFromStr("30"), rate)
+}
@@ -0,0 +1,121 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"price-feeder/oracle/types"
+)
+
+type auditStmts struct {
+	insert *sql.Stmt
+	query  *sql.Stmt
+	prune  *sql.Stmt
+}
+
+// Audits are stored as JSON, they are only read to explain past prices.
+func (p *PriceHistory) initAudit() error {
+	_, err := p.db.Exec(`CREATE TABLE IF NOT EXISTS price_audits(
+        denom TEXT NOT NULL,
+        time INT NOT NULL,
+        audit TEXT NOT NULL
+    )`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to create price audits table")
+		return err
+	}
+	_, err = p.db.Exec(`CREATE INDEX IF NOT EXISTS price_audits_denom_time ON price_audits(denom, time)`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to create price audits index")
+		return err
+	}
+	// pruning deletes by time across all denoms
+	_, err = p.db.Exec(`CREATE INDEX IF NOT EXISTS price_audits_time ON price_audits(time)`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to create price audits time index")
+		return err
+	}
+
+	insert, err := p.db.Prepare(`INSERT INTO price_audits(denom, time, audit) VALUES (?, ?, ?)`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prepare price audit insert statement")
+		return err
+	}
+	query, err := p.db.Prepare(`SELECT audit FROM price_audits WHERE denom = ? ORDER BY time DESC LIMIT ?`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prepare price audit query statement")
+		return err
+	}
+	prune, err := p.db.Prepare(`DELETE FROM price_audits WHERE time < ?`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prepare price audit prune statement")
+		return err
+	}
+
+	p.audit = auditStmts{
+		insert: insert,
+		query:  query,
+		prune:  prune,
+	}
+	return nil
+}
+
+// AddPriceAudits stores the explanations of the prices computed in a tick.
+func (p *PriceHistory) AddPriceAudits(audits []types.PriceAudit) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to begin price audit transaction")
+		return err
+	}
+	insert := tx.Stmt(p.audit.insert)
+	for _, audit := range audits {
+		bz, err := json.Marshal(audit)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		_, err = insert.Exec(audit.Denom, audit.Time.UnixMilli(), string(bz))
+		if err != nil {
+			_ = tx.Rollback()
+			p.logger.Error().Err(err).Str("denom", audit.Denom).Msg("failed to store price audit")
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPriceAudits returns the latest explanations of the denom, newest first.
+func (p *PriceHistory) GetPriceAudits(denom string, limit int) ([]types.PriceAudit, error) {
+	rows, err := p.audit.query.Query(denom, limit)
+	if err != nil {
+		p.logger.Error().Err(err).Str("denom", denom).Msg("failed to query price audits")
+		return nil, err
+	}
+	defer rows.Close()
+
+	audits := []types.PriceAudit{}
+	for rows.Next() {
+		var bz string
+		if err := rows.Scan(&bz); err != nil {
+			p.logger.Error().Err(err).Str("denom", denom).Msg("failed to read price audit")
+			return nil, err
+		}
+		var audit types.PriceAudit
+		if err := json.Unmarshal([]byte(bz), &audit); err != nil {
+			p.logger.Error().Err(err).Str("denom", denom).Msg("failed to parse price audit")
+			return nil, err
+		}
+		audits = append(audits, audit)
+	}
+	return audits, rows.Err()
+}
+
+// PrunePriceAudits deletes all explanations older than the given time.
+func (p *PriceHistory) PrunePriceAudits(before time.Time) error {
+	_, err := p.audit.prune.Exec(before.UnixMilli())
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prune price audits")
+	}
+	return err
+}
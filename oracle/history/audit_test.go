@@ -0,0 +1,63 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/oracle/types"
+)
+
+func TestPriceHistory_audit(t *testing.T) {
+	h, err := NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Millisecond)
+	older := now.Add(-time.Hour)
+
+	require.NoError(t, h.AddPriceAudits([]types.PriceAudit{
+		{Denom: "ATOM", Time: older, Error: "not enough tickers", Sources: []types.AuditSource{}},
+		{Denom: "OSMO", Time: older, Price: sdk.OneDec(), Sources: []types.AuditSource{}},
+	}))
+	require.NoError(t, h.AddPriceAudits([]types.PriceAudit{
+		{
+			Denom: "ATOM",
+			Time:  now,
+			Price: sdk.MustNewDecFromStr("10.5"),
+			Sources: []types.AuditSource{
+				{
+					Provider: "binance",
+					Path:     []string{"ATOMUSDT", "USDTUSD"},
+					Price:    sdk.MustNewDecFromStr("10.5"),
+					Rate:     sdk.OneDec(),
+					USDPrice: sdk.MustNewDecFromStr("10.5"),
+					Volume:   sdk.MustNewDecFromStr("100"),
+					Weight:   sdk.OneDec(),
+				},
+			},
+		},
+	}))
+
+	audits, err := h.GetPriceAudits("ATOM", 10)
+	require.NoError(t, err)
+	require.Len(t, audits, 2)
+	require.True(t, now.Equal(audits[0].Time))
+	require.Equal(t, sdk.MustNewDecFromStr("10.5"), audits[0].Price)
+	require.Equal(t, []string{"ATOMUSDT", "USDTUSD"}, audits[0].Sources[0].Path)
+	require.Equal(t, "not enough tickers", audits[1].Error)
+
+	audits, err = h.GetPriceAudits("ATOM", 1)
+	require.NoError(t, err)
+	require.Len(t, audits, 1)
+
+	require.NoError(t, h.PrunePriceAudits(now.Add(-time.Minute)))
+	audits, err = h.GetPriceAudits("ATOM", 10)
+	require.NoError(t, err)
+	require.Len(t, audits, 1)
+	audits, err = h.GetPriceAudits("OSMO", 10)
+	require.NoError(t, err)
+	require.Empty(t, audits)
+}
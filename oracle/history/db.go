@@ -12,10 +12,13 @@ import (
 
 type (
 	PriceHistory struct {
-		db     *sql.DB
-		insert *sql.Stmt
-		query  *sql.Stmt
-		logger zerolog.Logger
+		db      *sql.DB
+		insert  *sql.Stmt
+		query   *sql.Stmt
+		outbox  outboxStmts
+		prevote prevoteStmts
+		audit   auditStmts
+		logger  zerolog.Logger
 	}
 )
 
@@ -32,6 +35,14 @@ func NewPriceHistory(path string, logger zerolog.Logger) (PriceHistory, error) {
 	return p, p.Init()
 }
 
+// Close closes the underlying database.
+func (p *PriceHistory) Close() error {
+	if p.db == nil {
+		return nil
+	}
+	return p.db.Close()
+}
+
 func (p *PriceHistory) Init() error {
 	_, err := p.db.Exec(`CREATE TABLE IF NOT EXISTS crypto_ticker_prices(
         symbol TEXT NOT NULL,
@@ -63,7 +74,13 @@ func (p *PriceHistory) Init() error {
 	}
 	p.insert = insert
 	p.query = query
-	return nil
+	if err := p.initOutbox(); err != nil {
+		return err
+	}
+	if err := p.initPrevote(); err != nil {
+		return err
+	}
+	return p.initAudit()
 }
 
 func (p *PriceHistory) AddTickerPrice(pair types.CurrencyPair, provider string, ticker types.TickerPrice) error {
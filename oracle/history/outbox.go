@@ -0,0 +1,132 @@
+package history
+
+import (
+	"database/sql"
+	"time"
+)
+
+type (
+	// OutboxMessage defines a message that was handed to a publisher but
+	// has not been delivered yet.
+	OutboxMessage struct {
+		ID       int64
+		Target   string
+		Content  []byte
+		Created  time.Time
+		Expires  time.Time
+		Attempts int64
+	}
+
+	outboxStmts struct {
+		insert *sql.Stmt
+		query  *sql.Stmt
+		delete *sql.Stmt
+		retry  *sql.Stmt
+		stats  *sql.Stmt
+	}
+)
+
+// Outbox times are stored as unix milliseconds, as retries happen within
+// a single vote period, which is usually only a few seconds long.
+func (p *PriceHistory) initOutbox() error {
+	_, err := p.db.Exec(`CREATE TABLE IF NOT EXISTS outbox(
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        target TEXT NOT NULL,
+        content BLOB NOT NULL,
+        created INT NOT NULL,
+        expires INT NOT NULL,
+        attempts INT NOT NULL DEFAULT 0
+    )`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to create outbox table")
+		return err
+	}
+
+	statements := map[**sql.Stmt]string{
+		&p.outbox.insert: `INSERT INTO outbox(target, content, created, expires) VALUES (?, ?, ?, ?)`,
+		&p.outbox.query:  `SELECT id, target, content, created, expires, attempts FROM outbox WHERE target = ? ORDER BY id ASC`,
+		&p.outbox.delete: `DELETE FROM outbox WHERE id = ?`,
+		&p.outbox.retry:  `UPDATE outbox SET attempts = attempts + 1 WHERE id = ?`,
+		&p.outbox.stats:  `SELECT COUNT(*), COALESCE(MIN(created), 0) FROM outbox WHERE target = ?`,
+	}
+	for stmt, query := range statements {
+		prepared, err := p.db.Prepare(query)
+		if err != nil {
+			p.logger.Error().Err(err).Msg("failed to prepare outbox statement")
+			return err
+		}
+		*stmt = prepared
+	}
+	return nil
+}
+
+// AddOutboxMessage persists a message of the given target which is about
+// to be published and returns its id.
+func (p *PriceHistory) AddOutboxMessage(target string, content []byte, expires time.Time) (int64, error) {
+	res, err := p.outbox.insert.Exec(target, content, time.Now().UnixMilli(), expires.UnixMilli())
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to store outbox message")
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetOutboxMessages returns all pending messages of the given target,
+// oldest first.
+func (p *PriceHistory) GetOutboxMessages(target string) ([]OutboxMessage, error) {
+	rows, err := p.outbox.query.Query(target)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to query outbox messages")
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []OutboxMessage{}
+	for rows.Next() {
+		var message OutboxMessage
+		var created, expires int64
+		err := rows.Scan(&message.ID, &message.Target, &message.Content, &created, &expires, &message.Attempts)
+		if err != nil {
+			p.logger.Error().Err(err).Msg("failed to parse outbox message")
+			return nil, err
+		}
+		message.Created = time.UnixMilli(created)
+		message.Expires = time.UnixMilli(expires)
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// DeleteOutboxMessage removes a delivered or expired message.
+func (p *PriceHistory) DeleteOutboxMessage(id int64) error {
+	_, err := p.outbox.delete.Exec(id)
+	if err != nil {
+		p.logger.Error().Err(err).Int64("id", id).Msg("failed to delete outbox message")
+	}
+	return err
+}
+
+// IncrOutboxAttempts records a failed delivery attempt.
+func (p *PriceHistory) IncrOutboxAttempts(id int64) error {
+	_, err := p.outbox.retry.Exec(id)
+	if err != nil {
+		p.logger.Error().Err(err).Int64("id", id).Msg("failed to update outbox message")
+	}
+	return err
+}
+
+// GetOutboxStats returns the number of pending messages of the given target
+// and the creation time of the oldest one. The time is zero if the outbox
+// is empty.
+func (p *PriceHistory) GetOutboxStats(target string) (int64, time.Time, error) {
+	var depth, oldest int64
+	err := p.outbox.stats.QueryRow(target).Scan(&depth, &oldest)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to query outbox stats")
+		return 0, time.Time{}, err
+	}
+	if depth == 0 {
+		return 0, time.Time{}, nil
+	}
+	return depth, time.UnixMilli(oldest), nil
+}
@@ -0,0 +1,47 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceHistory_outbox(t *testing.T) {
+	h, err := NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	depth, oldest, err := h.GetOutboxStats("0.0.1234")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), depth)
+	require.True(t, oldest.IsZero())
+
+	expires := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	id, err := h.AddOutboxMessage("0.0.1234", []byte("foo"), expires)
+	require.NoError(t, err)
+	_, err = h.AddOutboxMessage("0.0.1234", []byte("bar"), expires)
+	require.NoError(t, err)
+	require.NoError(t, h.IncrOutboxAttempts(id))
+	_, err = h.AddOutboxMessage("0.0.5678", []byte("baz"), expires)
+	require.NoError(t, err)
+
+	messages, err := h.GetOutboxMessages("0.0.1234")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, "0.0.1234", messages[0].Target)
+	require.Equal(t, []byte("foo"), messages[0].Content)
+	require.Equal(t, int64(1), messages[0].Attempts)
+	require.True(t, expires.Equal(messages[0].Expires))
+
+	depth, oldest, err = h.GetOutboxStats("0.0.1234")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), depth)
+	require.False(t, oldest.IsZero())
+
+	require.NoError(t, h.DeleteOutboxMessage(id))
+	messages, err = h.GetOutboxMessages("0.0.1234")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, []byte("bar"), messages[0].Content)
+}
@@ -0,0 +1,117 @@
+package history
+
+import (
+	"database/sql"
+	"time"
+)
+
+type (
+	// Prevote defines the commit-reveal state of the last submitted prevote,
+	// which is needed to reveal the vote in the following voting period. It
+	// is stored per publish target, which defaults to the feeder account.
+	Prevote struct {
+		Target        string
+		Salt          string
+		ExchangeRates string
+		Confidences   string
+		Submitted     time.Time
+		Round         uint64
+	}
+
+	prevoteStmts struct {
+		upsert *sql.Stmt
+		query  *sql.Stmt
+		delete *sql.Stmt
+	}
+)
+
+func (p *PriceHistory) initPrevote() error {
+	// The feeder column holds the target name. Targets are named after their
+	// feeder account by default, which keeps prevotes of older versions.
+	_, err := p.db.Exec(`CREATE TABLE IF NOT EXISTS prevotes(
+        feeder TEXT NOT NULL PRIMARY KEY,
+        salt TEXT NOT NULL,
+        exchange_rates TEXT NOT NULL,
+        confidences TEXT NOT NULL,
+        submitted INT NOT NULL,
+        round INT NOT NULL
+    )`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to create prevotes table")
+		return err
+	}
+
+	upsert, err := p.db.Prepare(`INSERT OR REPLACE INTO prevotes(feeder, salt, exchange_rates, confidences, submitted, round)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prepare prevote upsert statement")
+		return err
+	}
+	query, err := p.db.Prepare(`SELECT salt, exchange_rates, confidences, submitted, round FROM prevotes WHERE feeder = ?`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prepare prevote query statement")
+		return err
+	}
+	del, err := p.db.Prepare(`DELETE FROM prevotes WHERE feeder = ?`)
+	if err != nil {
+		p.logger.Error().Err(err).Msg("failed to prepare prevote delete statement")
+		return err
+	}
+
+	p.prevote = prevoteStmts{
+		upsert: upsert,
+		query:  query,
+		delete: del,
+	}
+	return nil
+}
+
+// SetPrevote persists the prevote state of a target, replacing any
+// previously stored state.
+func (p *PriceHistory) SetPrevote(prevote Prevote) error {
+	_, err := p.prevote.upsert.Exec(
+		prevote.Target,
+		prevote.Salt,
+		prevote.ExchangeRates,
+		prevote.Confidences,
+		prevote.Submitted.UnixMilli(),
+		prevote.Round,
+	)
+	if err != nil {
+		p.logger.Error().Err(err).Str("target", prevote.Target).Msg("failed to store prevote")
+	}
+	return err
+}
+
+// GetPrevote returns the stored prevote state of a target. The returned bool
+// is false if there is none.
+func (p *PriceHistory) GetPrevote(target string) (Prevote, bool, error) {
+	prevote := Prevote{Target: target}
+	var submitted int64
+	err := p.prevote.query.QueryRow(target).Scan(
+		&prevote.Salt,
+		&prevote.ExchangeRates,
+		&prevote.Confidences,
+		&submitted,
+		&prevote.Round,
+	)
+	if err == sql.ErrNoRows {
+		return Prevote{}, false, nil
+	}
+	if err != nil {
+		p.logger.Error().Err(err).Str("target", target).Msg("failed to query prevote")
+		return Prevote{}, false, err
+	}
+	prevote.Submitted = time.UnixMilli(submitted)
+	return prevote, true, nil
+}
+
+// DeletePrevote removes the stored prevote state of a target.
+func (p *PriceHistory) DeletePrevote(target string) error {
+	_, err := p.prevote.delete.Exec(target)
+	if err != nil {
+		p.logger.Error().Err(err).Str("target", target).Msg("failed to delete prevote")
+	}
+	return err
+}
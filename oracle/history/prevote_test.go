@@ -0,0 +1,41 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceHistory_prevote(t *testing.T) {
+	h, err := NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	_, found, err := h.GetPrevote("0.0.1234")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	prevote := Prevote{
+		Target:        "0.0.1234",
+		Salt:          "abcd",
+		ExchangeRates: "1.000000000000000000UMEE",
+		Confidences:   "0.010000000000000000UMEE",
+		Submitted:     time.UnixMilli(1700000000123),
+		Round:         56666666,
+	}
+	require.NoError(t, h.SetPrevote(prevote))
+
+	prevote.Salt = "efgh"
+	require.NoError(t, h.SetPrevote(prevote))
+
+	stored, found, err := h.GetPrevote("0.0.1234")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, prevote, stored)
+
+	require.NoError(t, h.DeletePrevote("0.0.1234"))
+	_, found, err = h.GetPrevote("0.0.1234")
+	require.NoError(t, err)
+	require.False(t, found)
+}
@@ -0,0 +1,219 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+)
+
+const (
+	// pageLimit is the maximum page size supported by the mirror node.
+	pageLimit = 100
+)
+
+var errNotFound = errors.New("not found")
+
+type (
+	// Client queries the REST API of a Hedera mirror node.
+	Client struct {
+		url  string
+		http *http.Client
+	}
+
+	// TopicMessage defines a message as returned by
+	// /api/v1/topics/{topicId}/messages. The message content is base64
+	// encoded by the mirror node and decoded on unmarshalling.
+	TopicMessage struct {
+		ConsensusTimestamp string `json:"consensus_timestamp"`
+		Message            []byte `json:"message"`
+		PayerAccountID     string `json:"payer_account_id"`
+		RunningHash        []byte `json:"running_hash"`
+		SequenceNumber     uint64 `json:"sequence_number"`
+		TopicID            string `json:"topic_id"`
+	}
+
+	// Transaction defines a transaction as returned by
+	// /api/v1/transactions/{transactionId}.
+	Transaction struct {
+		ChargedTxFee       int64  `json:"charged_tx_fee"`
+		ConsensusTimestamp string `json:"consensus_timestamp"`
+		EntityID           string `json:"entity_id"`
+		Name               string `json:"name"`
+		Result             string `json:"result"`
+		TransactionID      string `json:"transaction_id"`
+	}
+
+	transactionsResponse struct {
+		Transactions []Transaction `json:"transactions"`
+	}
+
+	accountResponse struct {
+		Account string `json:"account"`
+		Key     struct {
+			Type string `json:"_type"`
+			Key  string `json:"key"`
+		} `json:"key"`
+	}
+
+	topicMessagesResponse struct {
+		Messages []TopicMessage `json:"messages"`
+		Links    struct {
+			Next string `json:"next"`
+		} `json:"links"`
+	}
+)
+
+func NewClient(url string, client *http.Client) (Client, error) {
+	if url == "" {
+		return Client{}, fmt.Errorf("mirror node url is required")
+	}
+	return Client{
+		url:  strings.TrimSuffix(url, "/"),
+		http: client,
+	}, nil
+}
+
+// GetTopicMessages returns all messages of a topic starting with the given
+// sequence number in ascending order, following the pagination links of
+// the mirror node.
+func (c Client) GetTopicMessages(
+	ctx context.Context,
+	topicID string,
+	fromSequence uint64,
+) ([]TopicMessage, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(pageLimit))
+	query.Set("order", "asc")
+	query.Set("sequencenumber", fmt.Sprintf("gte:%d", fromSequence))
+	path := fmt.Sprintf("/api/v1/topics/%s/messages?%s", url.PathEscape(topicID), query.Encode())
+
+	messages := []TopicMessage{}
+	for path != "" {
+		var res topicMessagesResponse
+		if err := c.get(ctx, path, &res); err != nil {
+			return nil, err
+		}
+		messages = append(messages, res.Messages...)
+		path = res.Links.Next
+	}
+
+	return messages, nil
+}
+
+// GetAccountKey returns the public key of an account. Accounts with key
+// lists or threshold keys are not supported.
+func (c Client) GetAccountKey(ctx context.Context, accountID string) (hedera.PublicKey, error) {
+	var res accountResponse
+	if err := c.get(ctx, "/api/v1/accounts/"+url.PathEscape(accountID), &res); err != nil {
+		return hedera.PublicKey{}, err
+	}
+
+	switch res.Key.Type {
+	case "ED25519":
+		return hedera.PublicKeyFromStringEd25519(res.Key.Key)
+	case "ECDSA_SECP256K1":
+		return hedera.PublicKeyFromStringECDSA(res.Key.Key)
+	default:
+		return hedera.PublicKey{}, fmt.Errorf("unsupported key type of account %s: %s", accountID, res.Key.Type)
+	}
+}
+
+// GetTransaction returns a transaction by its id. The returned bool is false
+// if the mirror node does not know the transaction (yet).
+func (c Client) GetTransaction(ctx context.Context, txID hedera.TransactionID) (Transaction, bool, error) {
+	var res transactionsResponse
+	err := c.get(ctx, "/api/v1/transactions/"+TransactionID(txID), &res)
+	if errors.Is(err, errNotFound) {
+		return Transaction{}, false, nil
+	}
+	if err != nil {
+		return Transaction{}, false, err
+	}
+	// scheduled and child transactions share the id of the parent, which
+	// is the first one returned
+	if len(res.Transactions) == 0 {
+		return Transaction{}, false, nil
+	}
+	return res.Transactions[0], true, nil
+}
+
+// GetTopicMessage returns the topic message which reached consensus at the
+// given timestamp.
+func (c Client) GetTopicMessage(ctx context.Context, consensusTimestamp string) (TopicMessage, error) {
+	var res TopicMessage
+	err := c.get(ctx, "/api/v1/topics/messages/"+url.PathEscape(consensusTimestamp), &res)
+	return res, err
+}
+
+func (c Client) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("mirror node returned invalid status: %d", res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// TransactionID formats a transaction id as expected by the mirror node,
+// e.g. 0.0.1234-1700000000-000000001.
+func TransactionID(txID hedera.TransactionID) string {
+	account := ""
+	if txID.AccountID != nil {
+		account = txID.AccountID.String()
+	}
+	var validStart time.Time
+	if txID.ValidStart != nil {
+		validStart = *txID.ValidStart
+	}
+	return fmt.Sprintf("%s-%d-%09d", account, validStart.Unix(), validStart.Nanosecond())
+}
+
+// ParseTimestamp parses a mirror node timestamp of the form
+// "seconds.nanoseconds".
+func ParseTimestamp(timestamp string) (time.Time, error) {
+	secStr, nsecStr, _ := strings.Cut(timestamp, ".")
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+	}
+	var nsec int64
+	if nsecStr != "" {
+		if len(nsecStr) > 9 {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", timestamp)
+		}
+		// the fraction is right padded to nanoseconds
+		nsecStr += strings.Repeat("0", 9-len(nsecStr))
+		nsec, err = strconv.ParseInt(nsecStr, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", timestamp, err)
+		}
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// ConsensusTime returns the consensus timestamp of the message.
+func (m TopicMessage) ConsensusTime() (time.Time, error) {
+	return ParseTimestamp(m.ConsensusTimestamp)
+}
@@ -0,0 +1,88 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetTopicMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/topics/0.0.1234/messages", r.URL.Path)
+		switch r.URL.Query().Get("sequencenumber") {
+		case "gte:1":
+			fmt.Fprint(w, `{"messages":[{"consensus_timestamp":"1700000000.000000001","message":"Zm9v","payer_account_id":"0.0.1","sequence_number":1,"topic_id":"0.0.1234"}],"links":{"next":"/api/v1/topics/0.0.1234/messages?sequencenumber=gt:1"}}`)
+		case "gt:1":
+			fmt.Fprint(w, `{"messages":[{"consensus_timestamp":"1700000001.5","message":"YmFy","payer_account_id":"0.0.1","sequence_number":2,"topic_id":"0.0.1234"}],"links":{"next":null}}`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL+"/", server.Client())
+	require.NoError(t, err)
+
+	messages, err := client.GetTopicMessages(context.Background(), "0.0.1234", 1)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, []byte("foo"), messages[0].Message)
+	require.Equal(t, []byte("bar"), messages[1].Message)
+	require.Equal(t, uint64(2), messages[1].SequenceNumber)
+
+	_, err = client.GetTopicMessages(context.Background(), "0.0.1234", 5)
+	require.Error(t, err)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	ts, err := ParseTimestamp("1700000000.000000001")
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1700000000, 1), ts)
+
+	ts, err = ParseTimestamp("1700000000.5")
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1700000000, 500000000), ts)
+
+	ts, err = ParseTimestamp("1700000000")
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1700000000, 0), ts)
+
+	_, err = ParseTimestamp("foo")
+	require.Error(t, err)
+}
+
+func TestClient_GetAccountKey(t *testing.T) {
+	key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/accounts/0.0.1":
+			fmt.Fprintf(w, `{"account":"0.0.1","key":{"_type":"ED25519","key":"%s"}}`, key.PublicKey().StringRaw())
+		case "/api/v1/accounts/0.0.2":
+			fmt.Fprint(w, `{"account":"0.0.2","key":{"_type":"ProtobufEncoded","key":"2a00"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL, server.Client())
+	require.NoError(t, err)
+
+	publicKey, err := client.GetAccountKey(context.Background(), "0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, key.PublicKey().StringRaw(), publicKey.StringRaw())
+
+	_, err = client.GetAccountKey(context.Background(), "0.0.2")
+	require.Error(t, err)
+
+	_, err = client.GetAccountKey(context.Background(), "0.0.3")
+	require.Error(t, err)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"github.com/tendermint/tendermint/crypto/tmhash"
 	"net/http"
@@ -17,7 +16,6 @@ import (
 	"github.com/rs/zerolog"
 	"golang.org/x/sync/errgroup"
 	"price-feeder/config"
-	"price-feeder/oracle/client"
 	"price-feeder/oracle/derivative"
 	"price-feeder/oracle/history"
 	"price-feeder/oracle/provider"
@@ -29,20 +27,22 @@ import (
 	"github.com/cosmos/cosmos-sdk/telemetry"
 )
 
-// We define tickerSleep as the minimum timeout between each oracle loop. We
-// define this value empirically based on enough time to collect exchange rates,
-// and broadcast pre-vote and vote transactions such that they're committed in
-// at least one block during each voting period.
+// We define tickerSleep as the minimum timeout between each oracle loop. It
+// also bounds how precisely the prevote and vote offsets within a voting
+// round are met.
 const (
 	tickerSleep = 1000 * time.Millisecond
 )
 
 // PreviousPrevote defines a structure for defining the previous prevote
-// submitted on-chain.
+// submitted on-chain. Confidences are revealed along with the exchange rates,
+// the prevote commits to them with a separate hash.
 type PreviousPrevote struct {
 	ExchangeRates     string
+	Confidences       string
 	Salt              string
 	SubmitBlockHeight int64
+	Round             uint64
 }
 
 func NewPreviousPrevote() *PreviousPrevote {
@@ -53,27 +53,65 @@ func NewPreviousPrevote() *PreviousPrevote {
 	}
 }
 
+// MsgAggregateExchangeRatePrevote mirrors the x/oracle prevote message and
+// adds the index of the voting round it was submitted in. Hash is the
+// GetAggregateVoteHash of the exchange rates, ConfidenceHash the one of their
+// confidences in place of the rates, if there are any.
+type MsgAggregateExchangeRatePrevote struct {
+	Hash           string `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty" yaml:"hash"`
+	Feeder         string `protobuf:"bytes,2,opt,name=feeder,proto3" json:"feeder,omitempty" yaml:"feeder"`
+	Round          uint64 `protobuf:"varint,3,opt,name=round,proto3" json:"round" yaml:"round"`
+	ConfidenceHash string `json:"confidence_hash,omitempty" yaml:"confidence_hash"`
+}
+
+// MsgAggregateExchangeRateVote mirrors the x/oracle vote message. Round is
+// the index of the voting round of the revealed prevote, Confidences the
+// confidence of each exchange rate in the same format.
 type MsgAggregateExchangeRateVote struct {
 	Salt          string `protobuf:"bytes,1,opt,name=salt,proto3" json:"salt,omitempty" yaml:"salt"`
 	ExchangeRates string `protobuf:"bytes,2,opt,name=exchange_rates,json=exchangeRates,proto3" json:"exchange_rates,omitempty" yaml:"exchange_rates"`
 	Feeder        string `protobuf:"bytes,3,opt,name=feeder,proto3" json:"feeder,omitempty" yaml:"feeder"`
+	Round         uint64 `protobuf:"varint,4,opt,name=round,proto3" json:"round" yaml:"round"`
+	Confidences   string `json:"confidences,omitempty" yaml:"confidences"`
+}
+
+// MsgPushExchangeRates publishes exchange rates directly, without a prior
+// prevote. Timestamp is the time the rates were computed in unix
+// milliseconds, Confidences the confidence of each exchange rate.
+type MsgPushExchangeRates struct {
+	ExchangeRates string `json:"exchange_rates" yaml:"exchange_rates"`
+	Confidences   string `json:"confidences,omitempty" yaml:"confidences"`
+	Feeder        string `json:"feeder" yaml:"feeder"`
+	Round         uint64 `json:"round" yaml:"round"`
+	Timestamp     int64  `json:"timestamp" yaml:"timestamp"`
 }
 
 // Oracle implements the core component responsible for fetching exchange rates
 // for a given set of currency pairs and determining the correct exchange rates
 // to submit to the on-chain price oracle adhering the oracle specification.
 type Oracle struct {
-	logger zerolog.Logger
-	closer *pfsync.Closer
+	logger   zerolog.Logger
+	closer   *pfsync.Closer
+	done     *pfsync.Closer
+	reloadCh chan reloadRequest
 
 	providerTimeout      time.Duration
+	shutdownGrace        time.Duration
+	auditRetention       time.Duration
+	auditsPruned         time.Time
 	providerPairs        map[provider.Name][]types.CurrencyPair
-	previousPrevote      *PreviousPrevote
-	previousVotePeriod   time.Time
+	targets              []*Target
+	providerMtx          sync.RWMutex
 	priceProviders       map[provider.Name]provider.Provider
-	oracleClient         *client.OracleClient
-	deviations           map[string]sdk.Dec
+	providerStatus       map[provider.Name]provider.Status
+	providerRetries      map[provider.Name]context.CancelFunc
+	deviations           map[string]DeviationFilter
 	providerMinOverrides map[string]int
+	aggregators          map[string]Aggregator
+	providerWeights      map[provider.Name]sdk.Dec
+	volumeShareCap       sdk.Dec
+	conversionTolerance  sdk.Dec
+	reputation           *Reputation
 	endpoints            map[provider.Name]provider.Endpoint
 	history              history.PriceHistory
 	derivatives          map[string]derivative.Derivative
@@ -84,17 +122,30 @@ type Oracle struct {
 	mtx             sync.RWMutex
 	lastPriceSyncTS time.Time
 	prices          map[string]sdk.Dec
+	confidences     map[string]sdk.Dec
 	paramCache      ParamCache
 	healthchecks    map[string]http.Client
+
+	// lastPrices are the last prices seen per denom, including rejected
+	// ones, which the agreement of single prices is checked against. They
+	// are only used by the goroutine setting the prices.
+	lastPrices map[string]sdk.Dec
 }
 
 func New(
 	logger zerolog.Logger,
-	oc client.OracleClient,
+	targets []*Target,
 	currencyPairs []config.CurrencyPair,
 	providerTimeout time.Duration,
-	deviations map[string]sdk.Dec,
+	shutdownGrace time.Duration,
+	auditRetention time.Duration,
+	deviations map[string]DeviationFilter,
 	providerMinOverrides map[string]int,
+	aggregators map[string]Aggregator,
+	providerWeights map[provider.Name]sdk.Dec,
+	volumeShareCap sdk.Dec,
+	conversionTolerance sdk.Dec,
+	reputation *Reputation,
 	endpoints map[provider.Name]provider.Endpoint,
 	derivatives map[string]derivative.Derivative,
 	derivativePairs map[string][]types.CurrencyPair,
@@ -103,15 +154,6 @@ func New(
 	history history.PriceHistory,
 	contractAddresses map[string]map[string]string,
 ) *Oracle {
-	providerPairs := make(map[provider.Name][]types.CurrencyPair)
-	for _, pair := range currencyPairs {
-		for _, provider := range pair.Providers {
-			providerPairs[provider] = append(providerPairs[provider], types.CurrencyPair{
-				Base:  pair.Base,
-				Quote: pair.Quote,
-			})
-		}
-	}
 	healthchecks := make(map[string]http.Client, len(healthchecksConfig))
 	for _, healthcheck := range healthchecksConfig {
 		timeout, err := time.ParseDuration(healthcheck.Timeout)
@@ -128,13 +170,23 @@ func New(
 	return &Oracle{
 		logger:               logger.With().Str("module", "oracle").Logger(),
 		closer:               pfsync.NewCloser(),
-		oracleClient:         &oc,
-		providerPairs:        providerPairs,
+		reloadCh:             make(chan reloadRequest),
+		targets:              targets,
+		providerPairs:        newProviderPairs(currencyPairs),
 		priceProviders:       make(map[provider.Name]provider.Provider),
-		previousPrevote:      nil,
+		providerStatus:       make(map[provider.Name]provider.Status),
+		providerRetries:      make(map[provider.Name]context.CancelFunc),
 		providerTimeout:      providerTimeout,
+		shutdownGrace:        shutdownGrace,
+		auditRetention:       auditRetention,
 		deviations:           deviations,
 		providerMinOverrides: providerMinOverrides,
+		aggregators:          aggregators,
+		providerWeights:      providerWeights,
+		volumeShareCap:       volumeShareCap,
+		conversionTolerance:  conversionTolerance,
+		reputation:           reputation,
+		lastPrices:           map[string]sdk.Dec{},
 		paramCache:           ParamCache{},
 		endpoints:            endpoints,
 		healthchecks:         healthchecks,
@@ -146,29 +198,54 @@ func New(
 	}
 }
 
-// Start starts the oracle process in a blocking fashion.
+// Start starts the oracle process in a blocking fashion. It returns once the
+// context is cancelled or Stop is called, after shutting down gracefully.
 func (o *Oracle) Start(ctx context.Context) error {
-	for {
-		select {
-		case <-ctx.Done():
-			o.closer.Close()
+	done := pfsync.NewCloser()
+	o.mtx.Lock()
+	o.done = done
+	o.mtx.Unlock()
+	defer done.Close()
+
+	// stops retrying to initialize providers once the oracle stopped
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for _, target := range o.targets {
+		o.restorePrevote(target)
+	}
+
+	o.initProviders(ctx)
 
-		default:
-			o.logger.Debug().Msg("starting oracle tick")
+	for {
+		o.logger.Debug().Msg("starting oracle tick")
 
-			startTime := time.Now()
+		startTime := time.Now()
 
-			if err := o.tick(ctx); err != nil {
-				telemetry.IncrCounter(1, "failure", "tick")
-				o.logger.Err(err).Msg("oracle tick failed")
-			}
+		if err := o.tick(ctx); err != nil {
+			telemetry.IncrCounter(1, "failure", "tick")
+			o.logger.Err(err).Msg("oracle tick failed")
+		}
 
-			o.lastPriceSyncTS = time.Now()
+		o.mtx.Lock()
+		o.lastPriceSyncTS = time.Now()
+		o.mtx.Unlock()
 
-			telemetry.MeasureSince(startTime, "runtime", "tick")
-			telemetry.IncrCounter(1, "new", "tick")
+		telemetry.MeasureSince(startTime, "runtime", "tick")
+		telemetry.IncrCounter(1, "new", "tick")
 
-			time.Sleep(tickerSleep)
+		select {
+		case <-ctx.Done():
+			o.shutdown()
+			return nil
+		case <-o.closer.Done():
+			cancel()
+			o.shutdown()
+			return nil
+		case req := <-o.reloadCh:
+			o.applySettings(ctx, req.settings)
+			close(req.done)
+		case <-time.After(tickerSleep):
 		}
 	}
 }
@@ -176,7 +253,60 @@ func (o *Oracle) Start(ctx context.Context) error {
 // Stop stops the oracle process and waits for it to gracefully exit.
 func (o *Oracle) Stop() {
 	o.closer.Close()
-	<-o.closer.Done()
+
+	o.mtx.RLock()
+	done := o.done
+	o.mtx.RUnlock()
+	if done != nil {
+		<-done.Done()
+	}
+}
+
+// shutdown gives pending reveals a bounded grace period to be submitted,
+// then stops all providers. The history database is left to its owner, as
+// it is shared with the outboxes of the targets.
+func (o *Oracle) shutdown() {
+	o.logger.Info().Dur("grace_period", o.shutdownGrace).Msg("shutting down oracle")
+
+	o.revealPending(time.Now().Add(o.shutdownGrace))
+
+	o.closeProviders()
+}
+
+// revealPending reveals the pending prevotes of all targets whose vote is
+// due before the deadline, which also bounds their submission. Other
+// prevotes stay persisted and are revealed after a restart, if still
+// possible.
+func (o *Oracle) revealPending(deadline time.Time) {
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	g := new(errgroup.Group)
+	for _, target := range o.targets {
+		target := target
+		if target.previousPrevote == nil {
+			continue
+		}
+
+		round := target.previousPrevote.Round + 1
+		revealAt := target.Rounds.Start(round).Add(target.Rounds.VoteOffset)
+		if revealAt.After(deadline) {
+			o.logger.Info().
+				Str("target", target.Name).
+				Time("reveal_at", revealAt).
+				Msg("pending vote not due within grace period")
+			continue
+		}
+
+		g.Go(func() error {
+			time.Sleep(time.Until(revealAt))
+			if err := o.reveal(ctx, target, time.Now()); err != nil {
+				o.logger.Warn().Err(err).Str("target", target.Name).Msg("failed to reveal pending vote")
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
 }
 
 // GetLastPriceSyncTimestamp returns the latest timestamp at which prices where
@@ -191,6 +321,21 @@ func (o *Oracle) GetLastPriceSyncTimestamp() time.Time {
 // GetPrices returns a copy of the current prices fetched from the oracle's
 // set of exchange rate providers.
 func (o *Oracle) GetPrices() sdk.DecCoins {
+	prices, _ := o.getPricesAndConfidences()
+	return prices
+}
+
+// GetConfidences returns a copy of the confidence of the current prices, see
+// priceConfidence.
+func (o *Oracle) GetConfidences() sdk.DecCoins {
+	_, confidences := o.getPricesAndConfidences()
+	return confidences
+}
+
+// getPricesAndConfidences returns copies of the current prices and their
+// confidences, read under a single lock so both belong to the same tick.
+// Zero confidences are kept, so every price has a confidence.
+func (o *Oracle) getPricesAndConfidences() (sdk.DecCoins, sdk.DecCoins) {
 	o.mtx.RLock()
 	defer o.mtx.RUnlock()
 	// Creates a new array for the prices in the oracle
@@ -200,7 +345,13 @@ func (o *Oracle) GetPrices() sdk.DecCoins {
 		prices = prices.Add(sdk.NewDecCoinFromDec(k, v))
 	}
 
-	return prices
+	// DecCoins.Add drops zero amounts
+	confidences := make(sdk.DecCoins, 0, len(o.confidences))
+	for k, v := range o.confidences {
+		confidences = append(confidences, sdk.NewDecCoinFromDec(k, v))
+	}
+
+	return prices, confidences.Sort()
 }
 
 // SetPrices retrieves all the prices and candles from our set of providers as
@@ -218,25 +369,11 @@ func (o *Oracle) SetPrices(ctx context.Context) error {
 		providerName := providerName
 		currencyPairs := currencyPairs
 
-		priceProvider, found := o.priceProviders[providerName]
+		priceProvider, found := o.getProvider(providerName)
 		if !found {
-			endpoint := o.endpoints[providerName]
-			contractAddresses, _ := o.contractAddresses[providerName.String()]
-			endpoint.ContractAddresses = contractAddresses
-
-			newProvider, err := NewProvider(
-				ctx,
-				providerName,
-				o.logger,
-				endpoint,
-				o.providerPairs[providerName]...,
-			)
-			if err != nil {
-				return err
-			}
-			priceProvider = newProvider
-
-			o.priceProviders[providerName] = priceProvider
+			o.logger.Debug().
+				Str("provider", providerName.String()).
+				Msg("skipping provider which is not initialized")
 			continue
 		}
 
@@ -342,16 +479,33 @@ func (o *Oracle) SetPrices(ctx context.Context) error {
 		}
 	}
 
-	computedPrices, err := GetComputedPrices(
+	now := time.Now()
+	var audit *priceAudit
+	if o.auditRetention > 0 {
+		audit = newPriceAudit(now)
+	}
+
+	computedPrices, confidences, err := computePrices(
 		o.logger,
 		providerPrices,
 		o.providerPairs,
-		o.deviations,
-		o.providerMinOverrides,
+		o.providerWeights,
+		conversionOptions{
+			deviationFilters:     o.deviations,
+			providerMinOverrides: o.providerMinOverrides,
+			aggregators:          o.aggregators,
+			volumeShareCap:       o.volumeShareCap,
+			conversionTolerance:  o.conversionTolerance,
+			now:                  now,
+			reputation:           o.reputation,
+			previous:             o.lastPrices,
+			audit:                audit,
+		},
 	)
 	if err != nil {
 		return err
 	}
+	o.storeAudits(audit)
 
 	if len(computedPrices) != len(requiredRates) {
 		missingPrices := []string{}
@@ -367,7 +521,10 @@ func (o *Oracle) SetPrices(ctx context.Context) error {
 		)
 	}
 
+	o.mtx.Lock()
 	o.prices = computedPrices
+	o.confidences = confidences
+	o.mtx.Unlock()
 
 	return nil
 }
@@ -383,18 +540,40 @@ func GetComputedPrices(
 	deviations map[string]sdk.Dec,
 	providerMinOverrides map[string]int,
 ) (prices map[string]sdk.Dec, err error) {
-	rates, err := convertTickersToUSD(
+	prices, _, err = computePrices(
 		logger,
 		providerPrices,
 		providerPairs,
-		deviations,
-		providerMinOverrides,
+		nil,
+		conversionOptions{
+			deviationFilters:     stdDevFilters(deviations),
+			providerMinOverrides: providerMinOverrides,
+			now:                  time.Now(),
+		},
 	)
+	return prices, err
+}
+
+// computePrices computes the USD prices like GetComputedPrices with the
+// conversion options, see convertTickersToUSD. The trust in the providers is
+// derived from their static weights and their reputation, which is updated
+// with the computed prices. Along with the prices it returns their
+// confidence.
+func computePrices(
+	logger zerolog.Logger,
+	providerPrices provider.AggregatedProviderPrices,
+	providerPairs map[provider.Name][]types.CurrencyPair,
+	providerWeights map[provider.Name]sdk.Dec,
+	opts conversionOptions,
+) (prices map[string]sdk.Dec, confidences map[string]sdk.Dec, err error) {
+	opts.trust = providerTrust(providerPrices, providerWeights, opts.reputation)
+	rates, confidences, err := convertTickersToUSD(logger, providerPrices, providerPairs, opts)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	opts.reputation.update()
 
-	return rates, nil
+	return rates, confidences, nil
 }
 
 // GetParamCache returns the last updated parameters of the x/oracle module
@@ -488,6 +667,7 @@ func (o *Oracle) checkWhitelist(params oracletypes.Params) {
 		}
 	}
 }
+
 func GetAggregateVoteHash(salt string, exchangeRatesStr string, voter string) []byte {
 	hash := tmhash.NewTruncated()
 	sourceStr := fmt.Sprintf("%s:%s:%s", salt, exchangeRatesStr, voter)
@@ -498,144 +678,6 @@ func GetAggregateVoteHash(salt string, exchangeRatesStr string, voter string) []
 	bz := hash.Sum(nil)
 	return bz
 }
-func (o *Oracle) tick(ctx context.Context) error {
-	o.logger.Debug().Msg("executing oracle tick")
-
-	//blockHeight, err := o.oracleClient.ChainHeight.GetChainHeight()
-	blockHeight := time.Now() //.Unix()
-	/*
-		if err != nil {
-			return err
-		}
-		if blockHeight < 1 {
-			return fmt.Errorf("expected positive block height")
-		}
-
-		oracleParams, err := o.GetParamCache(ctx, blockHeight)
-		if err != nil {
-			return err
-		}
-	*/
-	// Get oracle vote period, next block height, current vote period, and index
-	// in the vote period.
-
-	votingWindowEnd := o.previousVotePeriod.Add(o.oracleClient.VotePeriod)
-	nextVotingWindowEnd := votingWindowEnd.Add(o.oracleClient.VotePeriod)
-
-	//currentVotePeriod := math.Floor(float64(votingWindowEnd) / float64(oracleVotePeriod))
-	//	indexInVotePeriod := votingWindowEnd % oracleVotePeriod
-
-	o.logger.Debug().
-		Dur("vote_period", o.oracleClient.VotePeriod).
-		Time("previous_vote_period", o.previousVotePeriod).
-		Time("current_vote_period_end", votingWindowEnd).
-		//	Int64("indexInVotePeriod", indexInVotePeriod).
-		Msg("")
-
-	// Skip until new voting period. Specifically, skip when:
-	// index [0, oracleVotePeriod - 1] > oracleVotePeriod - 2 OR index is 0
-	if o.previousVotePeriod != time.Unix(0, 0) && votingWindowEnd.After(blockHeight) {
-		//||
-		//(indexInVotePeriod > 0 && oracleVotePeriod-indexInVotePeriod > 4) {
-		// oracleVotePeriod-indexInVotePeriod < 2 || (indexInVotePeriod > 0 && indexInVotePeriod < int64(float64(oracleVotePeriod)*0.75)) {
-		o.logger.Info().Time("next period starts", votingWindowEnd).
-			Msg("skipping until next voting period")
-
-		return nil
-	}
-
-	if err := o.SetPrices(ctx); err != nil {
-		return err
-	}
-
-	// If we're past the voting period we needed to hit, reset and submit another
-	// prevote.
-
-	if o.previousVotePeriod != time.Unix(0, 0) && nextVotingWindowEnd.Before(blockHeight) {
-		o.logger.Info().
-			Msg("missing vote during voting period")
-		telemetry.IncrCounter(1, "vote", "failure", "missed")
-
-		o.previousVotePeriod = time.Unix(0, 0)
-		o.previousPrevote = nil
-		return nil
-	}
-
-	salt, err := GenerateSalt(32)
-	if err != nil {
-		return err
-	}
-
-	exchangeRatesStr := GenerateExchangeRatesString(o.GetPrices())
-	hash := GetAggregateVoteHash(salt, exchangeRatesStr, o.oracleClient.OperatorAccount.String())
-	preVoteMsg := &oracletypes.MsgAggregateExchangeRatePrevote{
-		Hash:   string(hash), // hash of prices from the oracle
-		Feeder: o.oracleClient.OperatorAccount.String(),
-	}
-
-	isPrevoteOnlyTx := o.previousPrevote == nil
-	if isPrevoteOnlyTx {
-		currentHeight := blockHeight
-		// This timeout could be as small as oracleVotePeriod-indexInVotePeriod,
-		// but we give it some extra time just in case.
-		//
-		// Ref : https://github.com/terra-money/oracle-feeder/blob/baef2a4a02f57a2ffeaa207932b2e03d7fb0fb25/feeder/src/vote.ts#L222
-		o.logger.Info().
-			Str("hash", string(hash)).
-			Str("feeder", preVoteMsg.Feeder).
-			Msg("submitting pre-vote")
-		preVoteMsgBytes, err := json.Marshal(preVoteMsg)
-		if err != nil {
-			return err
-		}
-		if err := o.oracleClient.PutTx(preVoteMsgBytes); err != nil {
-			return err
-		}
-		/*
-			currentHeight, err := o.oracleClient.ChainHeight.GetChainHeight()
-			if err != nil {
-				return err
-			}
-		*/
-		o.previousVotePeriod = blockHeight //math.Floor(float64(currentHeight) / float64(oracleVotePeriod))
-		o.previousPrevote = &PreviousPrevote{
-			Salt:              salt,
-			ExchangeRates:     exchangeRatesStr,
-			SubmitBlockHeight: currentHeight.Unix(),
-		}
-	} else {
-		// otherwise, we're in the next voting period and thus we vote
-		voteMsg := &MsgAggregateExchangeRateVote{
-			Salt:          o.previousPrevote.Salt,
-			ExchangeRates: o.previousPrevote.ExchangeRates,
-			Feeder:        o.oracleClient.OperatorAccount.String(),
-		}
-		voteMsgBytes, err := json.Marshal(voteMsg)
-		if err != nil {
-			return err
-		}
-
-		o.logger.Info().
-			Str("exchange_rates", voteMsg.ExchangeRates).
-			Str("feeder", voteMsg.Feeder).
-			Msg("broadcasting vote")
-
-		if err := o.oracleClient.PutTx(
-			//		votingWindowEnd,
-			//		oracleVotePeriod-indexInVotePeriod,
-			voteMsgBytes,
-		); err != nil {
-			return err
-		}
-
-		o.previousPrevote = nil
-		o.previousVotePeriod = time.Unix(0, 0)
-		o.healthchecksPing()
-	}
-
-	return nil
-}
-
 func (o *Oracle) healthchecksPing() {
 	for url, client := range o.healthchecks {
 		o.logger.Info().Msg("updating healthcheck status")
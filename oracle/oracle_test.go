@@ -2,21 +2,28 @@ package oracle
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/hashgraph/hedera-sdk-go/v2"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"price-feeder/config"
-	"price-feeder/oracle/client"
 	"price-feeder/oracle/derivative"
+	"price-feeder/oracle/envelope"
 	"price-feeder/oracle/history"
 	"price-feeder/oracle/provider"
+	"price-feeder/oracle/publisher"
+	"price-feeder/oracle/push"
 	"price-feeder/oracle/types"
+	"price-feeder/oracle/voting"
+	pfsync "price-feeder/pkg/sync"
 )
 
 type mockProvider struct {
@@ -43,6 +50,8 @@ func (m mockProvider) CurrencyPairToProviderPair(pair types.CurrencyPair) string
 	return ""
 }
 
+func (m mockProvider) Close() {}
+
 // func (m mockProvider) ProviderPairToCurrencyPair(pair string) types.CurrencyPair {
 // 	return types.CurrencyPair{}
 // }
@@ -67,7 +76,14 @@ func (ots *OracleTestSuite) SetupSuite() {
 	ots.NoError(err)
 	ots.oracle = New(
 		zerolog.Nop(),
-		client.OracleClient{},
+		[]*Target{
+			{
+				Name:      "0.0.1",
+				Feeder:    "0.0.1",
+				Publisher: publisher.NewStdoutPublisher(zerolog.Nop()),
+				Rounds:    voting.VoteRounds{Period: 10 * time.Second},
+			},
+		},
 		[]config.CurrencyPair{
 			{
 				Base:      "UMEE",
@@ -96,8 +112,15 @@ func (ots *OracleTestSuite) SetupSuite() {
 			},
 		},
 		time.Millisecond*100,
-		make(map[string]sdk.Dec),
+		time.Second,
+		time.Hour,
+		make(map[string]DeviationFilter),
 		make(map[string]int),
+		map[string]Aggregator{},
+		map[provider.Name]sdk.Dec{},
+		sdk.Dec{},
+		sdk.Dec{},
+		NewReputation(sdk.MustNewDecFromStr("0.1"), sdk.MustNewDecFromStr("0.01")),
 		make(map[provider.Name]provider.Endpoint),
 		map[string]derivative.Derivative{},
 		map[string][]types.CurrencyPair{},
@@ -336,6 +359,19 @@ func TestGenerateSalt(t *testing.T) {
 	require.NotEmpty(t, salt)
 }
 
+func TestGetConfidences_zero(t *testing.T) {
+	o := &Oracle{
+		prices:      map[string]sdk.Dec{"ATOM": sdk.NewDec(40), "UMEE": sdk.NewDec(3)},
+		confidences: map[string]sdk.Dec{"ATOM": sdk.ZeroDec(), "UMEE": sdk.OneDec()},
+	}
+
+	prices, confidences := o.getPricesAndConfidences()
+	require.Len(t, confidences, len(prices))
+	require.Equal(t, "ATOM", confidences[0].Denom)
+	require.True(t, confidences[0].Amount.IsZero())
+	require.Equal(t, sdk.OneDec(), confidences.AmountOf("UMEE"))
+}
+
 func TestGenerateExchangeRatesString(t *testing.T) {
 	testCases := map[string]struct {
 		input    sdk.DecCoins
@@ -493,3 +529,431 @@ func TestGetComputedPricesTickersConversion(t *testing.T) {
 		prices[btcEthPair.Base],
 	)
 }
+
+func TestRestorePrevote(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	rounds, err := voting.NewVoteRounds(time.Minute, 0, 0)
+	require.NoError(t, err)
+
+	o := &Oracle{
+		logger:  zerolog.Nop(),
+		history: h,
+	}
+	target := &Target{Name: "testnet", Feeder: "0.0.1", Rounds: rounds}
+
+	// nothing persisted yet
+	o.restorePrevote(target)
+	require.Nil(t, target.previousPrevote)
+
+	// prevote of the previous round can still be revealed
+	round := rounds.Round(time.Now())
+	target.previousPrevote = &PreviousPrevote{
+		Salt:          "salt",
+		ExchangeRates: "1.0UMEE",
+		Confidences:   "0.1UMEE",
+		Round:         round - 1,
+	}
+	o.persistPrevote(target)
+
+	restored := &Target{Name: "testnet", Feeder: "0.0.1", Rounds: rounds}
+	o.restorePrevote(restored)
+	require.NotNil(t, restored.previousPrevote)
+	require.Equal(t, "salt", restored.previousPrevote.Salt)
+	require.Equal(t, "1.0UMEE", restored.previousPrevote.ExchangeRates)
+	require.Equal(t, "0.1UMEE", restored.previousPrevote.Confidences)
+	require.Equal(t, round-1, restored.previousPrevote.Round)
+
+	// prevotes are kept per target
+	other := &Target{Name: "mainnet", Feeder: "0.0.1", Rounds: rounds}
+	o.restorePrevote(other)
+	require.Nil(t, other.previousPrevote)
+
+	// prevote outside of the reveal window is discarded
+	target.previousPrevote.Round = round - 2
+	o.persistPrevote(target)
+
+	restored = &Target{Name: "testnet", Feeder: "0.0.1", Rounds: rounds}
+	o.restorePrevote(restored)
+	require.Nil(t, restored.previousPrevote)
+
+	_, found, err := h.GetPrevote("testnet")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+type recordingPublisher struct {
+	messages [][]byte
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, content []byte) error {
+	p.messages = append(p.messages, content)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error {
+	return nil
+}
+
+// newTestTarget returns a target publishing to a recording publisher and
+// a function to verify and decode its messages.
+func newTestTarget(t *testing.T, name string, rounds voting.VoteRounds) (*Target, *recordingPublisher, func([]byte, interface{})) {
+	key, err := hedera.PrivateKeyGenerateEd25519()
+	require.NoError(t, err)
+	signer, err := envelope.NewSigner(name, key, envelope.EncodingProtobuf, 0)
+	require.NoError(t, err)
+
+	pub := &recordingPublisher{}
+	target := &Target{
+		Name:      name,
+		Feeder:    name,
+		Publisher: pub,
+		Signer:    signer,
+		Rounds:    rounds,
+	}
+	unseal := func(bz []byte, msg interface{}) {
+		env, err := envelope.Unmarshal(bz)
+		require.NoError(t, err)
+		require.NoError(t, env.Verify(key.PublicKey()))
+		require.NoError(t, json.Unmarshal(env.Payload, msg))
+	}
+	return target, pub, unseal
+}
+
+func TestTickRounds(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	rounds, err := voting.NewVoteRounds(time.Hour, 0, 0)
+	require.NoError(t, err)
+
+	target, pub, unseal := newTestTarget(t, "0.0.1", rounds)
+	o := &Oracle{
+		logger:        zerolog.Nop(),
+		history:       h,
+		targets:       []*Target{target},
+		providerPairs: map[provider.Name][]types.CurrencyPair{},
+	}
+	round := rounds.Round(time.Now())
+
+	// first tick of a round submits the prevote
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, pub.messages, 1)
+
+	var prevote MsgAggregateExchangeRatePrevote
+	unseal(pub.messages[0], &prevote)
+	require.Equal(t, round, prevote.Round)
+
+	// no further messages within the same round
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, pub.messages, 1)
+
+	// pretend the prevote happened in the previous round
+	target.previousPrevote.Round = round - 1
+	target.previousPrevote.Confidences = "0.1UMEE"
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, pub.messages, 3)
+
+	// the confidences are revealed with the vote
+	var vote MsgAggregateExchangeRateVote
+	unseal(pub.messages[1], &vote)
+	require.Equal(t, round-1, vote.Round)
+	require.Equal(t, "0.1UMEE", vote.Confidences)
+
+	unseal(pub.messages[2], &prevote)
+	require.Equal(t, round, prevote.Round)
+}
+
+func TestTickRounds_targets(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	hourly, err := voting.NewVoteRounds(time.Hour, 0, 0)
+	require.NoError(t, err)
+	daily, err := voting.NewVoteRounds(24*time.Hour, 0, 0)
+	require.NoError(t, err)
+
+	first, firstPub, firstUnseal := newTestTarget(t, "0.0.1", hourly)
+	second, secondPub, secondUnseal := newTestTarget(t, "0.0.2", daily)
+	o := &Oracle{
+		logger:        zerolog.Nop(),
+		history:       h,
+		targets:       []*Target{first, second},
+		providerPairs: map[provider.Name][]types.CurrencyPair{},
+	}
+
+	// every target prevotes in its own round
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, firstPub.messages, 1)
+	require.Len(t, secondPub.messages, 1)
+
+	var prevote MsgAggregateExchangeRatePrevote
+	firstUnseal(firstPub.messages[0], &prevote)
+	require.Equal(t, "0.0.1", prevote.Feeder)
+	require.Equal(t, hourly.Round(time.Now()), prevote.Round)
+	secondUnseal(secondPub.messages[0], &prevote)
+	require.Equal(t, "0.0.2", prevote.Feeder)
+	require.Equal(t, daily.Round(time.Now()), prevote.Round)
+
+	// only the first target reveals and prevotes again
+	first.previousPrevote.Round--
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, firstPub.messages, 3)
+	require.Len(t, secondPub.messages, 1)
+
+	// both targets commit to the same prices
+	require.Equal(t, first.previousPrevote.ExchangeRates, second.previousPrevote.ExchangeRates)
+
+	_, found, err := h.GetPrevote("0.0.2")
+	require.NoError(t, err)
+	require.True(t, found)
+}
+
+func TestTickRounds_push(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	rounds, err := voting.NewVoteRounds(time.Hour, 0, 0)
+	require.NoError(t, err)
+
+	pushed, pushedPub, unseal := newTestTarget(t, "0.0.1", rounds)
+	pushed.Mode = push.ModePush
+	deviation, deviationPub, _ := newTestTarget(t, "0.0.2", rounds)
+	deviation.Mode = push.ModePushOnDeviation
+	deviation.Trigger = push.NewTrigger(push.Config{Threshold: sdk.OneDec()})
+	o := &Oracle{
+		logger:        zerolog.Nop(),
+		history:       h,
+		targets:       []*Target{pushed, deviation},
+		providerPairs: map[provider.Name][]types.CurrencyPair{},
+	}
+	round := rounds.Round(time.Now())
+
+	// prices are pushed without prevote
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, pushedPub.messages, 1)
+	require.Len(t, deviationPub.messages, 1)
+	require.Nil(t, pushed.previousPrevote)
+
+	var msg MsgPushExchangeRates
+	unseal(pushedPub.messages[0], &msg)
+	require.Equal(t, "0.0.1", msg.Feeder)
+	require.Equal(t, round, msg.Round)
+	require.NotZero(t, msg.Timestamp)
+
+	// once per round, or if the prices deviate
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, pushedPub.messages, 1)
+	require.Len(t, deviationPub.messages, 1)
+
+	_, found, err := h.GetPrevote("0.0.1")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+type staticBudget struct {
+	paused bool
+}
+
+func (b staticBudget) Paused() bool {
+	return b.paused
+}
+
+func TestTickRounds_paused(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	rounds, err := voting.NewVoteRounds(time.Hour, 0, 0)
+	require.NoError(t, err)
+
+	target, pub, _ := newTestTarget(t, "0.0.1", rounds)
+	target.Budget = staticBudget{paused: true}
+	o := &Oracle{
+		logger:        zerolog.Nop(),
+		history:       h,
+		targets:       []*Target{target},
+		providerPairs: map[provider.Name][]types.CurrencyPair{},
+	}
+	round := rounds.Round(time.Now())
+
+	// no prevote while paused
+	require.NoError(t, o.tick(context.TODO()))
+	require.Empty(t, pub.messages)
+	require.True(t, target.paused)
+
+	// pending prevotes are still revealed
+	target.previousPrevote = &PreviousPrevote{Round: round - 1}
+	require.NoError(t, o.tick(context.TODO()))
+	require.Len(t, pub.messages, 1)
+	require.Nil(t, target.previousPrevote)
+
+	// prevotes resume once the balance is restored
+	target.Budget = staticBudget{}
+	require.True(t, o.publishDue(target, time.Now()))
+	require.False(t, target.paused)
+}
+
+func TestStart_cancel(t *testing.T) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	o := &Oracle{
+		logger:        zerolog.Nop(),
+		closer:        pfsync.NewCloser(),
+		history:       h,
+		providerPairs: map[provider.Name][]types.CurrencyPair{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, o.Start(ctx))
+
+	// stopping an oracle which already returned doesn't block
+	o.Stop()
+}
+
+func TestShutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.db")
+	h, err := history.NewPriceHistory(path, zerolog.Nop())
+	require.NoError(t, err)
+
+	rounds, err := voting.NewVoteRounds(time.Hour, 0, 0)
+	require.NoError(t, err)
+	round := rounds.Round(time.Now())
+
+	due, duePub, unseal := newTestTarget(t, "0.0.1", rounds)
+	due.previousPrevote = &PreviousPrevote{Salt: "salt", ExchangeRates: "1.0UMEE", Round: round - 1}
+	pending, pendingPub, _ := newTestTarget(t, "0.0.2", rounds)
+	pending.previousPrevote = &PreviousPrevote{Salt: "salt", ExchangeRates: "1.0UMEE", Round: round}
+
+	o := &Oracle{
+		logger:        zerolog.Nop(),
+		history:       h,
+		targets:       []*Target{due, pending},
+		shutdownGrace: time.Second,
+	}
+	o.persistPrevote(pending)
+	o.shutdown()
+
+	// the vote due within the grace period is revealed
+	require.Len(t, duePub.messages, 1)
+	var vote MsgAggregateExchangeRateVote
+	unseal(duePub.messages[0], &vote)
+	require.Equal(t, round-1, vote.Round)
+	require.Nil(t, due.previousPrevote)
+
+	// the other prevote is kept for the next run
+	require.Empty(t, pendingPub.messages)
+	require.NoError(t, h.Close())
+
+	h, err = history.NewPriceHistory(path, zerolog.Nop())
+	require.NoError(t, err)
+	prevote, found, err := h.GetPrevote("0.0.2")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, round, prevote.Round)
+	require.NoError(t, h.Close())
+}
+
+func TestInitProviders(t *testing.T) {
+	pairs := []types.CurrencyPair{{Base: "ATOM", Quote: "USDT"}}
+	o := &Oracle{
+		logger: zerolog.Nop(),
+		providerPairs: map[provider.Name][]types.CurrencyPair{
+			provider.ProviderMock: pairs,
+			"unknown":             pairs,
+		},
+		endpoints:       map[provider.Name]provider.Endpoint{},
+		priceProviders:  map[provider.Name]provider.Provider{},
+		providerStatus:  map[provider.Name]provider.Status{},
+		providerRetries: map[provider.Name]context.CancelFunc{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.initProviders(ctx)
+
+	status := o.GetProviderStatus()
+	require.Equal(t, provider.StateReady, status[provider.ProviderMock].State)
+	require.Equal(t, 1, status[provider.ProviderMock].Attempts)
+	require.Equal(t, provider.StateFailed, status["unknown"].State)
+	require.Equal(t, "provider unknown not found", status["unknown"].Error)
+
+	_, ok := o.getProvider(provider.ProviderMock)
+	require.True(t, ok)
+	_, ok = o.getProvider("unknown")
+	require.False(t, ok)
+
+	// providers which aren't initialized are skipped
+	require.NoError(t, o.SetPrices(ctx))
+
+	// stops retrying the failed provider
+	cancel()
+	o.closeProviders()
+	_, ok = o.getProvider(provider.ProviderMock)
+	require.False(t, ok)
+}
+
+func TestApplySettings(t *testing.T) {
+	atom := config.CurrencyPair{Base: "ATOM", Quote: "USDT", Providers: []provider.Name{provider.ProviderMock}}
+	btc := config.CurrencyPair{Base: "BTC", Quote: "USDT", Providers: []provider.Name{provider.ProviderMock}}
+	o := &Oracle{
+		logger:          zerolog.Nop(),
+		closer:          pfsync.NewCloser(),
+		providerPairs:   newProviderPairs([]config.CurrencyPair{atom}),
+		endpoints:       map[provider.Name]provider.Endpoint{},
+		priceProviders:  map[provider.Name]provider.Provider{},
+		providerStatus:  map[provider.Name]provider.Status{},
+		providerRetries: map[provider.Name]context.CancelFunc{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.initProviders(ctx)
+	mock, ok := o.getProvider(provider.ProviderMock)
+	require.True(t, ok)
+
+	// new pairs are subscribed at the running provider
+	o.applySettings(ctx, Settings{
+		CurrencyPairs: []config.CurrencyPair{atom, btc},
+		Deviations:    map[string]DeviationFilter{"ATOM": {Threshold: sdk.NewDec(2)}},
+	})
+	reloaded, ok := o.getProvider(provider.ProviderMock)
+	require.True(t, ok)
+	require.Same(t, mock, reloaded)
+	require.Len(t, o.providerPairs[provider.ProviderMock], 2)
+	require.Equal(t, sdk.NewDec(2), o.deviations["ATOM"].Threshold)
+
+	// changed endpoints restart the provider
+	o.applySettings(ctx, Settings{
+		CurrencyPairs: []config.CurrencyPair{atom, btc},
+		Endpoints: map[provider.Name]provider.Endpoint{
+			provider.ProviderMock: {Name: provider.ProviderMock, Urls: []string{"http://localhost"}},
+		},
+	})
+	reloaded, ok = o.getProvider(provider.ProviderMock)
+	require.True(t, ok)
+	require.NotSame(t, mock, reloaded)
+	mock = reloaded
+
+	// removed pairs restart the provider with the remaining pairs
+	o.applySettings(ctx, Settings{
+		CurrencyPairs: []config.CurrencyPair{atom},
+		Endpoints: map[provider.Name]provider.Endpoint{
+			provider.ProviderMock: {Name: provider.ProviderMock, Urls: []string{"http://localhost"}},
+		},
+	})
+	reloaded, ok = o.getProvider(provider.ProviderMock)
+	require.True(t, ok)
+	require.NotSame(t, mock, reloaded)
+	require.Len(t, o.providerPairs[provider.ProviderMock], 1)
+
+	// removed providers are closed
+	o.applySettings(ctx, Settings{})
+	_, ok = o.getProvider(provider.ProviderMock)
+	require.False(t, ok)
+	require.Empty(t, o.GetProviderStatus())
+
+	// reloading a stopped oracle fails
+	o.closer.Close()
+	require.Error(t, o.Reload(ctx, Settings{}))
+}
@@ -100,12 +100,16 @@ func NewAstroportProvider(
 
 	provider.contracts = provider.endpoints.ContractAddresses
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, nil)
 
 	provider.denoms = provider.getDenoms()
 
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
@@ -78,10 +78,14 @@ func NewBinanceProvider(
 		provider.endpoints.Urls = append(provider.endpoints.Urls, urls...)
 	}
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, nil)
 
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
@@ -46,10 +46,14 @@ func NewBitfinexProvider(
 		nil,
 	)
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, currencyPairToBitfinexSymbol)
 
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
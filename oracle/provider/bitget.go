@@ -59,10 +59,14 @@ func NewBitgetProvider(
 		nil,
 	)
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, nil)
 
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
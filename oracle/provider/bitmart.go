@@ -59,7 +59,7 @@ func NewBitmartProvider(
 		nil,
 		nil,
 	)
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
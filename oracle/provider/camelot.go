@@ -101,12 +101,16 @@ func NewCamelotProvider(
 
 	provider.contracts = provider.endpoints.ContractAddresses
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, nil)
 
 	provider.init()
 
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
@@ -167,15 +171,9 @@ func (p *CamelotProvider) Poll() error {
 			continue
 		}
 
-		var tokenId int
-		_, found = p.inverse[symbol]
-		if found {
-			tokenId = 1
-		} else {
-			tokenId = 0
-		}
-
-		volume, err := p.getVolume(contract, tokenId)
+		// the volume of token0 is the base volume of the pool, it gets
+		// converted for inverse pairs like the price
+		volume, err := p.getVolume(contract, 0)
 		if err != nil {
 			p.logger.Error().Msg("failed getting volume data")
 		}
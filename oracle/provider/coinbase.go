@@ -55,12 +55,16 @@ func NewCoinbaseProvider(
 		nil,
 	)
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, currencyPairToCoinbaseSymbol)
 
 	interval := time.Duration(len(provider.getAllPairs())/10*2+1) * time.Second
 
-	go startPolling(provider, interval, logger)
+	go startPolling(provider.ctx, provider, interval, logger)
 	return provider, nil
 }
 
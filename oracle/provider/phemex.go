@@ -80,7 +80,11 @@ func NewPhemexProvider(
 		nil,
 	)
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, currencyPairToPhemexSymbol)
 
 	provider.priceScales = map[string]float64{}
@@ -119,7 +123,7 @@ func NewPhemexProvider(
 		len(provider.getAllPairs())*1700+2000,
 	) * time.Millisecond
 
-	go startPolling(provider, interval, logger)
+	go startPolling(provider.ctx, provider, interval, logger)
 	return provider, nil
 }
 
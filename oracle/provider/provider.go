@@ -65,6 +65,17 @@ const (
 	ProviderUniswapV3          Name = "uniswapv3"
 )
 
+const (
+	// StateInitializing is the state of a provider until its first attempt
+	// to initialize it finished.
+	StateInitializing State = "initializing"
+	// StateReady is the state of a provider which provides prices.
+	StateReady State = "ready"
+	// StateFailed is the state of a provider which failed to initialize and
+	// is retried in the background.
+	StateFailed State = "failed"
+)
+
 type (
 	// Provider defines an interface an exchange price provider must implement.
 	Provider interface {
@@ -78,12 +89,17 @@ type (
 		SubscribeCurrencyPairs(...types.CurrencyPair) error
 		CurrencyPairToProviderPair(types.CurrencyPair) string
 		// ProviderPairToCurrencyPair(string) types.CurrencyPair
+
+		// Close stops polling and closes the websocket connection of the
+		// provider.
+		Close()
 	}
 
 	CurrencyPairToProviderSymbol func(types.CurrencyPair) string
 
 	provider struct {
 		ctx       context.Context
+		cancel    context.CancelFunc
 		endpoints Endpoint
 		httpBase  string
 		http      *http.Client
@@ -100,6 +116,17 @@ type (
 		Poll() error
 	}
 
+	// State defines the initialization state of a provider.
+	State string
+
+	// Status defines the initialization state of a provider, the error of
+	// its last failed attempt and the number of attempts so far.
+	Status struct {
+		State    State  `json:"state"`
+		Error    string `json:"error,omitempty"`
+		Attempts int    `json:"attempts"`
+	}
+
 	// Name name of an oracle provider. Usually it is an exchange
 	// but this can be any provider name that can give token prices
 	// examples.: "binance", "osmosis", "kraken".
@@ -132,7 +159,7 @@ func (p *provider) Init(
 	websocketMessageHandler MessageHandler,
 	websocketSubscribeHandler SubscribeHandler,
 ) {
-	p.ctx = ctx
+	p.ctx, p.cancel = context.WithCancel(ctx)
 	p.endpoints = endpoints
 	p.endpoints.SetDefaults()
 	p.logger = logger.With().Str("provider", p.endpoints.Name.String()).Logger()
@@ -149,7 +176,7 @@ func (p *provider) Init(
 			Path:   p.endpoints.WebsocketPath,
 		}
 		p.websocket = NewWebsocketController(
-			ctx,
+			p.ctx,
 			p.endpoints.Name,
 			websocketUrl,
 			pairs,
@@ -164,6 +191,16 @@ func (p *provider) Init(
 	}
 }
 
+// Close stops the polling loop and the websocket of the provider.
+func (p *provider) Close() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	if p.websocket != nil {
+		p.websocket.Stop()
+	}
+}
+
 func (p *provider) GetTickerPrices(pairs ...types.CurrencyPair) (map[string]types.TickerPrice, error) {
 	p.mtx.RLock()
 	defer p.mtx.RUnlock()
@@ -407,14 +444,24 @@ func (e *Endpoint) SetDefaults() {
 	}
 }
 
-func startPolling(p PollingProvider, interval time.Duration, logger zerolog.Logger) {
+// startPolling polls the provider every interval until the context is
+// done, usually because the provider got closed.
+func startPolling(ctx context.Context, p PollingProvider, interval time.Duration, logger zerolog.Logger) {
 	logger.Debug().Dur("interval", interval).Msg("starting poll loop")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	for {
 		err := p.Poll()
 		if err != nil {
 			logger.Error().Err(err).Msg("failed to poll")
 		}
-		time.Sleep(interval)
+		select {
+		case <-ctx.Done():
+			logger.Debug().Msg("stopping poll loop")
+			return
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -472,6 +519,18 @@ func (p *provider) setPairs(
 }
 
 func (p *provider) setTickerPrice(symbol string, price sdk.Dec, volume sdk.Dec, timestamp time.Time) {
+	p.setTickerPriceConfidence(symbol, price, volume, sdk.Dec{}, timestamp)
+}
+
+// setTickerPriceConfidence sets the ticker of the symbol like setTickerPrice,
+// along with the confidence interval of the price reported by the provider.
+func (p *provider) setTickerPriceConfidence(
+	symbol string,
+	price sdk.Dec,
+	volume sdk.Dec,
+	confidence sdk.Dec,
+	timestamp time.Time,
+) {
 	if price.IsZero() {
 		p.logger.Warn().
 			Str("symbol", symbol).
@@ -479,6 +538,9 @@ func (p *provider) setTickerPrice(symbol string, price sdk.Dec, volume sdk.Dec,
 		return
 	}
 
+	// ticker volumes are the base volume of the pair over the volume window
+	volume = GetVolumeSpec(p.endpoints.Name).Normalize(price, volume)
+
 	if volume.IsZero() {
 		p.logger.Debug().
 			Str("symbol", symbol).
@@ -490,11 +552,16 @@ func (p *provider) setTickerPrice(symbol string, price sdk.Dec, volume sdk.Dec,
 	if inverse {
 		volume = volume.Mul(price)
 		price = invertDec(price)
+		// the interval of 1/x is about the interval of x divided by x²
+		if !confidence.IsNil() {
+			confidence = confidence.Mul(price).Mul(price)
+		}
 
 		p.tickers[pair.String()] = types.TickerPrice{
-			Price:  price,
-			Volume: volume,
-			Time:   timestamp,
+			Price:      price,
+			Volume:     volume,
+			Time:       timestamp,
+			Confidence: confidence,
 		}
 
 		TelemetryProviderPrice(
@@ -516,9 +583,10 @@ func (p *provider) setTickerPrice(symbol string, price sdk.Dec, volume sdk.Dec,
 	}
 
 	p.tickers[pair.String()] = types.TickerPrice{
-		Price:  price,
-		Volume: volume,
-		Time:   timestamp,
+		Price:      price,
+		Volume:     volume,
+		Time:       timestamp,
+		Confidence: confidence,
 	}
 
 	TelemetryProviderPrice(
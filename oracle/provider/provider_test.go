@@ -1,10 +1,17 @@
 package provider
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"price-feeder/oracle/types"
+	"sync/atomic"
+	"testing"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -58,3 +65,116 @@ var (
 		"BTCUSDT":  testBtcTicker,
 	}
 )
+
+type countingPoller struct {
+	polls atomic.Int32
+}
+
+func (p *countingPoller) Poll() error {
+	p.polls.Add(1)
+	return nil
+}
+
+func TestStartPolling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	poller := &countingPoller{}
+	done := make(chan struct{})
+	go func() {
+		startPolling(ctx, poller, time.Millisecond, zerolog.Nop())
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return poller.polls.Load() > 1
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
+
+func TestProvider_Close(t *testing.T) {
+	p := &provider{}
+	p.Init(
+		context.Background(),
+		Endpoint{Name: "test", Urls: []string{"http://localhost"}},
+		zerolog.Nop(),
+		nil,
+		nil,
+		nil,
+	)
+	require.NoError(t, p.ctx.Err())
+
+	p.Close()
+	require.ErrorIs(t, p.ctx.Err(), context.Canceled)
+
+	// closing twice is fine
+	p.Close()
+}
+
+func TestNewProvider_UnavailablePairs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// the provider fails to initialize, so it is retried later
+	_, err := NewKrakenProvider(
+		context.Background(),
+		zerolog.Nop(),
+		Endpoint{Name: ProviderKraken, Urls: []string{server.URL}},
+		types.CurrencyPair{Base: "ATOM", Quote: "USD"},
+	)
+	require.Error(t, err)
+}
+
+func TestVolumeSpec_Normalize(t *testing.T) {
+	price := sdk.NewDec(2)
+
+	spec := GetVolumeSpec(ProviderBinance)
+	require.Equal(t, VolumeBase, spec.Unit)
+	require.Equal(t, sdk.NewDec(10), spec.Normalize(price, sdk.NewDec(10)))
+
+	// quote volumes are converted to the base denom
+	require.Equal(t, sdk.NewDec(5), GetVolumeSpec(ProviderCurve).Normalize(price, sdk.NewDec(10)))
+
+	// providers without volume report none, even if they set a placeholder
+	require.True(t, GetVolumeSpec(ProviderPyth).Normalize(price, sdk.NewDec(1)).IsZero())
+
+	// volumes of shorter windows are scaled to 24h
+	hourly := VolumeSpec{Unit: VolumeBase, Window: time.Hour}
+	require.Equal(t, sdk.NewDec(240), hourly.Normalize(price, sdk.NewDec(10)))
+}
+
+func TestProvider_SetTickerPriceConfidence(t *testing.T) {
+	p := &provider{}
+	p.Init(
+		context.Background(),
+		Endpoint{Name: ProviderPyth, Urls: []string{"http://localhost"}},
+		zerolog.Nop(),
+		nil,
+		nil,
+		nil,
+	)
+	defer p.Close()
+	pair := types.CurrencyPair{Base: "EUR", Quote: "USD"}
+	p.setPairs([]types.CurrencyPair{pair, {Base: "USD", Quote: "JPY"}}, nil, nil)
+
+	now := time.Now()
+	p.setTickerPriceConfidence("EURUSD", sdk.NewDec(2), sdk.OneDec(), sdk.MustNewDecFromStr("0.01"), now)
+	require.Equal(t, sdk.MustNewDecFromStr("0.01"), p.tickers["EURUSD"].Confidence)
+
+	// the confidence of inverted prices is scaled with the price
+	p.setTickerPriceConfidence("JPYUSD", sdk.NewDec(2), sdk.OneDec(), sdk.MustNewDecFromStr("0.01"), now)
+	require.Equal(t, sdk.NewDecWithPrec(5, 1), p.tickers["USDJPY"].Price)
+	require.Equal(t, sdk.MustNewDecFromStr("0.0025"), p.tickers["USDJPY"].Confidence)
+
+	p.setTickerPrice("EURUSD", sdk.NewDec(2), sdk.OneDec(), now)
+	require.True(t, p.tickers["EURUSD"].Confidence.IsNil())
+}
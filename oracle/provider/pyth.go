@@ -36,6 +36,7 @@ type (
 
 	PythPrice struct {
 		Price    string `json:"price"`
+		Conf     string `json:"conf"`
 		Exponent int64  `json:"expo"`
 		Time     int64  `json:"publish_time"`
 	}
@@ -57,10 +58,14 @@ func NewPythProvider(
 		nil,
 	)
 
-	availablePairs, _ := provider.GetAvailablePairs()
+	availablePairs, err := provider.GetAvailablePairs()
+	if err != nil {
+		provider.Close()
+		return nil, err
+	}
 	provider.setPairs(pairs, availablePairs, currencyPairToPythSymbol)
 
-	go startPolling(provider, provider.endpoints.PollInterval, logger)
+	go startPolling(provider.ctx, provider, provider.endpoints.PollInterval, logger)
 	return provider, nil
 }
 
@@ -108,15 +113,21 @@ func (p *PythProvider) Poll() error {
 		}
 
 		price := strToDec(ticker.Price.Price).Mul(factor)
+		// the confidence interval shares the exponent of the price
+		confidence := sdk.Dec{}
+		if ticker.Price.Conf != "" {
+			confidence = strToDec(ticker.Price.Conf).Mul(factor)
+		}
 
 		// Use now for testing, as only crypto is available 24/7
 		// timestamp := time.Unix(ticker.Price.Time, 0)
 		timestamp := time.Now()
 
-		p.setTickerPrice(
+		p.setTickerPriceConfidence(
 			ticker.ID,
 			price,
 			sdk.NewDec(1),
+			confidence,
 			timestamp,
 		)
 	}
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// VolumeBase is the unit of volumes reported in the base denom of the
+	// exchange symbol.
+	VolumeBase VolumeUnit = "base"
+	// VolumeQuote is the unit of volumes reported in the quote denom of the
+	// exchange symbol.
+	VolumeQuote VolumeUnit = "quote"
+	// VolumeNone is the unit of providers which report no volume.
+	VolumeNone VolumeUnit = "none"
+
+	// VolumeWindow is the window all ticker volumes are normalized to.
+	VolumeWindow = 24 * time.Hour
+)
+
+type (
+	// VolumeUnit defines the denom a provider reports its volumes in.
+	VolumeUnit string
+
+	// VolumeSpec declares the unit of the volumes reported by a provider
+	// and the window they are traded in.
+	VolumeSpec struct {
+		Unit   VolumeUnit
+		Window time.Duration
+	}
+)
+
+// volumeSpecs lists the providers which don't report the base volume of the
+// last 24h. Osmosis reports USD volumes but converts them itself.
+var volumeSpecs = map[Name]VolumeSpec{
+	ProviderAstroportNeutron:   {Unit: VolumeNone},
+	ProviderAstroportTerra2:    {Unit: VolumeNone},
+	ProviderAstroportInjective: {Unit: VolumeNone},
+	ProviderCurve:              {Unit: VolumeQuote, Window: VolumeWindow},
+	ProviderFinV2:              {Unit: VolumeNone},
+	ProviderOsmosisV2:          {Unit: VolumeNone},
+	ProviderPyth:               {Unit: VolumeNone},
+	ProviderUniswapV3:          {Unit: VolumeNone},
+	ProviderZero:               {Unit: VolumeNone},
+}
+
+// GetVolumeSpec returns the declared volume unit and window of the provider,
+// the base volume of the last 24h by default.
+func GetVolumeSpec(name Name) VolumeSpec {
+	if spec, ok := volumeSpecs[name]; ok {
+		return spec
+	}
+	return VolumeSpec{Unit: VolumeBase, Window: VolumeWindow}
+}
+
+// Normalize converts the volume reported for the price of an exchange symbol
+// to the base volume of the symbol over the volume window. Volumes of
+// providers without volume are zero.
+func (s VolumeSpec) Normalize(price, volume sdk.Dec) sdk.Dec {
+	if s.Unit == VolumeNone || volume.IsNil() {
+		return sdk.ZeroDec()
+	}
+	if s.Unit == VolumeQuote {
+		volume = volume.Quo(price)
+	}
+	if s.Window > 0 && s.Window != VolumeWindow {
+		volume = volume.MulInt64(int64(VolumeWindow)).QuoInt64(int64(s.Window))
+	}
+	return volume
+}
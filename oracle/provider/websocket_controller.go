@@ -32,6 +32,7 @@ type (
 	// that manages reconnecting, subscribing, and receiving messages
 	WebsocketController struct {
 		parentCtx           context.Context
+		parentCancelFunc    context.CancelFunc
 		websocketCtx        context.Context
 		websocketCancelFunc context.CancelFunc
 		providerName        Name
@@ -64,8 +65,10 @@ func NewWebsocketController(
 	pingMessage string,
 	logger zerolog.Logger,
 ) *WebsocketController {
+	ctx, cancel := context.WithCancel(ctx)
 	return &WebsocketController{
 		parentCtx: ctx,
+		parentCancelFunc: cancel,
 		providerName: providerName,
 		websocketURL: websocketURL,
 		pairs: pairs,
@@ -87,6 +90,9 @@ func (wsc *WebsocketController) Start() {
 	defer connectTicker.Stop()
 
 	for {
+		if wsc.parentCtx.Err() != nil {
+			return
+		}
 		if err := wsc.connect(); err != nil {
 			wsc.logger.Err(err).Send()
 			select {
@@ -110,6 +116,12 @@ func (wsc *WebsocketController) Start() {
 	}
 }
 
+// Stop closes the websocket connection and stops reconnecting.
+func (wsc *WebsocketController) Stop() {
+	wsc.parentCancelFunc()
+	wsc.close()
+}
+
 // connect dials the websocket and sets the client to the established connection
 func (wsc *WebsocketController) connect() error {
 	wsc.mtx.Lock()
@@ -228,14 +240,26 @@ func (wsc *WebsocketController) readWebSocket() {
 	reconnectTicker := time.NewTicker(defaultMaxConnectionTime)
 	defer reconnectTicker.Stop()
 
+	// the client is reset once the connection got closed
+	wsc.mtx.Lock()
+	client := wsc.client
+	wsc.mtx.Unlock()
+	if client == nil {
+		return
+	}
+
 	for {
 		select {
 		case <-wsc.websocketCtx.Done():
 			wsc.close()
 			return
 		case <-time.After(defaultReadNewWSMessage):
-			messageType, bz, err := wsc.client.ReadMessage()
+			messageType, bz, err := client.ReadMessage()
 			if err != nil {
+				if wsc.parentCtx.Err() != nil {
+					// the controller got stopped and closed the connection
+					return
+				}
 				wsc.logger.Err(fmt.Errorf(types.ErrWebsocketRead.Error(), wsc.providerName, err)).Send()
 				wsc.reconnect()
 				return
@@ -264,6 +288,10 @@ func (wsc *WebsocketController) close() {
 	wsc.mtx.Lock()
 	defer wsc.mtx.Unlock()
 
+	if wsc.client == nil {
+		return
+	}
+
 	wsc.logger.Debug().Msg("closing websocket")
 	wsc.websocketCancelFunc()
 	if err := wsc.client.Close(); err != nil {
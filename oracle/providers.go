@@ -0,0 +1,191 @@
+package oracle
+
+import (
+	"context"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"golang.org/x/sync/errgroup"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+const (
+	// providerInitConcurrency limits the number of providers initialized
+	// at the same time.
+	providerInitConcurrency = 8
+	providerRetryInterval   = 5 * time.Second
+	maxProviderRetryBackoff = 5 * time.Minute
+)
+
+// initProviders initializes all providers which are neither initialized nor
+// retried yet and returns once each of them was attempted once. Providers
+// which fail to initialize are retried in the background until they succeed,
+// they are removed or the context is done.
+func (o *Oracle) initProviders(ctx context.Context) {
+	type pending struct {
+		name     provider.Name
+		endpoint provider.Endpoint
+		pairs    []types.CurrencyPair
+	}
+
+	providers := []pending{}
+	o.providerMtx.Lock()
+	for name, pairs := range o.providerPairs {
+		if _, ok := o.priceProviders[name]; ok {
+			continue
+		}
+		if _, ok := o.providerRetries[name]; ok {
+			continue
+		}
+		endpoint := o.endpoints[name]
+		endpoint.ContractAddresses = o.contractAddresses[name.String()]
+		o.providerStatus[name] = provider.Status{State: provider.StateInitializing}
+		providers = append(providers, pending{name: name, endpoint: endpoint, pairs: pairs})
+	}
+	o.providerMtx.Unlock()
+
+	g := new(errgroup.Group)
+	g.SetLimit(providerInitConcurrency)
+	for _, p := range providers {
+		p := p
+		g.Go(func() error {
+			if err := o.initProvider(ctx, ctx, p.name, p.endpoint, p.pairs); err != nil && ctx.Err() == nil {
+				retryCtx, cancel := context.WithCancel(ctx)
+				o.providerMtx.Lock()
+				o.providerRetries[p.name] = cancel
+				o.providerMtx.Unlock()
+				go func() {
+					defer cancel()
+					o.retryProvider(ctx, retryCtx, p.name, p.endpoint, p.pairs)
+				}()
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+}
+
+// initProvider creates the provider, which runs until ctx is done, and
+// records the outcome in its status. The provider is discarded if the attempt
+// is cancelled in the meantime.
+func (o *Oracle) initProvider(
+	ctx context.Context,
+	attempt context.Context,
+	name provider.Name,
+	endpoint provider.Endpoint,
+	pairs []types.CurrencyPair,
+) error {
+	priceProvider, err := NewProvider(ctx, name, o.logger, endpoint, pairs...)
+
+	o.providerMtx.Lock()
+	defer o.providerMtx.Unlock()
+
+	if attempt.Err() != nil {
+		// the provider got removed or the oracle stopped in the meantime
+		if err == nil {
+			priceProvider.Close()
+		}
+		return attempt.Err()
+	}
+
+	status := o.providerStatus[name]
+	status.Attempts++
+	if err != nil {
+		status.State = provider.StateFailed
+		status.Error = err.Error()
+		o.providerStatus[name] = status
+
+		o.logger.Warn().
+			Err(err).
+			Str("provider", name.String()).
+			Int("attempts", status.Attempts).
+			Msg("failed to initialize provider")
+		telemetry.IncrCounter(1, "failure", "provider", "type", "init")
+		return err
+	}
+
+	// the retry loop cancels its context once it returns
+	delete(o.providerRetries, name)
+	o.priceProviders[name] = priceProvider
+	o.providerStatus[name] = provider.Status{State: provider.StateReady, Attempts: status.Attempts}
+	return nil
+}
+
+// retryProvider retries to initialize the provider with an exponential
+// backoff until it succeeds or the retry context is done. The provider runs
+// until ctx is done, so it outlives the retry context.
+func (o *Oracle) retryProvider(
+	ctx context.Context,
+	retryCtx context.Context,
+	name provider.Name,
+	endpoint provider.Endpoint,
+	pairs []types.CurrencyPair,
+) {
+	backoff := providerRetryInterval
+	for {
+		select {
+		case <-retryCtx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if err := o.initProvider(ctx, retryCtx, name, endpoint, pairs); err == nil || retryCtx.Err() != nil {
+			return
+		}
+
+		backoff *= 2
+		if backoff > maxProviderRetryBackoff {
+			backoff = maxProviderRetryBackoff
+		}
+	}
+}
+
+// removeProvider stops the provider or its retries and forgets its status.
+// The caller must hold the provider lock.
+func (o *Oracle) removeProvider(name provider.Name) {
+	if cancel, ok := o.providerRetries[name]; ok {
+		cancel()
+		delete(o.providerRetries, name)
+	}
+	if priceProvider, ok := o.priceProviders[name]; ok {
+		priceProvider.Close()
+		delete(o.priceProviders, name)
+	}
+	delete(o.providerStatus, name)
+}
+
+// getProvider returns the provider if it is initialized.
+func (o *Oracle) getProvider(name provider.Name) (provider.Provider, bool) {
+	o.providerMtx.RLock()
+	defer o.providerMtx.RUnlock()
+
+	priceProvider, ok := o.priceProviders[name]
+	return priceProvider, ok
+}
+
+// GetProviderStatus returns the initialization status of all providers.
+func (o *Oracle) GetProviderStatus() map[provider.Name]provider.Status {
+	o.providerMtx.RLock()
+	defer o.providerMtx.RUnlock()
+
+	status := make(map[provider.Name]provider.Status, len(o.providerStatus))
+	for name, s := range o.providerStatus {
+		status[name] = s
+	}
+	return status
+}
+
+// closeProviders stops all price providers.
+func (o *Oracle) closeProviders() {
+	o.providerMtx.Lock()
+	defer o.providerMtx.Unlock()
+
+	for name := range o.priceProviders {
+		o.removeProvider(name)
+	}
+	for name := range o.providerRetries {
+		o.removeProvider(name)
+	}
+}
@@ -0,0 +1,96 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	_ Publisher = (*FilePublisher)(nil)
+	_ Publisher = (*StdoutPublisher)(nil)
+)
+
+type (
+	// Record defines a single line written by the file and stdout publishers.
+	Record struct {
+		Time    time.Time       `json:"time"`
+		Message json.RawMessage `json:"message"`
+	}
+
+	// writer appends one JSON encoded Record per line to out.
+	writer struct {
+		logger zerolog.Logger
+		mtx    sync.Mutex
+		out    io.Writer
+	}
+
+	// FilePublisher appends messages to a local JSONL file.
+	FilePublisher struct {
+		writer
+		file *os.File
+	}
+
+	// StdoutPublisher writes messages as JSONL to stdout.
+	StdoutPublisher struct {
+		writer
+	}
+)
+
+func NewFilePublisher(logger zerolog.Logger, path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		logger.Error().Err(err).Str("path", path).Msg("failed to open publisher file")
+		return nil, err
+	}
+	return &FilePublisher{
+		writer: writer{logger: logger, out: file},
+		file:   file,
+	}, nil
+}
+
+func (p *FilePublisher) Close() error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.file.Close()
+}
+
+func NewStdoutPublisher(logger zerolog.Logger) *StdoutPublisher {
+	return &StdoutPublisher{
+		writer: writer{logger: logger, out: os.Stdout},
+	}
+}
+
+func (p *StdoutPublisher) Close() error {
+	return nil
+}
+
+func (w *writer) Publish(ctx context.Context, content []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(Record{
+		Time:    time.Now().UTC(),
+		Message: content,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if _, err := w.out.Write(append(line, '\n')); err != nil {
+		w.logger.Error().Err(err).Msg("failed to write message")
+		return err
+	}
+
+	w.logger.Debug().Int("size", len(content)).Msg("published message")
+	return nil
+}
@@ -0,0 +1,123 @@
+package publisher
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"price-feeder/oracle/client"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+)
+
+// txValidDuration is how long the network accepts a transaction id after
+// its valid start, the default of the SDK.
+const txValidDuration = 120 * time.Second
+
+var _ Publisher = (*HCSPublisher)(nil)
+
+type (
+	// topicClient submits messages to a HCS topic, implemented by
+	// client.OracleClient.
+	topicClient interface {
+		NewTransactionID() hedera.TransactionID
+		PutTx(content []byte, txID hedera.TransactionID) (hedera.TransactionID, error)
+	}
+
+	// HCSPublisher submits messages to the Hedera Consensus Service topic
+	// configured on the oracle client. Submitted transactions are handed to
+	// the tracker, if any, which also reports the fees charged for them. A
+	// message that failed with a retryable error is submitted again under
+	// the same transaction id while it is valid, so the network rejects it
+	// as duplicate if the failed submission, e.g. one that timed out,
+	// reached it after all.
+	HCSPublisher struct {
+		logger  zerolog.Logger
+		client  topicClient
+		tracker Tracker
+
+		mtx     sync.Mutex
+		pending map[[sha256.Size]byte]hedera.TransactionID
+	}
+)
+
+func NewHCSPublisher(
+	logger zerolog.Logger,
+	oracleClient *client.OracleClient,
+	tracker Tracker,
+) *HCSPublisher {
+	return newHCSPublisher(logger, oracleClient, tracker)
+}
+
+func newHCSPublisher(logger zerolog.Logger, topicClient topicClient, tracker Tracker) *HCSPublisher {
+	return &HCSPublisher{
+		logger:  logger,
+		client:  topicClient,
+		tracker: tracker,
+		pending: map[[sha256.Size]byte]hedera.TransactionID{},
+	}
+}
+
+func (p *HCSPublisher) Publish(ctx context.Context, content []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := sha256.Sum256(content)
+	txID := p.transactionID(key)
+
+	_, err := p.client.PutTx(content, txID)
+	switch {
+	case err == nil:
+	case client.IsDuplicate(err):
+		// the previous attempt was received by the network
+	case client.IsExpired(err):
+		// the next attempt has to use a new transaction id
+		p.forget(key)
+		return err
+	case !client.IsRetryable(err):
+		p.forget(key)
+		return Fatal(err)
+	default:
+		return err
+	}
+
+	p.forget(key)
+	if p.tracker != nil {
+		p.tracker.Track(txID)
+	}
+	return nil
+}
+
+func (p *HCSPublisher) Close() error {
+	return nil
+}
+
+// transactionID returns the id a message was submitted under before, if it
+// is still valid, or a new one. Ids of messages which are no longer retried
+// expire along the way.
+func (p *HCSPublisher) transactionID(key [sha256.Size]byte) hedera.TransactionID {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for k, txID := range p.pending {
+		if txID.ValidStart != nil && time.Since(*txID.ValidStart) > txValidDuration {
+			delete(p.pending, k)
+		}
+	}
+
+	txID, ok := p.pending[key]
+	if !ok {
+		txID = p.client.NewTransactionID()
+		p.pending[key] = txID
+	}
+	return txID
+}
+
+func (p *HCSPublisher) forget(key [sha256.Size]byte) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	delete(p.pending, key)
+}
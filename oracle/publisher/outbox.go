@@ -0,0 +1,312 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"price-feeder/oracle/history"
+
+	"github.com/armon/go-metrics"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultRetryBackoff    = 250 * time.Millisecond
+	defaultMaxRetryBackoff = 4 * time.Second
+)
+
+var _ Publisher = (*Outbox)(nil)
+
+type (
+	// FatalError marks a publishing error that must not be retried, e.g. an
+	// invalid topic or an operator account without funds.
+	FatalError struct {
+		Err error
+	}
+
+	// Outbox wraps a Publisher and persists every message before it is
+	// handed over. Publish attempts the delivery once, retryable failures are
+	// retried with exponential backoff by Start in the background until the
+	// message expires, so publishing doesn't block on retries. Messages left
+	// over from a previous run are replayed or dropped by Replay. Messages
+	// are stored per target, so the outboxes of several targets can share
+	// the history database.
+	Outbox struct {
+		logger     zerolog.Logger
+		target     string
+		publisher  Publisher
+		history    *history.PriceHistory
+		ttl        time.Duration
+		backoff    time.Duration
+		maxBackoff time.Duration
+
+		mtx     sync.Mutex
+		retries map[int64]retry
+	}
+
+	// retry is a message waiting for its next delivery attempt.
+	retry struct {
+		message history.OutboxMessage
+		at      time.Time
+		backoff time.Duration
+	}
+
+	expiryKey struct{}
+)
+
+func (e FatalError) Error() string {
+	return e.Err.Error()
+}
+
+func (e FatalError) Unwrap() error {
+	return e.Err
+}
+
+// Fatal wraps err so that it will not be retried by the outbox.
+func Fatal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return FatalError{Err: err}
+}
+
+// WithExpiry returns a context carrying the time a published message expires
+// at. It takes precedence over the deadline of the context, which then only
+// bounds the first delivery attempt, e.g. during a shutdown.
+func WithExpiry(ctx context.Context, expires time.Time) context.Context {
+	return context.WithValue(ctx, expiryKey{}, expires)
+}
+
+// IsRetryable reports whether a failed publish may be attempted again.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var fatal FatalError
+	return !errors.As(err, &fatal)
+}
+
+// NewOutbox returns an Outbox delivering messages through publisher. The ttl
+// bounds how long a message without deadline is retried and should not
+// exceed the vote period, as a prevote or vote is worthless after its voting
+// window.
+func NewOutbox(
+	logger zerolog.Logger,
+	target string,
+	publisher Publisher,
+	history *history.PriceHistory,
+	ttl time.Duration,
+) *Outbox {
+	return &Outbox{
+		logger:     logger.With().Str("module", "outbox").Str("target", target).Logger(),
+		target:     target,
+		publisher:  publisher,
+		history:    history,
+		ttl:        ttl,
+		backoff:    defaultRetryBackoff,
+		maxBackoff: defaultMaxRetryBackoff,
+		retries:    map[int64]retry{},
+	}
+}
+
+// Publish stores the message in the outbox and attempts to deliver it. The
+// message expires at the expiry set by WithExpiry or else the deadline of
+// the context, i.e. the end of the voting window it belongs to, or after the
+// ttl if the context has neither. If the attempt fails with a retryable
+// error, the message is retried in the background and no error is returned.
+func (o *Outbox) Publish(ctx context.Context, content []byte) error {
+	expires, ok := ctx.Value(expiryKey{}).(time.Time)
+	if !ok {
+		expires, ok = ctx.Deadline()
+	}
+	if !ok {
+		expires = time.Now().Add(o.ttl)
+	}
+	id, err := o.history.AddOutboxMessage(o.target, content, expires)
+	if err != nil {
+		return err
+	}
+	o.telemetry()
+
+	message := history.OutboxMessage{
+		ID:      id,
+		Target:  o.target,
+		Content: content,
+		Expires: expires,
+	}
+	if err := o.deliver(ctx, message); !IsRetryable(err) {
+		return err
+	}
+	o.schedule(message, o.backoff)
+	return nil
+}
+
+// Replay schedules all messages left in the outbox, e.g. after a crash, for
+// delivery once the outbox is started and drops those that expired in the
+// meantime.
+func (o *Outbox) Replay() error {
+	messages, err := o.history.GetOutboxMessages(o.target)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if time.Now().After(message.Expires) {
+			o.expire(message)
+			continue
+		}
+
+		o.logger.Info().
+			Int64("id", message.ID).
+			Int64("attempts", message.Attempts).
+			Msg("replaying outbox message")
+
+		o.mtx.Lock()
+		o.retries[message.ID] = retry{message: message, at: time.Now(), backoff: o.backoff}
+		o.mtx.Unlock()
+	}
+
+	return nil
+}
+
+// Start retries the messages whose delivery failed until the context is
+// cancelled. Messages still pending by then stay in the outbox and are
+// replayed by the next run.
+func (o *Outbox) Start(ctx context.Context) error {
+	ticker := time.NewTicker(o.backoff)
+	defer ticker.Stop()
+
+	for {
+		o.retryDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *Outbox) Close() error {
+	return o.publisher.Close()
+}
+
+// deliver attempts to publish the message once. The message is removed
+// from the outbox unless the error is retryable.
+func (o *Outbox) deliver(ctx context.Context, message history.OutboxMessage) error {
+	err := o.publisher.Publish(ctx, message.Content)
+	if err == nil {
+		o.remove(message.ID)
+		return nil
+	}
+
+	_ = o.history.IncrOutboxAttempts(message.ID)
+
+	if !IsRetryable(err) {
+		telemetry.IncrCounter(1, "outbox", "failure", "fatal")
+		o.logger.Error().Err(err).Int64("id", message.ID).Msg("dropping message after fatal error")
+		o.remove(message.ID)
+		return err
+	}
+
+	telemetry.IncrCounter(1, "outbox", "retry")
+	o.logger.Warn().Err(err).Int64("id", message.ID).Msg("failed to deliver message")
+	return err
+}
+
+// schedule retries the message after the backoff, unless it expires before.
+func (o *Outbox) schedule(message history.OutboxMessage, backoff time.Duration) {
+	if time.Now().Add(backoff).After(message.Expires) {
+		o.expire(message)
+		return
+	}
+
+	o.logger.Debug().
+		Int64("id", message.ID).
+		Dur("backoff", backoff).
+		Msg("retrying message")
+
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.retries[message.ID] = retry{message: message, at: time.Now().Add(backoff), backoff: backoff}
+}
+
+// retryDue delivers the messages whose backoff elapsed in the order they
+// were published, and schedules them again with a doubled backoff if the
+// delivery failed again.
+func (o *Outbox) retryDue(ctx context.Context) {
+	now := time.Now()
+
+	o.mtx.Lock()
+	due := []retry{}
+	for id, r := range o.retries {
+		if !now.Before(r.at) {
+			due = append(due, r)
+			delete(o.retries, id)
+		}
+	}
+	o.mtx.Unlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].message.ID < due[j].message.ID
+	})
+
+	for _, r := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if now.After(r.message.Expires) {
+			o.expire(r.message)
+			continue
+		}
+
+		msgCtx, cancel := context.WithDeadline(ctx, r.message.Expires)
+		err := o.deliver(msgCtx, r.message)
+		cancel()
+		if !IsRetryable(err) {
+			continue
+		}
+
+		backoff := r.backoff * 2
+		if backoff > o.maxBackoff {
+			backoff = o.maxBackoff
+		}
+		o.schedule(r.message, backoff)
+	}
+}
+
+func (o *Outbox) expire(message history.OutboxMessage) {
+	telemetry.IncrCounter(1, "outbox", "expired")
+	o.logger.Warn().
+		Int64("id", message.ID).
+		Time("expires", message.Expires).
+		Msg("outbox message expired")
+	o.remove(message.ID)
+}
+
+func (o *Outbox) remove(id int64) {
+	_ = o.history.DeleteOutboxMessage(id)
+	o.telemetry()
+}
+
+// telemetry exports the number of pending messages and the age of the
+// oldest one.
+func (o *Outbox) telemetry() {
+	depth, oldest, err := o.history.GetOutboxStats(o.target)
+	if err != nil {
+		return
+	}
+
+	age := float32(0)
+	if !oldest.IsZero() {
+		age = float32(time.Since(oldest).Seconds())
+	}
+
+	labels := []metrics.Label{telemetry.NewLabel("target", o.target)}
+	telemetry.SetGaugeWithLabels([]string{"outbox", "depth"}, float32(depth), labels)
+	telemetry.SetGaugeWithLabels([]string{"outbox", "age"}, age, labels)
+}
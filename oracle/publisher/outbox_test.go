@@ -0,0 +1,160 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"price-feeder/oracle/history"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+const testTarget = "0.0.1234"
+
+type flakyPublisher struct {
+	mtx       sync.Mutex
+	failures  int
+	err       error
+	published [][]byte
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, content []byte) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.failures > 0 {
+		p.failures--
+		return p.err
+	}
+	p.published = append(p.published, content)
+	return nil
+}
+
+func (p *flakyPublisher) Published() [][]byte {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.published
+}
+
+func (p *flakyPublisher) Close() error {
+	return nil
+}
+
+func newTestOutbox(t *testing.T, p Publisher, ttl time.Duration) (*Outbox, *history.PriceHistory) {
+	h, err := history.NewPriceHistory(":memory:", zerolog.Nop())
+	require.NoError(t, err)
+
+	outbox := NewOutbox(zerolog.Nop(), testTarget, p, &h, ttl)
+	outbox.backoff = time.Millisecond
+	outbox.maxBackoff = 4 * time.Millisecond
+	return outbox, &h
+}
+
+// startOutbox runs the retries of the outbox until the test ends.
+func startOutbox(t *testing.T, outbox *Outbox) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = outbox.Start(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func requireOutboxEmpty(t *testing.T, h *history.PriceHistory) {
+	require.Eventually(t, func() bool {
+		depth, _, err := h.GetOutboxStats(testTarget)
+		return err == nil && depth == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestOutbox_Retry(t *testing.T) {
+	p := &flakyPublisher{failures: 3, err: fmt.Errorf("busy")}
+	outbox, h := newTestOutbox(t, p, time.Second)
+
+	// the failed delivery doesn't block the publish
+	require.NoError(t, outbox.Publish(context.Background(), testMessage))
+	require.Empty(t, p.Published())
+
+	startOutbox(t, outbox)
+	requireOutboxEmpty(t, h)
+	require.Equal(t, [][]byte{testMessage}, p.Published())
+}
+
+func TestOutbox_Fatal(t *testing.T) {
+	p := &flakyPublisher{failures: 3, err: Fatal(fmt.Errorf("invalid topic"))}
+	outbox, h := newTestOutbox(t, p, time.Second)
+
+	err := outbox.Publish(context.Background(), testMessage)
+	require.Error(t, err)
+	require.False(t, IsRetryable(err))
+	require.Equal(t, 2, p.failures)
+
+	depth, _, err := h.GetOutboxStats(testTarget)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), depth)
+}
+
+func TestOutbox_Expire(t *testing.T) {
+	p := &flakyPublisher{failures: 1000, err: fmt.Errorf("busy")}
+	outbox, h := newTestOutbox(t, p, 20*time.Millisecond)
+	startOutbox(t, outbox)
+
+	require.NoError(t, outbox.Publish(context.Background(), testMessage))
+	requireOutboxEmpty(t, h)
+	require.Empty(t, p.Published())
+}
+
+func TestOutbox_Deadline(t *testing.T) {
+	p := &flakyPublisher{failures: 1, err: fmt.Errorf("busy")}
+	outbox, h := newTestOutbox(t, p, time.Second)
+
+	// the deadline of the context overrides the ttl
+	deadline := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	require.NoError(t, outbox.Publish(ctx, testMessage))
+
+	messages, err := h.GetOutboxMessages(testTarget)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.WithinDuration(t, deadline, messages[0].Expires, time.Second)
+}
+
+func TestOutbox_Expiry(t *testing.T) {
+	p := &flakyPublisher{failures: 1, err: fmt.Errorf("busy")}
+	outbox, h := newTestOutbox(t, p, time.Second)
+
+	// the expiry outlives a shorter deadline bounding the attempt
+	expires := time.Now().Add(time.Hour)
+	ctx, cancel := context.WithTimeout(WithExpiry(context.Background(), expires), time.Minute)
+	defer cancel()
+	require.NoError(t, outbox.Publish(ctx, testMessage))
+
+	messages, err := h.GetOutboxMessages(testTarget)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.WithinDuration(t, expires, messages[0].Expires, time.Second)
+}
+
+func TestOutbox_Replay(t *testing.T) {
+	p := &flakyPublisher{}
+	outbox, h := newTestOutbox(t, p, time.Second)
+
+	_, err := h.AddOutboxMessage(testTarget, []byte(`{"pending":true}`), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	_, err = h.AddOutboxMessage(testTarget, []byte(`{"expired":true}`), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	require.NoError(t, outbox.Replay())
+	startOutbox(t, outbox)
+	requireOutboxEmpty(t, h)
+	require.Equal(t, [][]byte{[]byte(`{"pending":true}`)}, p.Published())
+}
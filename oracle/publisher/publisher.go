@@ -0,0 +1,85 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"price-feeder/oracle/client"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+)
+
+const (
+	PublisherHCS     = "hcs"
+	PublisherFile    = "file"
+	PublisherStdout  = "stdout"
+	PublisherWebhook = "webhook"
+
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+type (
+	// Publisher defines an interface a vote publishing backend must implement.
+	// The oracle hands over the already encoded prevote and vote messages, the
+	// publisher is only responsible for delivering them.
+	Publisher interface {
+		// Publish delivers a single encoded message. The context bounds the
+		// delivery, publishers that can't be interrupted check it upfront.
+		Publish(context.Context, []byte) error
+		// Close releases any resources held by the publisher.
+		Close() error
+	}
+
+	// Tracker is notified of every message submitted to the HCS topic, to
+	// follow it until it reaches consensus.
+	Tracker interface {
+		Track(txID hedera.TransactionID)
+	}
+
+	// Config defines the settings used to construct a Publisher.
+	Config struct {
+		Type    string
+		Path    string
+		URL     string
+		Headers map[string]string
+		Timeout time.Duration
+	}
+)
+
+// NewPublisher returns the Publisher selected by cfg.Type. The oracle client
+// and tracker are only used by the HCS publisher, the oracle client may be
+// nil for all other types and the tracker is always optional.
+func NewPublisher(
+	logger zerolog.Logger,
+	cfg Config,
+	oracleClient *client.OracleClient,
+	tracker Tracker,
+) (Publisher, error) {
+	publisherLogger := logger.With().Str("publisher", cfg.Type).Logger()
+	switch cfg.Type {
+	case PublisherHCS:
+		if oracleClient == nil {
+			return nil, fmt.Errorf("hcs publisher requires an oracle client")
+		}
+		return NewHCSPublisher(publisherLogger, oracleClient, tracker), nil
+	case PublisherFile:
+		return NewFilePublisher(publisherLogger, cfg.Path)
+	case PublisherStdout:
+		return NewStdoutPublisher(publisherLogger), nil
+	case PublisherWebhook:
+		timeout := cfg.Timeout
+		if timeout == 0 {
+			timeout = defaultWebhookTimeout
+		}
+		return NewWebhookPublisher(
+			publisherLogger,
+			cfg.URL,
+			cfg.Headers,
+			&http.Client{Timeout: timeout},
+		)
+	}
+	return nil, fmt.Errorf("unsupported publisher: %s", cfg.Type)
+}
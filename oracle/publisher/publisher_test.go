@@ -0,0 +1,165 @@
+package publisher
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = []byte(`{"hash":"abc","feeder":"0.0.1234"}`)
+
+func TestNewPublisher(t *testing.T) {
+	_, err := NewPublisher(zerolog.Nop(), Config{Type: "foo"}, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewPublisher(zerolog.Nop(), Config{Type: PublisherHCS}, nil, nil)
+	require.Error(t, err)
+
+	_, err = NewPublisher(zerolog.Nop(), Config{Type: PublisherWebhook}, nil, nil)
+	require.Error(t, err)
+
+	p, err := NewPublisher(zerolog.Nop(), Config{Type: PublisherStdout}, nil, nil)
+	require.NoError(t, err)
+	require.IsType(t, &StdoutPublisher{}, p)
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "votes.jsonl")
+
+	p, err := NewFilePublisher(zerolog.Nop(), path)
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(context.Background(), testMessage))
+	require.NoError(t, p.Publish(context.Background(), testMessage))
+	require.NoError(t, p.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		require.JSONEq(t, string(testMessage), string(record.Message))
+		require.False(t, record.Time.IsZero())
+		lines++
+	}
+	require.Equal(t, 2, lines)
+}
+
+func TestStdoutPublisher(t *testing.T) {
+	buf := new(bytes.Buffer)
+	p := NewStdoutPublisher(zerolog.Nop())
+	p.out = buf
+
+	require.NoError(t, p.Publish(context.Background(), testMessage))
+
+	var record Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.JSONEq(t, string(testMessage), string(record.Message))
+}
+
+func TestWebhookPublisher(t *testing.T) {
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "secret", r.Header.Get("Authorization"))
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	p, err := NewPublisher(zerolog.Nop(), Config{
+		Type:    PublisherWebhook,
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "secret"},
+	}, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(context.Background(), testMessage))
+	require.Equal(t, testMessage, received)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	p, err = NewPublisher(zerolog.Nop(), Config{Type: PublisherWebhook, URL: failing.URL}, nil, nil)
+	require.NoError(t, err)
+	require.Error(t, p.Publish(context.Background(), testMessage))
+}
+
+type fakeTopicClient struct {
+	account hedera.AccountID
+	errs    []error
+	txIDs   []hedera.TransactionID
+}
+
+func (c *fakeTopicClient) NewTransactionID() hedera.TransactionID {
+	return hedera.TransactionIDGenerate(c.account)
+}
+
+func (c *fakeTopicClient) PutTx(_ []byte, txID hedera.TransactionID) (hedera.TransactionID, error) {
+	c.txIDs = append(c.txIDs, txID)
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return hedera.TransactionID{}, err
+	}
+	return txID, nil
+}
+
+type recordingTracker struct {
+	txIDs []hedera.TransactionID
+}
+
+func (t *recordingTracker) Track(txID hedera.TransactionID) {
+	t.txIDs = append(t.txIDs, txID)
+}
+
+func TestHCSPublisher_Duplicate(t *testing.T) {
+	c := &fakeTopicClient{
+		account: hedera.AccountID{Account: 1234},
+		errs: []error{
+			hedera.ErrHederaPreCheckStatus{Status: hedera.StatusBusy},
+			hedera.ErrHederaPreCheckStatus{Status: hedera.StatusDuplicateTransaction},
+		},
+	}
+	tracker := &recordingTracker{}
+	p := newHCSPublisher(zerolog.Nop(), c, tracker)
+
+	// the retry reuses the transaction id, the duplicate is delivered
+	require.Error(t, p.Publish(context.Background(), testMessage))
+	require.NoError(t, p.Publish(context.Background(), testMessage))
+	require.Len(t, c.txIDs, 2)
+	require.Equal(t, c.txIDs[0].String(), c.txIDs[1].String())
+	require.Equal(t, []hedera.TransactionID{c.txIDs[0]}, tracker.txIDs)
+
+	// delivered messages are submitted under a new transaction id
+	require.NoError(t, p.Publish(context.Background(), testMessage))
+	require.Len(t, c.txIDs, 3)
+	require.NotEqual(t, c.txIDs[0].String(), c.txIDs[2].String())
+}
+
+func TestHCSPublisher_Fatal(t *testing.T) {
+	c := &fakeTopicClient{
+		account: hedera.AccountID{Account: 1234},
+		errs:    []error{hedera.ErrHederaPreCheckStatus{Status: hedera.StatusInvalidTopicID}},
+	}
+	p := newHCSPublisher(zerolog.Nop(), c, nil)
+
+	err := p.Publish(context.Background(), testMessage)
+	require.Error(t, err)
+	require.False(t, IsRetryable(err))
+}
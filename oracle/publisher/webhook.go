@@ -0,0 +1,82 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rs/zerolog"
+)
+
+var _ Publisher = (*WebhookPublisher)(nil)
+
+type (
+	// WebhookPublisher POSTs every message as JSON body to a HTTP endpoint.
+	WebhookPublisher struct {
+		logger  zerolog.Logger
+		url     string
+		headers map[string]string
+		http    *http.Client
+	}
+)
+
+func NewWebhookPublisher(
+	logger zerolog.Logger,
+	url string,
+	headers map[string]string,
+	client *http.Client,
+) (*WebhookPublisher, error) {
+	if url == "" {
+		return nil, fmt.Errorf("webhook publisher requires an url")
+	}
+	return &WebhookPublisher{
+		logger:  logger,
+		url:     url,
+		headers: headers,
+		http:    client,
+	}, nil
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, content []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range p.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := p.http.Do(req)
+	if err != nil {
+		p.logger.Warn().Err(err).Str("url", p.url).Msg("webhook request failed")
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		p.logger.Warn().
+			Int("code", res.StatusCode).
+			Str("url", p.url).
+			Msg("webhook returned invalid status")
+		err := fmt.Errorf("webhook returned invalid status: %d", res.StatusCode)
+		// client errors other than timeouts and rate limits will not go
+		// away by sending the same message again
+		if res.StatusCode >= 400 && res.StatusCode < 500 &&
+			res.StatusCode != http.StatusRequestTimeout &&
+			res.StatusCode != http.StatusTooManyRequests {
+			return Fatal(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (p *WebhookPublisher) Close() error {
+	return nil
+}
@@ -0,0 +1,123 @@
+package push
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// ModeCommitReveal submits a prevote with the hash of the prices and
+	// reveals them with a vote in the following round.
+	ModeCommitReveal = "commit_reveal"
+	// ModePush publishes the prices once per round.
+	ModePush = "push"
+	// ModePushOnDeviation publishes the prices whenever a price deviates
+	// from the last published one or the heartbeat expires.
+	ModePushOnDeviation = "push_on_deviation"
+)
+
+// SupportedModes defines the modes prices can be published in.
+var SupportedModes = map[string]struct{}{
+	ModeCommitReveal:    {},
+	ModePush:            {},
+	ModePushOnDeviation: {},
+}
+
+var hundred = sdk.NewDec(100)
+
+type (
+	// Config defines when prices are pushed in push_on_deviation mode.
+	// Thresholds are percentages, Thresholds overrides the default
+	// Threshold per denom. Prices quoted in other denoms than USD, e.g.
+	// ATOM/HBAR, use the threshold of their base denom unless they have one
+	// of their own. A zero Heartbeat disables the heartbeat.
+	Config struct {
+		Heartbeat  time.Duration
+		Threshold  sdk.Dec
+		Thresholds map[string]sdk.Dec
+	}
+
+	// Trigger keeps the last pushed prices and decides whether the current
+	// prices have to be pushed.
+	Trigger struct {
+		cfg Config
+
+		mtx    sync.Mutex
+		prices map[string]sdk.Dec
+		pushed time.Time
+	}
+)
+
+// NewTrigger returns a new Trigger, which is due until the first push.
+func NewTrigger(cfg Config) *Trigger {
+	return &Trigger{
+		cfg: cfg,
+	}
+}
+
+// Due reports whether the given prices have to be pushed and why. Prices are
+// due if the heartbeat expired, a denom was not pushed before or its price
+// moved beyond its threshold since the last push.
+func (t *Trigger) Due(prices sdk.DecCoins, now time.Time) (bool, string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.prices == nil {
+		return true, "first push"
+	}
+	if t.cfg.Heartbeat > 0 && now.Sub(t.pushed) >= t.cfg.Heartbeat {
+		return true, "heartbeat"
+	}
+
+	for _, price := range prices {
+		last, ok := t.prices[price.Denom]
+		if !ok {
+			return true, fmt.Sprintf("new denom %s", price.Denom)
+		}
+		deviation := Deviation(last, price.Amount)
+		if deviation.GT(t.threshold(price.Denom)) {
+			return true, fmt.Sprintf("%s deviated by %s%%", price.Denom, deviation.String())
+		}
+	}
+	return false, ""
+}
+
+// Pushed records the prices of a successful push.
+func (t *Trigger) Pushed(prices sdk.DecCoins, now time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.prices = make(map[string]sdk.Dec, len(prices))
+	for _, price := range prices {
+		t.prices[price.Denom] = price.Amount
+	}
+	t.pushed = now
+}
+
+func (t *Trigger) threshold(denom string) sdk.Dec {
+	if threshold, ok := t.cfg.Thresholds[denom]; ok {
+		return threshold
+	}
+	if base, _, quoted := strings.Cut(denom, "/"); quoted {
+		if threshold, ok := t.cfg.Thresholds[base]; ok {
+			return threshold
+		}
+	}
+	return t.cfg.Threshold
+}
+
+// Deviation returns the absolute change from last to current in percent.
+// Any change of a zero price is a deviation of 100%.
+func Deviation(last, current sdk.Dec) sdk.Dec {
+	if last.IsZero() {
+		if current.IsZero() {
+			return sdk.ZeroDec()
+		}
+		return hundred
+	}
+	return current.Sub(last).Abs().Quo(last.Abs()).Mul(hundred)
+}
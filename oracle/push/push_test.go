@@ -0,0 +1,81 @@
+package push
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrigger(t *testing.T) {
+	trigger := NewTrigger(Config{
+		Heartbeat: time.Hour,
+		Threshold: sdk.MustNewDecFromStr("1"),
+		Thresholds: map[string]sdk.Dec{
+			"BTC": sdk.MustNewDecFromStr("0.1"),
+		},
+	})
+	now := time.Now()
+	prices := sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("ATOM", sdk.MustNewDecFromStr("10")),
+		sdk.NewDecCoinFromDec("BTC", sdk.MustNewDecFromStr("30000")),
+	)
+
+	due, reason := trigger.Due(prices, now)
+	require.True(t, due)
+	require.Equal(t, "first push", reason)
+	trigger.Pushed(prices, now)
+
+	due, _ = trigger.Due(prices, now.Add(time.Minute))
+	require.False(t, due)
+
+	// 0.5% is within the default threshold
+	atom := sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("ATOM", sdk.MustNewDecFromStr("10.05")),
+		sdk.NewDecCoinFromDec("BTC", sdk.MustNewDecFromStr("30000")),
+	)
+	due, _ = trigger.Due(atom, now.Add(time.Minute))
+	require.False(t, due)
+
+	// 0.2% exceeds the threshold of BTC
+	btc := sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("ATOM", sdk.MustNewDecFromStr("10")),
+		sdk.NewDecCoinFromDec("BTC", sdk.MustNewDecFromStr("29940")),
+	)
+	due, reason = trigger.Due(btc, now.Add(time.Minute))
+	require.True(t, due)
+	require.Contains(t, reason, "BTC")
+
+	due, reason = trigger.Due(prices, now.Add(time.Hour))
+	require.True(t, due)
+	require.Equal(t, "heartbeat", reason)
+
+	added := prices.Add(sdk.NewDecCoinFromDec("ETH", sdk.MustNewDecFromStr("2000")))
+	due, reason = trigger.Due(added, now.Add(time.Minute))
+	require.True(t, due)
+	require.Equal(t, "new denom ETH", reason)
+}
+
+func TestTrigger_quoted(t *testing.T) {
+	trigger := NewTrigger(Config{
+		Threshold: sdk.MustNewDecFromStr("1"),
+		Thresholds: map[string]sdk.Dec{
+			"BTC":      sdk.MustNewDecFromStr("0.1"),
+			"ETH/HBAR": sdk.MustNewDecFromStr("5"),
+			"ETH":      sdk.MustNewDecFromStr("0.1"),
+		},
+	})
+
+	// quoted prices use the threshold of their base denom
+	require.Equal(t, sdk.MustNewDecFromStr("0.1"), trigger.threshold("BTC/HBAR"))
+	require.Equal(t, sdk.MustNewDecFromStr("5"), trigger.threshold("ETH/HBAR"))
+	require.Equal(t, sdk.MustNewDecFromStr("1"), trigger.threshold("ATOM/HBAR"))
+}
+
+func TestDeviation(t *testing.T) {
+	require.Equal(t, sdk.MustNewDecFromStr("10"), Deviation(sdk.NewDec(100), sdk.NewDec(90)))
+	require.Equal(t, sdk.MustNewDecFromStr("10"), Deviation(sdk.NewDec(100), sdk.NewDec(110)))
+	require.Equal(t, sdk.MustNewDecFromStr("100"), Deviation(sdk.ZeroDec(), sdk.NewDec(1)))
+	require.True(t, Deviation(sdk.ZeroDec(), sdk.ZeroDec()).IsZero())
+}
@@ -0,0 +1,106 @@
+package oracle
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/config"
+)
+
+// quotePrices returns the USD prices quoted in the given denom, i.e. divided
+// by the USD price of the quote. USD prices keep their denoms, the prices of
+// other quotes are named <denom>/<quote>, e.g. ATOM/HBAR. The price of the
+// quote itself is left out.
+func quotePrices(prices sdk.DecCoins, quote string) (sdk.DecCoins, error) {
+	if quote == config.DenomUSD {
+		return prices, nil
+	}
+
+	quotePrice := prices.AmountOf(quote)
+	if !quotePrice.IsPositive() {
+		return nil, fmt.Errorf("no price for quote: %s", quote)
+	}
+
+	quoted := sdk.DecCoins{}
+	for _, price := range prices {
+		if price.Denom == quote {
+			continue
+		}
+		quoted = append(quoted, sdk.NewDecCoinFromDec(
+			price.Denom+"/"+quote,
+			price.Amount.Quo(quotePrice),
+		))
+	}
+	return quoted.Sort(), nil
+}
+
+// quoteConfidences returns the confidence of the USD prices quoted in the
+// given denom like quotePrices. The confidence of the quote price adds to the
+// confidence of every quoted price in proportion to it.
+func quoteConfidences(prices, confidences sdk.DecCoins, quote string) (sdk.DecCoins, error) {
+	if quote == config.DenomUSD {
+		return confidences, nil
+	}
+
+	quotePrice := prices.AmountOf(quote)
+	if !quotePrice.IsPositive() {
+		return nil, fmt.Errorf("no price for quote: %s", quote)
+	}
+	quoteConfidence := confidences.AmountOf(quote).Quo(quotePrice)
+
+	quoted := sdk.DecCoins{}
+	for _, confidence := range confidences {
+		if confidence.Denom == quote {
+			continue
+		}
+		price := prices.AmountOf(confidence.Denom)
+		quoted = append(quoted, sdk.NewDecCoinFromDec(
+			confidence.Denom+"/"+quote,
+			confidence.Amount.Add(price.Mul(quoteConfidence)).Quo(quotePrice),
+		))
+	}
+	return quoted.Sort(), nil
+}
+
+// GetQuotedPrices returns the current prices and their confidence quoted in
+// the given denom.
+func (o *Oracle) GetQuotedPrices(quote string) (sdk.DecCoins, sdk.DecCoins, error) {
+	prices, confidences := o.getPricesAndConfidences()
+	return quotePricesAndConfidences(prices, confidences, quote)
+}
+
+// quotePricesAndConfidences quotes the prices and their confidence in the
+// given denom, see quotePrices and quoteConfidences.
+func quotePricesAndConfidences(prices, confidences sdk.DecCoins, quote string) (sdk.DecCoins, sdk.DecCoins, error) {
+	quotedConfidences, err := quoteConfidences(prices, confidences, quote)
+	if err != nil {
+		return nil, nil, err
+	}
+	quoted, err := quotePrices(prices, quote)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quoted, quotedConfidences, nil
+}
+
+// targetPrices returns the current prices and their confidence in all quotes
+// of the target, USD by default. It fails if a quote has no price, so no
+// partial prices are published.
+func (o *Oracle) targetPrices(t *Target) (sdk.DecCoins, sdk.DecCoins, error) {
+	prices, confidences := o.getPricesAndConfidences()
+	if len(t.Quotes) == 0 {
+		return prices, confidences, nil
+	}
+
+	quoted, quotedConfidences := sdk.DecCoins{}, sdk.DecCoins{}
+	for _, quote := range t.Quotes {
+		p, c, err := quotePricesAndConfidences(prices, confidences, quote)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to quote prices: %w", err)
+		}
+		quoted = append(quoted, p...)
+		quotedConfidences = append(quotedConfidences, c...)
+	}
+	return quoted.Sort(), quotedConfidences.Sort(), nil
+}
@@ -0,0 +1,68 @@
+package oracle
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotePrices(t *testing.T) {
+	prices := sdk.NewDecCoins(
+		sdk.NewDecCoinFromDec("ATOM", sdk.MustNewDecFromStr("10")),
+		sdk.NewDecCoinFromDec("HBAR", sdk.MustNewDecFromStr("0.05")),
+		sdk.NewDecCoinFromDec("EUR", sdk.MustNewDecFromStr("1.25")),
+	)
+
+	quoted, err := quotePrices(prices, "USD")
+	require.NoError(t, err)
+	require.Equal(t, prices, quoted)
+
+	quoted, err = quotePrices(prices, "HBAR")
+	require.NoError(t, err)
+	require.Equal(t, sdk.DecCoins{
+		sdk.NewDecCoinFromDec("ATOM/HBAR", sdk.MustNewDecFromStr("200")),
+		sdk.NewDecCoinFromDec("EUR/HBAR", sdk.MustNewDecFromStr("25")),
+	}, quoted)
+
+	_, err = quotePrices(prices, "OSMO")
+	require.Error(t, err)
+
+	o := &Oracle{
+		logger: zerolog.Nop(),
+		prices: map[string]sdk.Dec{},
+		confidences: map[string]sdk.Dec{
+			"ATOM": sdk.MustNewDecFromStr("0.1"),
+			"EUR":  sdk.MustNewDecFromStr("0.0125"),
+		},
+	}
+	for _, price := range prices {
+		o.prices[price.Denom] = price.Amount
+	}
+
+	// a quote without a price fails instead of publishing partial prices
+	_, _, err = o.targetPrices(&Target{Quotes: []string{"EUR", "OSMO"}})
+	require.Error(t, err)
+
+	quoted, confidences, err := o.targetPrices(&Target{Quotes: []string{"EUR"}})
+	require.NoError(t, err)
+	require.Equal(t, sdk.DecCoins{
+		sdk.NewDecCoinFromDec("ATOM/EUR", sdk.MustNewDecFromStr("8")),
+		sdk.NewDecCoinFromDec("HBAR/EUR", sdk.MustNewDecFromStr("0.04")),
+	}, quoted)
+	// the confidence of the quote adds to the one of the price
+	require.Equal(t, sdk.DecCoins{
+		sdk.NewDecCoinFromDec("ATOM/EUR", sdk.MustNewDecFromStr("0.16")),
+	}, confidences)
+
+	quoted, confidences, err = o.targetPrices(&Target{})
+	require.NoError(t, err)
+	require.Equal(t, prices, quoted)
+	require.Equal(t, o.GetConfidences(), confidences)
+
+	quoted, confidences, err = o.targetPrices(&Target{Quotes: []string{"USD", "EUR"}})
+	require.NoError(t, err)
+	require.Len(t, quoted, 5)
+	require.Len(t, confidences, 3)
+}
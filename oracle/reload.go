@@ -0,0 +1,200 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/config"
+	"price-feeder/oracle/derivative"
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+type (
+	// Settings defines the price settings of the oracle, which can be
+	// reloaded while it is running.
+	Settings struct {
+		CurrencyPairs        []config.CurrencyPair
+		Deviations           map[string]DeviationFilter
+		ProviderMinOverrides map[string]int
+		Aggregators          map[string]Aggregator
+		ProviderWeights      map[provider.Name]sdk.Dec
+		VolumeShareCap       sdk.Dec
+		ConversionTolerance  sdk.Dec
+		Endpoints            map[provider.Name]provider.Endpoint
+		Derivatives          map[string]derivative.Derivative
+		DerivativePairs      map[string][]types.CurrencyPair
+		DerivativeSymbols    map[string]struct{}
+		ContractAddresses    map[string]map[string]string
+	}
+
+	reloadRequest struct {
+		settings Settings
+		done     chan struct{}
+	}
+)
+
+// Reload applies the settings to the running oracle between two ticks. New
+// pairs are subscribed at their providers, removed providers are closed and
+// providers with changed endpoints or removed pairs are restarted, as pairs
+// can't be unsubscribed. Derivative prices are
+// computed from the price history, so swapping derivatives keeps their
+// progress.
+func (o *Oracle) Reload(ctx context.Context, settings Settings) error {
+	req := reloadRequest{settings: settings, done: make(chan struct{})}
+	select {
+	case o.reloadCh <- req:
+	case <-o.closer.Done():
+		return fmt.Errorf("oracle stopped")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-req.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// applySettings swaps the settings of the oracle. It must only be called by
+// the goroutine running the oracle loop, as ticks read the settings without
+// holding a lock.
+func (o *Oracle) applySettings(ctx context.Context, s Settings) {
+	providerPairs := newProviderPairs(s.CurrencyPairs)
+
+	// pairs are subscribed after releasing the lock, as subscribing blocks
+	// on the network while price reads need the lock
+	type subscription struct {
+		provider provider.Provider
+		pairs    []types.CurrencyPair
+	}
+	subscriptions := map[provider.Name]subscription{}
+
+	o.providerMtx.Lock()
+	for name, pairs := range o.providerPairs {
+		logger := o.logger.With().Str("provider", name.String()).Logger()
+
+		newPairs, ok := providerPairs[name]
+		if !ok {
+			logger.Info().Msg("removing provider")
+			o.removeProvider(name)
+			continue
+		}
+
+		endpoint := o.endpoints[name]
+		endpoint.ContractAddresses = o.contractAddresses[name.String()]
+		newEndpoint := s.Endpoints[name]
+		newEndpoint.ContractAddresses = s.ContractAddresses[name.String()]
+		if !reflect.DeepEqual(endpoint, newEndpoint) {
+			logger.Info().Msg("endpoint changed, restarting provider")
+			o.removeProvider(name)
+			continue
+		}
+
+		removed := missingPairs(pairs, newPairs)
+		if len(removed) > 0 {
+			logger.Info().Str("pairs", pairsString(removed)).Msg("pairs removed, restarting provider")
+			o.removeProvider(name)
+			continue
+		}
+
+		added := missingPairs(newPairs, pairs)
+		if len(added) == 0 {
+			continue
+		}
+		logger.Info().Str("pairs", pairsString(added)).Msg("adding pairs")
+
+		priceProvider, ok := o.priceProviders[name]
+		if !ok {
+			// restart the pending initialization with all pairs
+			o.removeProvider(name)
+			continue
+		}
+		subscriptions[name] = subscription{provider: priceProvider, pairs: added}
+	}
+	for name := range providerPairs {
+		if _, ok := o.providerPairs[name]; !ok {
+			o.logger.Info().Str("provider", name.String()).Msg("adding provider")
+		}
+	}
+
+	o.providerPairs = providerPairs
+	o.endpoints = s.Endpoints
+	o.contractAddresses = s.ContractAddresses
+	o.providerWeights = s.ProviderWeights
+	o.providerMtx.Unlock()
+
+	for name, sub := range subscriptions {
+		if err := sub.provider.SubscribeCurrencyPairs(sub.pairs...); err != nil {
+			o.logger.Warn().
+				Err(err).
+				Str("provider", name.String()).
+				Msg("failed to subscribe pairs, restarting provider")
+			o.providerMtx.Lock()
+			o.removeProvider(name)
+			o.providerMtx.Unlock()
+		}
+	}
+
+	o.deviations = s.Deviations
+	o.providerMinOverrides = s.ProviderMinOverrides
+	o.aggregators = s.Aggregators
+	o.volumeShareCap = s.VolumeShareCap
+	o.conversionTolerance = s.ConversionTolerance
+	o.derivatives = s.Derivatives
+	o.derivativePairs = s.DerivativePairs
+	o.derivativeSymbols = s.DerivativeSymbols
+
+	o.logger.Info().
+		Int("providers", len(providerPairs)).
+		Int("derivatives", len(s.Derivatives)).
+		Msg("reloaded settings")
+
+	o.initProviders(ctx)
+}
+
+// newProviderPairs groups the currency pairs by provider.
+func newProviderPairs(currencyPairs []config.CurrencyPair) map[provider.Name][]types.CurrencyPair {
+	providerPairs := make(map[provider.Name][]types.CurrencyPair)
+	for _, pair := range currencyPairs {
+		for _, provider := range pair.Providers {
+			providerPairs[provider] = append(providerPairs[provider], types.CurrencyPair{
+				Base:  pair.Base,
+				Quote: pair.Quote,
+			})
+		}
+	}
+	return providerPairs
+}
+
+// missingPairs returns the pairs which are not part of others.
+func missingPairs(pairs, others []types.CurrencyPair) []types.CurrencyPair {
+	missing := []types.CurrencyPair{}
+	for _, pair := range pairs {
+		found := false
+		for _, other := range others {
+			if pair == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, pair)
+		}
+	}
+	return missing
+}
+
+func pairsString(pairs []types.CurrencyPair) string {
+	symbols := make([]string, len(pairs))
+	for i, pair := range pairs {
+		symbols[i] = pair.String()
+	}
+	return strings.Join(symbols, ",")
+}
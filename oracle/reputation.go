@@ -0,0 +1,170 @@
+package oracle
+
+import (
+	"sync"
+
+	"github.com/armon/go-metrics"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+type (
+	// Reputation scores providers by how far their USD prices were from the
+	// aggregated prices. The relative distance of each provider is smoothed
+	// exponentially over the ticks, the score is tolerance/(tolerance+error),
+	// i.e. 1 for a provider always matching the aggregated prices and 0.5
+	// for one which is off by the tolerance. A nil Reputation scores all
+	// providers with 1.
+	Reputation struct {
+		mtx       sync.RWMutex
+		smoothing sdk.Dec
+		tolerance sdk.Dec
+		errors    map[provider.Name]sdk.Dec
+		counts    map[provider.Name]uint64
+		pending   map[provider.Name][]sdk.Dec
+	}
+)
+
+// NewReputation returns a Reputation which weights the distance of the
+// latest tick with the smoothing factor.
+func NewReputation(smoothing, tolerance sdk.Dec) *Reputation {
+	return &Reputation{
+		smoothing: smoothing,
+		tolerance: tolerance,
+		errors:    map[provider.Name]sdk.Dec{},
+		counts:    map[provider.Name]uint64{},
+		pending:   map[provider.Name][]sdk.Dec{},
+	}
+}
+
+// Score returns the reputation score of the provider, which is 1 for
+// providers without observations.
+func (r *Reputation) Score(name provider.Name) sdk.Dec {
+	if r == nil {
+		return sdk.OneDec()
+	}
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.score(name)
+}
+
+func (r *Reputation) score(name provider.Name) sdk.Dec {
+	err, ok := r.errors[name]
+	if !ok {
+		return sdk.OneDec()
+	}
+	return r.tolerance.Quo(r.tolerance.Add(err))
+}
+
+// observe records the USD prices of a denom and the price they were
+// aggregated to. They are applied to the scores by update.
+func (r *Reputation) observe(aggregate sdk.Dec, tickers map[provider.Name]types.TickerPrice) {
+	if r == nil || !aggregate.IsPositive() {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for name, ticker := range tickers {
+		distance := ticker.Price.Sub(aggregate).Abs().Quo(aggregate)
+		r.pending[name] = append(r.pending[name], distance)
+	}
+}
+
+// update smoothes the mean distance of each provider observed in this tick
+// into its error and reports the scores.
+func (r *Reputation) update() {
+	if r == nil {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for name, distances := range r.pending {
+		mean := sdk.ZeroDec()
+		for _, distance := range distances {
+			mean = mean.Add(distance)
+		}
+		mean = mean.QuoInt64(int64(len(distances)))
+
+		if err, ok := r.errors[name]; ok {
+			r.errors[name] = err.Mul(sdk.OneDec().Sub(r.smoothing)).Add(mean.Mul(r.smoothing))
+		} else {
+			r.errors[name] = mean
+		}
+		r.counts[name]++
+
+		telemetry.SetGaugeWithLabels(
+			[]string{"provider", "reputation"},
+			float32(r.score(name).MustFloat64()),
+			[]metrics.Label{telemetry.NewLabel("provider", name.String())},
+		)
+	}
+	r.pending = map[provider.Name][]sdk.Dec{}
+}
+
+// reputations returns the reputation of all observed providers.
+func (r *Reputation) reputations() map[provider.Name]types.ProviderReputation {
+	reputations := map[provider.Name]types.ProviderReputation{}
+	if r == nil {
+		return reputations
+	}
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	for name, err := range r.errors {
+		reputations[name] = types.ProviderReputation{
+			Score:        r.score(name),
+			Error:        err,
+			Observations: r.counts[name],
+		}
+	}
+	return reputations
+}
+
+// providerTrust returns the trust weight of each provider with prices, which
+// is its static weight times its reputation score.
+func providerTrust(
+	providerPrices provider.AggregatedProviderPrices,
+	weights map[provider.Name]sdk.Dec,
+	reputation *Reputation,
+) map[provider.Name]sdk.Dec {
+	trust := make(map[provider.Name]sdk.Dec, len(providerPrices))
+	for name := range providerPrices {
+		weight, ok := weights[name]
+		if !ok {
+			weight = sdk.OneDec()
+		}
+		trust[name] = weight.Mul(reputation.Score(name))
+	}
+	return trust
+}
+
+// GetProviderReputation returns the static weight and the reputation of all
+// configured or observed providers.
+func (o *Oracle) GetProviderReputation() map[provider.Name]types.ProviderReputation {
+	reputations := o.reputation.reputations()
+
+	o.providerMtx.RLock()
+	defer o.providerMtx.RUnlock()
+
+	for name := range o.providerPairs {
+		if _, ok := reputations[name]; !ok {
+			reputations[name] = types.ProviderReputation{
+				Score: sdk.OneDec(),
+				Error: sdk.ZeroDec(),
+			}
+		}
+	}
+	for name, reputation := range reputations {
+		weight, ok := o.providerWeights[name]
+		if !ok {
+			weight = sdk.OneDec()
+		}
+		reputation.Weight = weight
+		reputation.Trust = weight.Mul(reputation.Score)
+		reputations[name] = reputation
+	}
+	return reputations
+}
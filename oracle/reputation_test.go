@@ -0,0 +1,130 @@
+package oracle
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+func TestReputation(t *testing.T) {
+	reputation := NewReputation(sdk.MustNewDecFromStr("0.1"), sdk.MustNewDecFromStr("0.01"))
+	require.Equal(t, sdk.OneDec(), reputation.Score(provider.ProviderBinance))
+
+	reputation.observe(sdk.NewDec(10), map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: {Price: sdk.NewDec(10)},
+		provider.ProviderKraken:  {Price: sdk.NewDec(11)},
+	})
+	// observations only count once they are applied
+	require.Equal(t, sdk.OneDec(), reputation.Score(provider.ProviderKraken))
+	reputation.update()
+
+	require.Equal(t, sdk.OneDec(), reputation.Score(provider.ProviderBinance))
+	require.Equal(t, sdk.MustNewDecFromStr("0.090909090909090909"), reputation.Score(provider.ProviderKraken))
+
+	// the distances of a tick are averaged and smoothed into the error
+	reputation.observe(sdk.NewDec(10), map[provider.Name]types.TickerPrice{
+		provider.ProviderKraken: {Price: sdk.NewDec(10)},
+	})
+	reputation.observe(sdk.NewDec(20), map[provider.Name]types.TickerPrice{
+		provider.ProviderKraken: {Price: sdk.NewDec(20)},
+	})
+	reputation.update()
+
+	reputations := reputation.reputations()
+	require.Len(t, reputations, 2)
+	require.Equal(t, sdk.MustNewDecFromStr("0.09"), reputations[provider.ProviderKraken].Error)
+	require.Equal(t, uint64(2), reputations[provider.ProviderKraken].Observations)
+	require.Equal(t, uint64(1), reputations[provider.ProviderBinance].Observations)
+
+	var disabled *Reputation
+	disabled.observe(sdk.NewDec(10), map[provider.Name]types.TickerPrice{
+		provider.ProviderKraken: {Price: sdk.NewDec(11)},
+	})
+	disabled.update()
+	require.Equal(t, sdk.OneDec(), disabled.Score(provider.ProviderKraken))
+	require.Empty(t, disabled.reputations())
+}
+
+func TestAggregators_Trust(t *testing.T) {
+	// a small exchange reporting a fake volume
+	prices := map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: ticker("10", "1000"),
+		provider.ProviderMexc:    ticker("20", "20000"),
+	}
+
+	price, _, err := VWAPAggregator{}.Aggregate(prices, nil)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("19.090909090909090909"), price)
+
+	price, weights, err := VWAPAggregator{}.Aggregate(prices, map[provider.Name]sdk.Dec{
+		provider.ProviderMexc: sdk.MustNewDecFromStr("0.01"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("10.909090909090909091"), price)
+	require.Equal(t, sdk.MustNewDecFromStr("0.909090909090909091"), weights[provider.ProviderBinance])
+
+	// the trust weights the median
+	prices = map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: ticker("10", "1"),
+		provider.ProviderKraken:  ticker("11", "1"),
+		provider.ProviderMexc:    ticker("12", "1"),
+	}
+	price, _, err = MedianAggregator{}.Aggregate(prices, map[provider.Name]sdk.Dec{
+		provider.ProviderMexc: sdk.NewDec(5),
+	})
+	require.NoError(t, err)
+	require.Equal(t, sdk.NewDec(12), price)
+}
+
+func TestComputePrices_Reputation(t *testing.T) {
+	atomUsd := types.CurrencyPair{Base: "ATOM", Quote: "USD"}
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderBinance: {"ATOMUSD": ticker("10", "1")},
+		provider.ProviderKraken:  {"ATOMUSD": ticker("10", "1")},
+		provider.ProviderMexc:    {"ATOMUSD": ticker("11", "1")},
+	}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderBinance: {atomUsd},
+		provider.ProviderKraken:  {atomUsd},
+		provider.ProviderMexc:    {atomUsd},
+	}
+	filters := map[string]DeviationFilter{"ATOM": {Threshold: sdk.NewDec(2)}}
+	reputation := NewReputation(sdk.OneDec(), sdk.MustNewDecFromStr("0.01"))
+
+	prices, _, err := computePrices(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		nil,
+		conversionOptions{
+			deviationFilters: filters,
+			now:              time.Now(),
+			reputation:       reputation,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("10.333333333333333333"), prices["ATOM"])
+
+	// the provider which was furthest off loses more weight than the others
+	// in the next tick
+	require.True(t, reputation.Score(provider.ProviderMexc).LT(reputation.Score(provider.ProviderBinance)))
+	prices, _, err = computePrices(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		nil,
+		conversionOptions{
+			deviationFilters: filters,
+			now:              time.Now(),
+			reputation:       reputation,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("10.220910623946037097"), prices["ATOM"])
+}
@@ -0,0 +1,450 @@
+package oracle
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"golang.org/x/sync/errgroup"
+
+	"price-feeder/oracle/envelope"
+	"price-feeder/oracle/history"
+	"price-feeder/oracle/publisher"
+	"price-feeder/oracle/push"
+	"price-feeder/oracle/voting"
+)
+
+type (
+	// Budget reports whether non-essential publishing is paused, e.g. because
+	// the balance of the operator account is low.
+	Budget interface {
+		Paused() bool
+	}
+
+	// Target defines a topic the oracle publishes its prices to. Every
+	// target has its own feeder account, voting rounds and commit-reveal
+	// state, while the prices are shared by all targets. The name identifies
+	// the persisted state of the target and must be unique. Mode selects how
+	// prices are published, commit-reveal by default. Trigger decides when
+	// prices are pushed in push_on_deviation mode. Quotes are the denoms the
+	// prices are published in, USD by default.
+	Target struct {
+		Name      string
+		Feeder    string
+		Mode      string
+		Publisher publisher.Publisher
+		Signer    envelope.Signer
+		Budget    Budget
+		Rounds    voting.VoteRounds
+		Trigger   *push.Trigger
+		Quotes    []string
+
+		previousPrevote *PreviousPrevote
+		pushedRound     *uint64
+		paused          bool
+	}
+)
+
+// tick reveals the pending votes of all targets and publishes the prices to
+// all targets that are due, i.e. submits their prevotes or pushes the prices.
+// Prices are only set once per tick and shared by all targets, which publish
+// concurrently.
+func (o *Oracle) tick(ctx context.Context) error {
+	o.logger.Debug().Msg("executing oracle tick")
+
+	now := time.Now()
+
+	g := new(errgroup.Group)
+	for _, target := range o.targets {
+		target := target
+		g.Go(func() error {
+			return o.reveal(ctx, target, now)
+		})
+	}
+	err := g.Wait()
+
+	due := []*Target{}
+	for _, target := range o.targets {
+		if o.publishDue(target, now) {
+			due = append(due, target)
+		}
+	}
+	if len(due) == 0 {
+		return err
+	}
+
+	if err := o.SetPrices(ctx); err != nil {
+		return err
+	}
+
+	g = new(errgroup.Group)
+	for _, target := range due {
+		target := target
+		g.Go(func() error {
+			return o.publishPrices(ctx, target, now)
+		})
+	}
+	if prevoteErr := g.Wait(); prevoteErr != nil {
+		return prevoteErr
+	}
+	return err
+}
+
+// publishDue reports whether the target may publish prices in this tick.
+// Targets in push_on_deviation mode are due as long as they are not paused,
+// whether the prices are pushed is decided once they are set.
+func (o *Oracle) publishDue(t *Target, now time.Time) bool {
+	switch t.Mode {
+	case push.ModePush:
+		return o.pushDue(t, now)
+	case push.ModePushOnDeviation:
+		return !o.paused(t, t.Rounds.Round(now))
+	default:
+		return o.prevoteDue(t, now)
+	}
+}
+
+// publishPrices publishes the current prices according to the mode of the
+// target.
+func (o *Oracle) publishPrices(ctx context.Context, t *Target, now time.Time) error {
+	round := t.Rounds.Round(now)
+	switch t.Mode {
+	case push.ModePush:
+		return o.push(ctx, t, round, now)
+	case push.ModePushOnDeviation:
+		prices, _, err := o.targetPrices(t)
+		if err != nil {
+			return err
+		}
+		due, reason := t.Trigger.Due(prices, now)
+		if !due {
+			return nil
+		}
+		o.logger.Debug().
+			Str("target", t.Name).
+			Str("reason", reason).
+			Msg("prices due for push")
+		return o.push(ctx, t, round, now)
+	default:
+		return o.prevote(ctx, t, round)
+	}
+}
+
+// reveal submits the vote of the target's pending prevote once the vote
+// offset of the following round is reached. Prevotes which can't be
+// revealed anymore are dropped.
+func (o *Oracle) reveal(ctx context.Context, t *Target, now time.Time) error {
+	if t.previousPrevote == nil {
+		return nil
+	}
+
+	round := t.Rounds.Round(now)
+	prevoteRound := t.previousPrevote.Round
+
+	// The reveal has to happen in the round following the prevote,
+	// anything older can't be revealed anymore.
+	if prevoteRound+1 < round {
+		o.logger.Info().
+			Str("target", t.Name).
+			Uint64("round", prevoteRound).
+			Msg("missing vote during voting period")
+		telemetry.IncrCounter(1, "vote", "failure", "missed")
+
+		t.previousPrevote = nil
+		o.clearPrevote(t)
+		return nil
+	}
+	if prevoteRound+1 != round || t.Rounds.Offset(now) < t.Rounds.VoteOffset {
+		return nil
+	}
+
+	return o.vote(ctx, t)
+}
+
+// prevoteDue reports whether the target needs a prevote for the current
+// round.
+func (o *Oracle) prevoteDue(t *Target, now time.Time) bool {
+	round := t.Rounds.Round(now)
+	offset := t.Rounds.Offset(now)
+
+	o.logger.Debug().
+		Str("target", t.Name).
+		Dur("vote_period", t.Rounds.Period).
+		Uint64("round", round).
+		Dur("offset", offset).
+		Msg("")
+
+	if offset < t.Rounds.PrevoteOffset {
+		return false
+	}
+
+	// A prevote left after the reveal either belongs to the current round or
+	// its vote failed and is retried on the next tick.
+	if t.previousPrevote != nil {
+		if t.previousPrevote.Round == round {
+			o.logger.Debug().
+				Str("target", t.Name).
+				Time("next round starts", t.Rounds.Start(round+1)).
+				Msg("skipping until next voting round")
+		}
+		return false
+	}
+
+	// Revealing a prevote is essential, as its fee is already spent, but
+	// starting a new commit-reveal cycle can be skipped while the operator
+	// balance is low.
+	return !o.paused(t, round)
+}
+
+// pushDue reports whether the target has to push the prices of the current
+// round, which happens once per round at the prevote offset.
+func (o *Oracle) pushDue(t *Target, now time.Time) bool {
+	round := t.Rounds.Round(now)
+	if t.Rounds.Offset(now) < t.Rounds.PrevoteOffset {
+		return false
+	}
+	if t.pushedRound != nil && *t.pushedRound == round {
+		return false
+	}
+	return !o.paused(t, round)
+}
+
+// paused reports whether publishing new prices to the target is paused
+// because the operator balance is low. Changes of the state are logged once.
+func (o *Oracle) paused(t *Target, round uint64) bool {
+	paused := t.Budget != nil && t.Budget.Paused()
+	if paused != t.paused {
+		t.paused = paused
+		if paused {
+			o.logger.Warn().
+				Str("target", t.Name).
+				Uint64("round", round).
+				Msg("operator balance low, pausing prices")
+		} else {
+			o.logger.Info().
+				Str("target", t.Name).
+				Uint64("round", round).
+				Msg("operator balance restored, resuming prices")
+		}
+	}
+
+	gauge := float32(0)
+	if paused {
+		gauge = 1
+	}
+	telemetry.SetGaugeWithLabels(
+		[]string{"publish", "paused"},
+		gauge,
+		[]metrics.Label{telemetry.NewLabel("target", t.Name)},
+	)
+	return paused
+}
+
+// push publishes the current exchange rates without commit-reveal.
+func (o *Oracle) push(ctx context.Context, t *Target, round uint64, now time.Time) error {
+	prices, confidences, err := o.targetPrices(t)
+	if err != nil {
+		return err
+	}
+	pushMsg := &MsgPushExchangeRates{
+		ExchangeRates: GenerateExchangeRatesString(prices),
+		Confidences:   GenerateExchangeRatesString(confidences),
+		Feeder:        t.Feeder,
+		Round:         round,
+		Timestamp:     now.UnixMilli(),
+	}
+
+	o.logger.Info().
+		Str("target", t.Name).
+		Str("exchange_rates", pushMsg.ExchangeRates).
+		Str("feeder", pushMsg.Feeder).
+		Uint64("round", round).
+		Msg("pushing prices")
+
+	if err := o.publish(ctx, t, round, pushMsg, t.Rounds.Start(round+1)); err != nil {
+		return err
+	}
+
+	t.pushedRound = &round
+	if t.Trigger != nil {
+		t.Trigger.Pushed(prices, now)
+	}
+	o.healthchecksPing()
+
+	return nil
+}
+
+// prevote submits the hash of the current exchange rates for the given round
+// and keeps the salt and rates to reveal them in the next round.
+func (o *Oracle) prevote(ctx context.Context, t *Target, round uint64) error {
+	salt, err := GenerateSalt(32)
+	if err != nil {
+		return err
+	}
+
+	prices, confidences, err := o.targetPrices(t)
+	if err != nil {
+		return err
+	}
+	exchangeRatesStr := GenerateExchangeRatesString(prices)
+	confidencesStr := GenerateExchangeRatesString(confidences)
+	hash := GetAggregateVoteHash(salt, exchangeRatesStr, t.Feeder)
+	preVoteMsg := &MsgAggregateExchangeRatePrevote{
+		Hash:   hex.EncodeToString(hash), // hash of prices from the oracle
+		Feeder: t.Feeder,
+		Round:  round,
+	}
+	if confidencesStr != "" {
+		// the hash of the rates stays compatible with x/oracle
+		confidenceHash := GetAggregateVoteHash(salt, confidencesStr, t.Feeder)
+		preVoteMsg.ConfidenceHash = hex.EncodeToString(confidenceHash)
+	}
+
+	o.logger.Info().
+		Str("target", t.Name).
+		Str("hash", preVoteMsg.Hash).
+		Str("feeder", preVoteMsg.Feeder).
+		Uint64("round", round).
+		Msg("submitting pre-vote")
+
+	if err := o.publish(ctx, t, round, preVoteMsg, t.Rounds.Start(round+1)); err != nil {
+		return err
+	}
+
+	t.previousPrevote = &PreviousPrevote{
+		Salt:              salt,
+		ExchangeRates:     exchangeRatesStr,
+		Confidences:       confidencesStr,
+		SubmitBlockHeight: time.Now().Unix(),
+		Round:             round,
+	}
+	o.persistPrevote(t)
+
+	return nil
+}
+
+// vote reveals the salt and exchange rates of the previous prevote.
+func (o *Oracle) vote(ctx context.Context, t *Target) error {
+	voteMsg := &MsgAggregateExchangeRateVote{
+		Salt:          t.previousPrevote.Salt,
+		ExchangeRates: t.previousPrevote.ExchangeRates,
+		Feeder:        t.Feeder,
+		Round:         t.previousPrevote.Round,
+		Confidences:   t.previousPrevote.Confidences,
+	}
+
+	o.logger.Info().
+		Str("target", t.Name).
+		Str("exchange_rates", voteMsg.ExchangeRates).
+		Str("feeder", voteMsg.Feeder).
+		Uint64("round", voteMsg.Round).
+		Msg("broadcasting vote")
+
+	// the vote is due in the round following its prevote
+	if err := o.publish(ctx, t, voteMsg.Round, voteMsg, t.Rounds.Start(voteMsg.Round+2)); err != nil {
+		return err
+	}
+
+	t.previousPrevote = nil
+	o.clearPrevote(t)
+	o.healthchecksPing()
+
+	return nil
+}
+
+// publish wraps the message into a signed envelope for the given round and
+// hands it to the publisher of the target, split into chunks if it is too
+// large for a single message. The message expires at the end of the window
+// it may be submitted in.
+func (o *Oracle) publish(
+	ctx context.Context,
+	t *Target,
+	round uint64,
+	msg interface{},
+	expires time.Time,
+) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	chunks, err := t.Signer.Seal(round, payload)
+	if err != nil {
+		return err
+	}
+	if len(chunks) > 1 {
+		o.logger.Debug().
+			Str("target", t.Name).
+			Int("size", len(payload)).
+			Int("chunks", len(chunks)).
+			Msg("splitting message into chunks")
+	}
+
+	ctx, cancel := context.WithDeadline(publisher.WithExpiry(ctx, expires), expires)
+	defer cancel()
+	for _, bz := range chunks {
+		if err := t.Publisher.Publish(ctx, bz); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistPrevote stores the commit-reveal state of the current prevote, so
+// the vote can still be revealed if the feeder restarts in between.
+func (o *Oracle) persistPrevote(t *Target) {
+	err := o.history.SetPrevote(history.Prevote{
+		Target:        t.Name,
+		Salt:          t.previousPrevote.Salt,
+		ExchangeRates: t.previousPrevote.ExchangeRates,
+		Confidences:   t.previousPrevote.Confidences,
+		Submitted:     time.Unix(t.previousPrevote.SubmitBlockHeight, 0),
+		Round:         t.previousPrevote.Round,
+	})
+	if err != nil {
+		o.logger.Warn().Err(err).Str("target", t.Name).Msg("failed to persist prevote")
+	}
+}
+
+func (o *Oracle) clearPrevote(t *Target) {
+	err := o.history.DeletePrevote(t.Name)
+	if err != nil {
+		o.logger.Warn().Err(err).Str("target", t.Name).Msg("failed to delete persisted prevote")
+	}
+}
+
+// restorePrevote loads the prevote persisted by a previous run. It is only
+// restored if its vote can still be revealed, i.e. it was submitted in the
+// current or the previous round, otherwise it is discarded.
+func (o *Oracle) restorePrevote(t *Target) {
+	prevote, found, err := o.history.GetPrevote(t.Name)
+	if err != nil || !found {
+		return
+	}
+
+	round := t.Rounds.Round(time.Now())
+	if prevote.Round+1 < round || prevote.Round > round {
+		o.logger.Info().
+			Str("target", t.Name).
+			Uint64("round", prevote.Round).
+			Uint64("current_round", round).
+			Msg("discarding persisted prevote outside of voting window")
+		o.clearPrevote(t)
+		return
+	}
+
+	o.logger.Info().
+		Str("target", t.Name).
+		Uint64("round", prevote.Round).
+		Msg("restored persisted prevote")
+
+	t.previousPrevote = &PreviousPrevote{
+		Salt:              prevote.Salt,
+		ExchangeRates:     prevote.ExchangeRates,
+		Confidences:       prevote.Confidences,
+		SubmitBlockHeight: prevote.Submitted.Unix(),
+		Round:             prevote.Round,
+	}
+}
@@ -0,0 +1,28 @@
+package tracker
+
+import (
+	"sort"
+)
+
+// Group combines the trackers of several publish targets, keyed by the
+// target name.
+type Group map[string]*Tracker
+
+// GetSubmissions returns the most recent submissions of all targets, oldest
+// first.
+func (g Group) GetSubmissions() []Submission {
+	submissions := []Submission{}
+	for name, t := range g {
+		for _, submission := range t.GetSubmissions() {
+			submission.Target = name
+			submissions = append(submissions, submission)
+		}
+	}
+	sort.SliceStable(submissions, func(i, j int) bool {
+		if submissions[i].Submitted.Equal(submissions[j].Submitted) {
+			return submissions[i].Target < submissions[j].Target
+		}
+		return submissions[i].Submitted.Before(submissions[j].Submitted)
+	})
+	return submissions
+}
@@ -0,0 +1,281 @@
+package tracker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/cosmos/cosmos-sdk/telemetry"
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+
+	"price-feeder/oracle/mirror"
+)
+
+const (
+	StatusPending     = "pending"
+	StatusConfirmed   = "confirmed"
+	StatusFailed      = "failed"
+	StatusUnconfirmed = "unconfirmed"
+
+	// resultSuccess is the mirror node result of a successful transaction.
+	resultSuccess = "SUCCESS"
+
+	// maxSubmissions is the number of submissions kept for the API.
+	maxSubmissions = 100
+)
+
+type (
+	// Submission defines the confirmation state of a topic message
+	// submission.
+	Submission struct {
+		// Target is the name of the publish target, it is only set for
+		// submissions returned by a Group.
+		Target             string    `json:"target,omitempty"`
+		TransactionID      string    `json:"transaction_id"`
+		Status             string    `json:"status"`
+		Submitted          time.Time `json:"submitted"`
+		Deadline           time.Time `json:"deadline"`
+		Result             string    `json:"result,omitempty"`
+		ConsensusTimestamp string    `json:"consensus_timestamp,omitempty"`
+		SequenceNumber     uint64    `json:"sequence_number,omitempty"`
+		// ChargedFee is the fee charged for the transaction in tinybars.
+		ChargedFee int64 `json:"charged_fee,omitempty"`
+
+		txID hedera.TransactionID
+	}
+
+	// Fees is notified of the fee charged for every submission that reached
+	// consensus, as reported by the mirror node.
+	Fees interface {
+		AddFee(fee hedera.Hbar)
+	}
+
+	// Tracker polls a mirror node for the consensus state of submitted
+	// topic messages. A submission has to be confirmed before the voting
+	// round it was submitted in closes, otherwise it is reported as
+	// unconfirmed. The fees charged for submissions are reported to fees,
+	// if any. Its metrics are labeled with the publish target.
+	Tracker struct {
+		logger       zerolog.Logger
+		target       string
+		mirror       mirror.Client
+		roundEnd     func(time.Time) time.Time
+		pollInterval time.Duration
+		fees         Fees
+
+		mtx         sync.RWMutex
+		submissions []*Submission
+	}
+)
+
+// NewTracker returns a new Tracker of a publish target. roundEnd returns the
+// time the voting round containing the given time closes, fees is optional.
+func NewTracker(
+	logger zerolog.Logger,
+	target string,
+	mirrorClient mirror.Client,
+	roundEnd func(time.Time) time.Time,
+	pollInterval time.Duration,
+	fees Fees,
+) *Tracker {
+	return &Tracker{
+		logger:       logger.With().Str("module", "tracker").Logger(),
+		target:       target,
+		mirror:       mirrorClient,
+		roundEnd:     roundEnd,
+		pollInterval: pollInterval,
+		fees:         fees,
+		submissions:  []*Submission{},
+	}
+}
+
+// Track starts tracking a submitted transaction. Tracking is a no-op on a
+// nil Tracker, so it can be passed around if no mirror node is configured.
+func (t *Tracker) Track(txID hedera.TransactionID) {
+	if t == nil {
+		return
+	}
+
+	now := time.Now()
+	submission := &Submission{
+		TransactionID: mirror.TransactionID(txID),
+		Status:        StatusPending,
+		Submitted:     now,
+		Deadline:      t.roundEnd(now),
+		txID:          txID,
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.submissions = append(t.submissions, submission)
+	// only pending submissions must not be dropped
+	for len(t.submissions) > maxSubmissions && t.submissions[0].Status != StatusPending {
+		t.submissions = t.submissions[1:]
+	}
+}
+
+// GetSubmissions returns the most recent submissions, oldest first.
+func (t *Tracker) GetSubmissions() []Submission {
+	if t == nil {
+		return []Submission{}
+	}
+
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	submissions := make([]Submission, len(t.submissions))
+	for i, submission := range t.submissions {
+		submissions[i] = *submission
+	}
+	return submissions
+}
+
+// Start polls the mirror node until the context is cancelled.
+func (t *Tracker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.poll(ctx)
+		}
+	}
+}
+
+func (t *Tracker) poll(ctx context.Context) {
+	t.mtx.RLock()
+	pending := []*Submission{}
+	for _, submission := range t.submissions {
+		if submission.Status == StatusPending {
+			pending = append(pending, submission)
+		}
+	}
+	t.mtx.RUnlock()
+
+	for _, submission := range pending {
+		t.check(ctx, submission)
+	}
+
+	telemetry.SetGaugeWithLabels([]string{"submission", "pending"}, float32(len(pending)), t.labels())
+}
+
+func (t *Tracker) check(ctx context.Context, submission *Submission) {
+	now := time.Now()
+
+	tx, found, err := t.mirror.GetTransaction(ctx, submission.txID)
+	if err != nil {
+		t.logger.Warn().
+			Err(err).
+			Str("transaction_id", submission.TransactionID).
+			Msg("failed to query transaction")
+	}
+	if !found {
+		if now.After(submission.Deadline) {
+			t.update(submission, func(s *Submission) {
+				s.Status = StatusUnconfirmed
+			})
+			t.logger.Error().
+				Str("transaction_id", submission.TransactionID).
+				Time("deadline", submission.Deadline).
+				Msg("submission not confirmed before the round closed")
+			telemetry.IncrCounterWithLabels([]string{"submission", StatusUnconfirmed}, 1, t.labels())
+		}
+		return
+	}
+
+	if tx.Result != resultSuccess {
+		t.update(submission, func(s *Submission) {
+			s.Status = StatusFailed
+			s.Result = tx.Result
+			s.ConsensusTimestamp = tx.ConsensusTimestamp
+			s.ChargedFee = tx.ChargedTxFee
+		})
+		t.logger.Error().
+			Str("transaction_id", submission.TransactionID).
+			Str("result", tx.Result).
+			Msg("submission failed")
+		telemetry.IncrCounterWithLabels([]string{"submission", StatusFailed}, 1, t.labels())
+		t.charge(tx.ChargedTxFee)
+		return
+	}
+
+	msg, err := t.mirror.GetTopicMessage(ctx, tx.ConsensusTimestamp)
+	if err != nil {
+		t.logger.Debug().
+			Err(err).
+			Str("transaction_id", submission.TransactionID).
+			Msg("failed to query topic message")
+		// the topic message is usually indexed together with the
+		// transaction, retry with the next poll until the round closes
+		if !now.After(submission.Deadline) {
+			return
+		}
+	}
+
+	consensus, err := mirror.ParseTimestamp(tx.ConsensusTimestamp)
+	if err != nil {
+		t.logger.Warn().
+			Err(err).
+			Str("transaction_id", submission.TransactionID).
+			Msg("invalid consensus timestamp")
+		return
+	}
+
+	status := StatusConfirmed
+	if consensus.After(submission.Deadline) {
+		status = StatusUnconfirmed
+	}
+	t.update(submission, func(s *Submission) {
+		s.Status = status
+		s.Result = tx.Result
+		s.ConsensusTimestamp = tx.ConsensusTimestamp
+		s.SequenceNumber = msg.SequenceNumber
+		s.ChargedFee = tx.ChargedTxFee
+	})
+
+	t.logger.Debug().
+		Str("transaction_id", submission.TransactionID).
+		Str("consensus_timestamp", tx.ConsensusTimestamp).
+		Uint64("sequence_number", msg.SequenceNumber).
+		Str("status", status).
+		Msg("submission reached consensus")
+
+	telemetry.IncrCounterWithLabels([]string{"submission", status}, 1, t.labels())
+	t.charge(tx.ChargedTxFee)
+	telemetry.SetGaugeWithLabels(
+		[]string{"submission", "latency"},
+		float32(consensus.Sub(submission.Submitted).Seconds()),
+		t.labels(),
+	)
+	if status == StatusUnconfirmed {
+		t.logger.Error().
+			Str("transaction_id", submission.TransactionID).
+			Time("deadline", submission.Deadline).
+			Msg("submission not confirmed before the round closed")
+	}
+}
+
+// charge reports the fee in tinybars charged for a submission, once its state
+// is final, so every submission is only charged once.
+func (t *Tracker) charge(fee int64) {
+	telemetry.IncrCounterWithLabels([]string{"submission", "fee"}, float32(fee), t.labels())
+	if t.fees != nil {
+		t.fees.AddFee(hedera.HbarFromTinybar(fee))
+	}
+}
+
+func (t *Tracker) labels() []metrics.Label {
+	return []metrics.Label{telemetry.NewLabel("target", t.target)}
+}
+
+func (t *Tracker) update(submission *Submission, fn func(*Submission)) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	fn(submission)
+}
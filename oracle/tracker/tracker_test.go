@@ -0,0 +1,121 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashgraph/hedera-sdk-go/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/oracle/mirror"
+)
+
+type feeSum struct {
+	tinybar int64
+}
+
+func (f *feeSum) AddFee(fee hedera.Hbar) {
+	f.tinybar += fee.AsTinybar()
+}
+
+func TestTracker(t *testing.T) {
+	account := hedera.AccountID{Account: 1234}
+	confirmed := hedera.TransactionIDGenerate(account)
+	failed := hedera.TransactionIDGenerate(account)
+	late := hedera.TransactionIDGenerate(account)
+	missing := hedera.TransactionIDGenerate(account)
+
+	consensus := time.Now().Add(time.Second)
+	timestamp := fmt.Sprintf("%d.%09d", consensus.Unix(), consensus.Nanosecond())
+	lateConsensus := consensus.Add(time.Hour)
+	lateTimestamp := fmt.Sprintf("%d.%09d", lateConsensus.Unix(), lateConsensus.Nanosecond())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/transactions/" + mirror.TransactionID(confirmed):
+			fmt.Fprintf(w, `{"transactions":[{"charged_tx_fee":100,"consensus_timestamp":"%s","result":"SUCCESS"}]}`, timestamp)
+		case "/api/v1/transactions/" + mirror.TransactionID(failed):
+			fmt.Fprintf(w, `{"transactions":[{"charged_tx_fee":20,"consensus_timestamp":"%s","result":"INVALID_TOPIC_ID"}]}`, timestamp)
+		case "/api/v1/transactions/" + mirror.TransactionID(late):
+			fmt.Fprintf(w, `{"transactions":[{"consensus_timestamp":"%s","result":"SUCCESS"}]}`, lateTimestamp)
+		case "/api/v1/topics/messages/" + timestamp:
+			fmt.Fprintf(w, `{"consensus_timestamp":"%s","sequence_number":7}`, timestamp)
+		case "/api/v1/topics/messages/" + lateTimestamp:
+			fmt.Fprintf(w, `{"consensus_timestamp":"%s","sequence_number":8}`, lateTimestamp)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := mirror.NewClient(server.URL, server.Client())
+	require.NoError(t, err)
+
+	deadline := time.Now().Add(time.Minute)
+	roundEnd := func(time.Time) time.Time { return deadline }
+	fees := &feeSum{}
+	tracker := NewTracker(zerolog.Nop(), "test", client, roundEnd, time.Second, fees)
+
+	tracker.Track(confirmed)
+	tracker.Track(failed)
+	tracker.Track(late)
+	tracker.Track(missing)
+
+	tracker.poll(context.Background())
+	submissions := tracker.GetSubmissions()
+	require.Len(t, submissions, 4)
+
+	require.Equal(t, StatusConfirmed, submissions[0].Status)
+	require.Equal(t, uint64(7), submissions[0].SequenceNumber)
+	require.Equal(t, timestamp, submissions[0].ConsensusTimestamp)
+	require.Equal(t, int64(100), submissions[0].ChargedFee)
+
+	require.Equal(t, StatusFailed, submissions[1].Status)
+	require.Equal(t, "INVALID_TOPIC_ID", submissions[1].Result)
+
+	require.Equal(t, StatusUnconfirmed, submissions[2].Status)
+	require.Equal(t, uint64(8), submissions[2].SequenceNumber)
+
+	// still within the round
+	require.Equal(t, StatusPending, submissions[3].Status)
+
+	// failed submissions are charged as well
+	require.Equal(t, int64(120), fees.tinybar)
+
+	deadline = time.Now().Add(-time.Second)
+	tracker.Track(missing)
+	tracker.poll(context.Background())
+	submissions = tracker.GetSubmissions()
+	require.Equal(t, StatusUnconfirmed, submissions[4].Status)
+}
+
+func TestTracker_nil(t *testing.T) {
+	var tracker *Tracker
+	tracker.Track(hedera.TransactionIDGenerate(hedera.AccountID{Account: 1234}))
+	require.Empty(t, tracker.GetSubmissions())
+}
+
+func TestGroup(t *testing.T) {
+	roundEnd := func(now time.Time) time.Time { return now.Add(time.Minute) }
+	first := NewTracker(zerolog.Nop(), "first", mirror.Client{}, roundEnd, time.Second, nil)
+	second := NewTracker(zerolog.Nop(), "second", mirror.Client{}, roundEnd, time.Second, nil)
+
+	first.Track(hedera.TransactionIDGenerate(hedera.AccountID{Account: 1234}))
+	second.Track(hedera.TransactionIDGenerate(hedera.AccountID{Account: 5678}))
+	first.Track(hedera.TransactionIDGenerate(hedera.AccountID{Account: 1234}))
+
+	submissions := Group{"first": first, "second": second, "disabled": nil}.GetSubmissions()
+	require.Len(t, submissions, 3)
+	for i, submission := range submissions {
+		require.Equal(t, StatusPending, submission.Status)
+		require.NotEmpty(t, submission.Target)
+		if i > 0 {
+			require.False(t, submission.Submitted.Before(submissions[i-1].Submitted))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+type (
+	// PriceAudit explains how the price of a denom was computed, i.e. which
+	// provider prices were considered, how they were converted to USD,
+	// which of them were filtered and how the remaining ones were weighted.
+	// The price is zero if no price could be computed, Error tells why.
+	PriceAudit struct {
+		Denom   string        `json:"denom"`
+		Time    time.Time     `json:"time"`
+		Price   sdk.Dec       `json:"price"`
+		Error   string        `json:"error,omitempty"`
+		Sources []AuditSource `json:"sources"`
+	}
+
+	// AuditSource defines a single provider price of a denom. Path lists
+	// the symbols used to convert the provider price to USD, starting with
+	// the symbol of the provider price, Rate is the USD rate of its quote.
+	// Weight is the share of the price in the final price, filtered prices
+	// have no weight and tell why they were filtered.
+	AuditSource struct {
+		Provider string   `json:"provider"`
+		Path     []string `json:"path"`
+		Price    sdk.Dec  `json:"price"`
+		Rate     sdk.Dec  `json:"rate"`
+		USDPrice sdk.Dec  `json:"usd_price"`
+		Volume   sdk.Dec  `json:"volume"`
+		Weight   sdk.Dec  `json:"weight"`
+		Filtered string   `json:"filtered,omitempty"`
+	}
+)
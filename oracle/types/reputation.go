@@ -0,0 +1,18 @@
+package types
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// ProviderReputation defines how much the prices of a provider are trusted.
+// Weight is the static weight set by the config, Score the dynamic score
+// derived from Error, the smoothed relative distance of the provider prices
+// from the aggregated prices. Trust is the product of both and scales the
+// contribution of the provider to the aggregated prices.
+type ProviderReputation struct {
+	Weight       sdk.Dec `json:"weight"`
+	Score        sdk.Dec `json:"score"`
+	Error        sdk.Dec `json:"error"`
+	Trust        sdk.Dec `json:"trust"`
+	Observations uint64  `json:"observations"`
+}
@@ -7,10 +7,13 @@ import (
 )
 
 // TickerPrice defines price and volume information for a symbol or ticker exchange rate.
+// Confidence is the uncertainty of the price reported by the provider, e.g.
+// the confidence interval of Pyth, it is nil for providers without one.
 type TickerPrice struct {
-	Price  sdk.Dec   `json:"price"`  // last trade price
-	Volume sdk.Dec   `json:"volume"` // 24h volume
-	Time   time.Time `json:"time"`
+	Price      sdk.Dec   `json:"price"`  // last trade price
+	Volume     sdk.Dec   `json:"volume"` // 24h volume
+	Time       time.Time `json:"time"`
+	Confidence sdk.Dec   `json:"confidence"`
 }
 
 func NewTickerPrice(price string, volume string, timestamp time.Time) (TickerPrice, error) {
@@ -0,0 +1,135 @@
+package oracle
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+// weighVolumes prepares the USD notional volumes of the prices of a denom for
+// aggregation. Providers without volume get the median volume of the others
+// and the volume share of every provider is limited to the cap, if set.
+func weighVolumes(
+	tickers map[provider.Name]types.TickerPrice,
+	shareCap sdk.Dec,
+) map[provider.Name]types.TickerPrice {
+	return capVolumeShares(imputeVolumes(tickers), shareCap)
+}
+
+// imputeVolumes sets the volume of providers which report no volume, e.g.
+// DEX pools, to the median volume of the other providers. Otherwise their
+// prices would be ignored by volume based strategies as soon as they are
+// mixed with the prices of exchanges. If no provider reports a volume, all
+// volumes stay zero and the prices are weighted equally.
+func imputeVolumes(tickers map[provider.Name]types.TickerPrice) map[provider.Name]types.TickerPrice {
+	volumes := []sdk.Dec{}
+	missing := []provider.Name{}
+	for name, ticker := range tickers {
+		if provider.GetVolumeSpec(name).Unit == provider.VolumeNone {
+			missing = append(missing, name)
+			continue
+		}
+		if ticker.Volume.IsPositive() {
+			volumes = append(volumes, ticker.Volume)
+		}
+	}
+	if len(missing) == 0 || len(volumes) == 0 {
+		return tickers
+	}
+
+	median := medianDec(volumes)
+	imputed := make(map[provider.Name]types.TickerPrice, len(tickers))
+	for name, ticker := range tickers {
+		imputed[name] = ticker
+	}
+	for _, name := range missing {
+		ticker := imputed[name]
+		ticker.Volume = median
+		imputed[name] = ticker
+	}
+	return imputed
+}
+
+// capVolumeShares limits the share of every provider in the total volume to
+// the cap. Providers above the cap are cut to it and their excess is shared
+// by the other providers in proportion to their volumes, until no provider
+// exceeds the cap. If there are too few providers to meet the cap, all of
+// them get the same share. A nil cap or a cap of 1 keeps the volumes.
+func capVolumeShares(
+	tickers map[provider.Name]types.TickerPrice,
+	shareCap sdk.Dec,
+) map[provider.Name]types.TickerPrice {
+	if shareCap.IsNil() || !shareCap.IsPositive() || shareCap.GTE(sdk.OneDec()) {
+		return tickers
+	}
+
+	total := sdk.ZeroDec()
+	for _, ticker := range tickers {
+		total = total.Add(ticker.Volume)
+	}
+	if !total.IsPositive() {
+		return tickers
+	}
+
+	shares := make(map[provider.Name]sdk.Dec, len(tickers))
+	if shareCap.MulInt64(int64(len(tickers))).LT(sdk.OneDec()) {
+		for name := range tickers {
+			shares[name] = sdk.OneDec().QuoInt64(int64(len(tickers)))
+		}
+	} else {
+		capped := map[provider.Name]struct{}{}
+		for {
+			rest := sdk.OneDec().Sub(shareCap.MulInt64(int64(len(capped))))
+			uncapped := sdk.ZeroDec()
+			for name, ticker := range tickers {
+				if _, ok := capped[name]; !ok {
+					uncapped = uncapped.Add(ticker.Volume)
+				}
+			}
+			remaining := len(tickers) - len(capped)
+			if remaining == 0 {
+				break
+			}
+
+			exceeded := false
+			for name, ticker := range tickers {
+				if _, ok := capped[name]; ok {
+					shares[name] = shareCap
+					continue
+				}
+				share := rest.QuoInt64(int64(remaining))
+				if uncapped.IsPositive() {
+					share = rest.Mul(ticker.Volume).Quo(uncapped)
+				}
+				if share.GT(shareCap) {
+					capped[name] = struct{}{}
+					exceeded = true
+				}
+				shares[name] = share
+			}
+			if !exceeded {
+				break
+			}
+		}
+		if len(capped) == 0 {
+			return tickers
+		}
+	}
+
+	weighed := make(map[provider.Name]types.TickerPrice, len(tickers))
+	for name, ticker := range tickers {
+		ticker.Volume = shares[name].Mul(total)
+		weighed[name] = ticker
+	}
+	return weighed
+}
+
+// baseVolume returns the volume of the ticker in its base denom, i.e. its USD
+// notional volume divided by its USD price.
+func baseVolume(ticker types.TickerPrice) sdk.Dec {
+	if ticker.Price.IsZero() {
+		return sdk.ZeroDec()
+	}
+	return ticker.Volume.Quo(ticker.Price)
+}
@@ -0,0 +1,180 @@
+package oracle
+
+import (
+	"testing"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
+)
+
+func TestImputeVolumes(t *testing.T) {
+	imputed := imputeVolumes(map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance:   ticker("10", "1000"),
+		provider.ProviderKraken:    ticker("10", "3000"),
+		provider.ProviderKucoin:    ticker("10", "2000"),
+		provider.ProviderUniswapV3: ticker("10", "0"),
+	})
+	require.Equal(t, sdk.NewDec(2000), imputed[provider.ProviderUniswapV3].Volume)
+	require.Equal(t, sdk.NewDec(1000), imputed[provider.ProviderBinance].Volume)
+
+	// without any volume all prices count the same
+	imputed = imputeVolumes(map[provider.Name]types.TickerPrice{
+		provider.ProviderPyth:      ticker("10", "0"),
+		provider.ProviderUniswapV3: ticker("10", "0"),
+	})
+	require.True(t, imputed[provider.ProviderPyth].Volume.IsZero())
+	require.True(t, imputed[provider.ProviderUniswapV3].Volume.IsZero())
+}
+
+func TestCapVolumeShares(t *testing.T) {
+	testCases := []struct {
+		name     string
+		shareCap string
+		volumes  map[provider.Name]string
+		expected map[provider.Name]string
+	}{
+		{
+			"below cap",
+			"0.6",
+			map[provider.Name]string{
+				provider.ProviderBinance: "400",
+				provider.ProviderKraken:  "600",
+			},
+			map[provider.Name]string{
+				provider.ProviderBinance: "400",
+				provider.ProviderKraken:  "600",
+			},
+		},
+		{
+			"excess is shared",
+			"0.5",
+			map[provider.Name]string{
+				provider.ProviderBinance: "1000",
+				provider.ProviderKraken:  "100",
+				provider.ProviderOsmosis: "100",
+			},
+			map[provider.Name]string{
+				provider.ProviderBinance: "600",
+				provider.ProviderKraken:  "300",
+				provider.ProviderOsmosis: "300",
+			},
+		},
+		{
+			"excess caps others",
+			"0.3",
+			map[provider.Name]string{
+				provider.ProviderBinance: "1000",
+				provider.ProviderKraken:  "800",
+				provider.ProviderOsmosis: "100",
+				provider.ProviderKucoin:  "100",
+			},
+			map[provider.Name]string{
+				provider.ProviderBinance: "600",
+				provider.ProviderKraken:  "600",
+				provider.ProviderOsmosis: "400",
+				provider.ProviderKucoin:  "400",
+			},
+		},
+		{
+			"too few providers",
+			"0.4",
+			map[provider.Name]string{
+				provider.ProviderBinance: "900",
+				provider.ProviderKraken:  "100",
+			},
+			map[provider.Name]string{
+				provider.ProviderBinance: "500",
+				provider.ProviderKraken:  "500",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tickers := map[provider.Name]types.TickerPrice{}
+			for name, volume := range tc.volumes {
+				tickers[name] = ticker("10", volume)
+			}
+
+			capped := capVolumeShares(tickers, sdk.MustNewDecFromStr(tc.shareCap))
+			require.Len(t, capped, len(tc.expected))
+			for name, volume := range tc.expected {
+				require.Equal(t, sdk.MustNewDecFromStr(volume), capped[name].Volume, name)
+			}
+		})
+	}
+
+	// without a cap the volumes are kept
+	tickers := map[provider.Name]types.TickerPrice{
+		provider.ProviderBinance: ticker("10", "900"),
+		provider.ProviderKraken:  ticker("10", "100"),
+	}
+	require.Equal(t, tickers, capVolumeShares(tickers, sdk.Dec{}))
+	require.Equal(t, tickers, capVolumeShares(tickers, sdk.OneDec()))
+}
+
+func TestConvertTickersToUSD_Volumes(t *testing.T) {
+	atomUsd := types.CurrencyPair{Base: "ATOM", Quote: "USD"}
+	atomUsdt := types.CurrencyPair{Base: "ATOM", Quote: "USDT"}
+	usdtUsd := types.CurrencyPair{Base: "USDT", Quote: "USD"}
+	providerPrices := provider.AggregatedProviderPrices{
+		provider.ProviderBinance: {
+			"ATOMUSDT": ticker("10", "1000"),
+			"USDTUSD":  ticker("2", "1"),
+		},
+		provider.ProviderKraken: {
+			"ATOMUSD": ticker("30", "10"),
+			"USDTUSD": ticker("2", "1"),
+		},
+		provider.ProviderUniswapV3: {
+			"ATOMUSD": ticker("40", "0"),
+			"USDTUSD": ticker("2", "1"),
+		},
+	}
+	providerPairs := map[provider.Name][]types.CurrencyPair{
+		provider.ProviderBinance:   {atomUsdt, usdtUsd},
+		provider.ProviderKraken:    {atomUsd, usdtUsd},
+		provider.ProviderUniswapV3: {atomUsd, usdtUsd},
+	}
+	deviations := map[string]DeviationFilter{
+		"ATOM": {Threshold: sdk.NewDec(3)},
+		"USDT": {Threshold: sdk.NewDec(3)},
+	}
+	aggregators := map[string]Aggregator{"ATOM": LiquidityWeightedAggregator{}}
+
+	// binance trades a USD notional of 20000, kraken 300 and uniswap, which
+	// reports no volume, gets their median of 10150
+	rates, _, err := convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters: deviations,
+			aggregators:      aggregators,
+			now:              time.Now(),
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("26.765188834154351396"), rates["ATOM"])
+
+	// binance and uniswap are capped to a share of 0.4, kraken gets the rest
+	rates, _, err = convertTickersToUSD(
+		zerolog.Nop(),
+		providerPrices,
+		providerPairs,
+		conversionOptions{
+			deviationFilters: deviations,
+			aggregators:      aggregators,
+			volumeShareCap:   sdk.MustNewDecFromStr("0.4"),
+			now:              time.Now(),
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, sdk.MustNewDecFromStr("30"), rates["ATOM"])
+}
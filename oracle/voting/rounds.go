@@ -0,0 +1,58 @@
+package voting
+
+import (
+	"fmt"
+	"time"
+)
+
+// VoteRounds defines voting rounds aligned to the unix epoch, so that
+// independent feeders agree on the round a message belongs to. Round n
+// starts at n * Period. Within a round, the reveal of the previous round's
+// prevote is submitted at VoteOffset and the new prevote at PrevoteOffset.
+type VoteRounds struct {
+	Period        time.Duration
+	PrevoteOffset time.Duration
+	VoteOffset    time.Duration
+}
+
+// NewVoteRounds returns the voting rounds of the given period. Both offsets
+// must fall within the period and the vote must not be after the prevote.
+func NewVoteRounds(period, prevoteOffset, voteOffset time.Duration) (VoteRounds, error) {
+	if period <= 0 {
+		return VoteRounds{}, fmt.Errorf("vote period must be positive")
+	}
+	if prevoteOffset < 0 || prevoteOffset >= period {
+		return VoteRounds{}, fmt.Errorf("prevote offset must be within the vote period")
+	}
+	if voteOffset < 0 || voteOffset >= period {
+		return VoteRounds{}, fmt.Errorf("vote offset must be within the vote period")
+	}
+	if voteOffset > prevoteOffset {
+		return VoteRounds{}, fmt.Errorf("vote offset must not be after prevote offset")
+	}
+	return VoteRounds{
+		Period:        period,
+		PrevoteOffset: prevoteOffset,
+		VoteOffset:    voteOffset,
+	}, nil
+}
+
+// Round returns the index of the round t falls into, floor(unix / period).
+func (r VoteRounds) Round(t time.Time) uint64 {
+	return uint64(t.UnixNano() / int64(r.Period))
+}
+
+// Start returns the time the given round starts at.
+func (r VoteRounds) Start(round uint64) time.Time {
+	return time.Unix(0, int64(round)*int64(r.Period))
+}
+
+// Offset returns the time elapsed since the start of the round t falls into.
+func (r VoteRounds) Offset(t time.Time) time.Duration {
+	return t.Sub(r.Start(r.Round(t)))
+}
+
+// End returns the time the round t falls into closes.
+func (r VoteRounds) End(t time.Time) time.Time {
+	return r.Start(r.Round(t) + 1)
+}
@@ -0,0 +1,37 @@
+package voting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVoteRounds(t *testing.T) {
+	_, err := NewVoteRounds(0, 0, 0)
+	require.Error(t, err)
+
+	_, err = NewVoteRounds(10*time.Second, 10*time.Second, 0)
+	require.Error(t, err)
+
+	_, err = NewVoteRounds(10*time.Second, time.Second, 2*time.Second)
+	require.Error(t, err)
+
+	_, err = NewVoteRounds(10*time.Second, 2*time.Second, time.Second)
+	require.NoError(t, err)
+}
+
+func TestVoteRounds(t *testing.T) {
+	rounds, err := NewVoteRounds(30*time.Second, 0, 0)
+	require.NoError(t, err)
+
+	now := time.Unix(1700000015, 500)
+	require.Equal(t, uint64(56666667), rounds.Round(now))
+	require.Equal(t, time.Unix(1700000010, 0), rounds.Start(rounds.Round(now)))
+	require.Equal(t, 5*time.Second+500, rounds.Offset(now))
+	require.Equal(t, time.Unix(1700000040, 0), rounds.End(now))
+
+	// independent of the local time zone and monotonic clock
+	require.Equal(t, rounds.Round(now), rounds.Round(now.UTC()))
+	require.Equal(t, rounds.Round(now)+1, rounds.Round(now.Add(25*time.Second)))
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
 	"time"
 
@@ -70,3 +71,19 @@ func AddCORSMiddleware(mChain alice.Chain, logger zerolog.Logger, cfg config.Con
 
 	return mChain
 }
+
+// AddLocalOnlyMiddleware appends middleware to a provided middleware chain
+// which rejects all requests not sent from a loopback address, e.g. for
+// admin endpoints, as the server listens on all interfaces by default.
+func AddLocalOnlyMiddleware(mChain alice.Chain) alice.Chain {
+	return mChain.Append(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil || !net.ParseIP(host).IsLoopback() {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+}
@@ -1,13 +1,27 @@
 package v1
 
 import (
+	"context"
 	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/types"
 )
 
 // Oracle defines the Oracle interface contract that the v1 router depends on.
 type Oracle interface {
 	GetLastPriceSyncTimestamp() time.Time
 	GetPrices() sdk.DecCoins
+	GetQuotedPrices(quote string) (sdk.DecCoins, sdk.DecCoins, error)
+	GetProviderStatus() map[provider.Name]provider.Status
+	GetPriceAudits(denom string, limit int) ([]types.PriceAudit, error)
+	GetProviderReputation() map[provider.Name]types.ProviderReputation
+}
+
+// Reloader defines the interface the v1 router depends on to reload the
+// config of the running price feeder.
+type Reloader interface {
+	Reload(context.Context) error
 }
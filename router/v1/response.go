@@ -5,11 +5,16 @@ import (
 	"net/http"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/tracker"
+	"price-feeder/oracle/types"
 )
 
 // Response constants
 const (
 	StatusAvailable = "available"
+	StatusReloaded  = "reloaded"
 )
 
 type (
@@ -22,9 +27,39 @@ type (
 	}
 
 	// PricesResponse defines the response type for getting the latest exchange
-	// rates from the oracle.
+	// rates from the oracle and the confidence of each of them.
 	PricesResponse struct {
-		Prices map[string]sdk.Dec `json:"prices"`
+		Prices      map[string]sdk.Dec `json:"prices"`
+		Confidences map[string]sdk.Dec `json:"confidences"`
+	}
+
+	// ProvidersResponse defines the response type for getting the
+	// initialization status of all providers.
+	ProvidersResponse struct {
+		Providers map[provider.Name]provider.Status `json:"providers"`
+	}
+
+	// ReputationResponse defines the response type for getting the weights
+	// and reputation scores of all providers.
+	ReputationResponse struct {
+		Providers map[provider.Name]types.ProviderReputation `json:"providers"`
+	}
+
+	// AuditResponse defines the response type for getting the latest
+	// explanations of the computed prices of a denom.
+	AuditResponse struct {
+		Audits []types.PriceAudit `json:"audits"`
+	}
+
+	// ReloadResponse defines the response type for reloading the config.
+	ReloadResponse struct {
+		Status string `json:"status"`
+	}
+
+	// SubmissionsResponse defines the response type for getting the
+	// confirmation state of the latest topic message submissions.
+	SubmissionsResponse struct {
+		Submissions []tracker.Submission `json:"submissions"`
 	}
 )
 
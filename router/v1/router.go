@@ -3,6 +3,7 @@ package v1
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,22 +18,36 @@ import (
 
 const (
 	APIPathPrefix = "/api/v1"
+
+	defaultAuditLimit = 10
+	maxAuditLimit     = 1000
 )
 
 // Router defines a router wrapper used for registering v1 API routes.
 type Router struct {
-	logger  zerolog.Logger
-	cfg     config.Config
-	oracle  Oracle
-	metrics Metrics
+	logger   zerolog.Logger
+	cfg      config.Config
+	oracle   Oracle
+	metrics  Metrics
+	tracker  Tracker
+	reloader Reloader
 }
 
-func New(logger zerolog.Logger, cfg config.Config, oracle Oracle, metrics Metrics) *Router {
+func New(
+	logger zerolog.Logger,
+	cfg config.Config,
+	oracle Oracle,
+	metrics Metrics,
+	tracker Tracker,
+	reloader Reloader,
+) *Router {
 	return &Router{
-		logger:  logger.With().Str("module", "router").Logger(),
-		cfg:     cfg,
-		oracle:  oracle,
-		metrics: metrics,
+		logger:   logger.With().Str("module", "router").Logger(),
+		cfg:      cfg,
+		oracle:   oracle,
+		metrics:  metrics,
+		tracker:  tracker,
+		reloader: reloader,
 	}
 }
 
@@ -70,6 +85,34 @@ func (r *Router) RegisterRoutes(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.pricesHandler()),
 	).Methods(httputil.MethodGET)
 
+	v1Router.Handle(
+		"/providers",
+		mChain.ThenFunc(r.providersHandler()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/reputation",
+		mChain.ThenFunc(r.reputationHandler()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/audit/{denom}",
+		mChain.ThenFunc(r.auditHandler()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/submissions",
+		mChain.ThenFunc(r.submissionsHandler()),
+	).Methods(httputil.MethodGET)
+
+	if r.cfg.Server.EnableAdmin && r.reloader != nil {
+		adminChain := middleware.AddLocalOnlyMiddleware(mChain)
+		v1Router.Handle(
+			"/admin/reload",
+			adminChain.ThenFunc(r.reloadHandler()),
+		).Methods(httputil.MethodPOST)
+	}
+
 	if r.cfg.Telemetry.Enabled {
 		v1Router.Handle(
 			"/metrics",
@@ -92,18 +135,98 @@ func (r *Router) healthzHandler() http.HandlerFunc {
 
 func (r *Router) pricesHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		prices := make(map[string]sdk.Dec, len(r.oracle.GetPrices()))
-		for _, price := range r.oracle.GetPrices() {
+		quote := req.URL.Query().Get("quote")
+		if quote == "" {
+			quote = config.DenomUSD
+		}
+		quoted, quotedConfidences, err := r.oracle.GetQuotedPrices(quote)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		prices := make(map[string]sdk.Dec, len(quoted))
+		for _, price := range quoted {
 			prices[price.Denom] = price.Amount
 		}
+		confidences := make(map[string]sdk.Dec, len(quotedConfidences))
+		for _, confidence := range quotedConfidences {
+			confidences[confidence.Denom] = confidence.Amount
+		}
 		resp := PricesResponse{
-			Prices: prices,
+			Prices:      prices,
+			Confidences: confidences,
 		}
 
 		httputil.RespondWithJSON(w, http.StatusOK, resp)
 	}
 }
 
+func (r *Router) providersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		resp := ProvidersResponse{
+			Providers: r.oracle.GetProviderStatus(),
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (r *Router) reputationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		resp := ReputationResponse{
+			Providers: r.oracle.GetProviderReputation(),
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (r *Router) auditHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		denom := strings.ToUpper(mux.Vars(req)["denom"])
+
+		limit := defaultAuditLimit
+		if s := strings.TrimSpace(req.FormValue("limit")); s != "" {
+			var err error
+			limit, err = strconv.Atoi(s)
+			if err != nil || limit < 1 || limit > maxAuditLimit {
+				writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid limit: %s", s))
+				return
+			}
+		}
+
+		audits, err := r.oracle.GetPriceAudits(denom, limit)
+		if err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to get price audits: %s", err))
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, AuditResponse{Audits: audits})
+	}
+}
+
+func (r *Router) submissionsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		resp := SubmissionsResponse{
+			Submissions: r.tracker.GetSubmissions(),
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (r *Router) reloadHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := r.reloader.Reload(req.Context()); err != nil {
+			writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("failed to reload config: %s", err))
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, ReloadResponse{Status: StatusReloaded})
+	}
+}
+
 func (r *Router) metricsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		format := strings.TrimSpace(req.FormValue("format"))
@@ -1,7 +1,9 @@
 package v1_test
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -13,18 +15,26 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"price-feeder/config"
+	"price-feeder/oracle/provider"
+	"price-feeder/oracle/tracker"
+	"price-feeder/oracle/types"
 	v1 "price-feeder/router/v1"
 
 	"github.com/cosmos/cosmos-sdk/telemetry"
 )
 
 var (
-	_ v1.Oracle = (*mockOracle)(nil)
+	_ v1.Oracle   = (*mockOracle)(nil)
+	_ v1.Tracker  = (*mockTracker)(nil)
+	_ v1.Reloader = (*mockReloader)(nil)
 
 	mockPrices = sdk.DecCoins{
 		sdk.NewDecCoinFromDec("ATOM", sdk.MustNewDecFromStr("34.84")),
 		sdk.NewDecCoinFromDec("UMEE", sdk.MustNewDecFromStr("4.21")),
 	}
+	mockConfidences = sdk.DecCoins{
+		sdk.NewDecCoinFromDec("ATOM", sdk.MustNewDecFromStr("0.02")),
+	}
 )
 
 type mockOracle struct{}
@@ -37,6 +47,75 @@ func (m mockOracle) GetPrices() sdk.DecCoins {
 	return mockPrices
 }
 
+func (m mockOracle) GetQuotedPrices(quote string) (sdk.DecCoins, sdk.DecCoins, error) {
+	switch quote {
+	case config.DenomUSD:
+		return mockPrices, mockConfidences, nil
+	case "ATOM":
+		return sdk.DecCoins{
+			sdk.NewDecCoinFromDec("UMEE/ATOM", mockPrices.AmountOf("UMEE").Quo(mockPrices.AmountOf("ATOM"))),
+		}, sdk.DecCoins{}, nil
+	}
+	return nil, nil, fmt.Errorf("no price for quote: %s", quote)
+}
+
+func (m mockOracle) GetProviderStatus() map[provider.Name]provider.Status {
+	return map[provider.Name]provider.Status{
+		provider.ProviderBinance: {State: provider.StateReady, Attempts: 1},
+		provider.ProviderKraken:  {State: provider.StateFailed, Error: "unreachable", Attempts: 2},
+	}
+}
+
+func (m mockOracle) GetPriceAudits(denom string, limit int) ([]types.PriceAudit, error) {
+	if denom != "ATOM" {
+		return []types.PriceAudit{}, nil
+	}
+	audits := []types.PriceAudit{
+		{
+			Denom: "ATOM",
+			Price: sdk.MustNewDecFromStr("34.84"),
+			Sources: []types.AuditSource{
+				{Provider: provider.ProviderBinance.String(), Weight: sdk.OneDec()},
+				{Provider: provider.ProviderKraken.String(), Weight: sdk.ZeroDec(), Filtered: "deviating price"},
+			},
+		},
+		{Denom: "ATOM", Error: "not enough tickers"},
+	}
+	if limit < len(audits) {
+		audits = audits[:limit]
+	}
+	return audits, nil
+}
+
+func (m mockOracle) GetProviderReputation() map[provider.Name]types.ProviderReputation {
+	return map[provider.Name]types.ProviderReputation{
+		provider.ProviderBinance: {
+			Weight:       sdk.OneDec(),
+			Score:        sdk.MustNewDecFromStr("0.5"),
+			Error:        sdk.MustNewDecFromStr("0.01"),
+			Trust:        sdk.MustNewDecFromStr("0.5"),
+			Observations: 3,
+		},
+	}
+}
+
+type mockTracker struct{}
+
+func (m mockTracker) GetSubmissions() []tracker.Submission {
+	return []tracker.Submission{
+		{TransactionID: "0.0.1-1700000000-000000001", Status: tracker.StatusConfirmed, SequenceNumber: 7},
+	}
+}
+
+type mockReloader struct {
+	reloads int
+}
+
+func (m *mockReloader) Reload(context.Context) error {
+	m.reloads++
+	return nil
+}
+
 type mockMetrics struct{}
 
 func (mockMetrics) Gather(format string) (telemetry.GatherResponse, error) {
@@ -46,8 +125,9 @@ func (mockMetrics) Gather(format string) (telemetry.GatherResponse, error) {
 type RouterTestSuite struct {
 	suite.Suite
 
-	mux    *mux.Router
-	router *v1.Router
+	mux      *mux.Router
+	router   *v1.Router
+	reloader *mockReloader
 }
 
 // SetupSuite executes once before the suite's tests are executed.
@@ -57,14 +137,17 @@ func (rts *RouterTestSuite) SetupSuite() {
 		Server: config.Server{
 			AllowedOrigins: []string{},
 			VerboseCORS:    false,
+			EnableAdmin:    true,
 		},
 	}
 
-	r := v1.New(zerolog.Nop(), cfg, mockOracle{}, mockMetrics{})
+	reloader := &mockReloader{}
+	r := v1.New(zerolog.Nop(), cfg, mockOracle{}, mockMetrics{}, mockTracker{}, reloader)
 	r.RegisterRoutes(mux, v1.APIPathPrefix)
 
 	rts.mux = mux
 	rts.router = r
+	rts.reloader = reloader
 }
 
 func TestServiceTestSuite(t *testing.T) {
@@ -102,4 +185,124 @@ func (rts *RouterTestSuite) TestPrices() {
 	rts.Require().Equal(respBody.Prices["ATOM"], mockPrices.AmountOf("ATOM"))
 	rts.Require().Equal(respBody.Prices["UMEE"], mockPrices.AmountOf("UMEE"))
 	rts.Require().Equal(respBody.Prices["FOO"], sdk.Dec{})
+	rts.Require().Equal(respBody.Confidences["ATOM"], mockConfidences.AmountOf("ATOM"))
+}
+
+func (rts *RouterTestSuite) TestPrices_Quote() {
+	req, err := http.NewRequest("GET", "/api/v1/prices?quote=ATOM", nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+
+	var respBody v1.PricesResponse
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Len(respBody.Prices, 1)
+	rts.Require().Equal(sdk.MustNewDecFromStr("0.120838117106773823"), respBody.Prices["UMEE/ATOM"])
+
+	req, err = http.NewRequest("GET", "/api/v1/prices?quote=FOO", nil)
+	rts.Require().NoError(err)
+	rts.Require().Equal(http.StatusBadRequest, rts.executeRequest(req).Code)
+}
+
+func (rts *RouterTestSuite) TestProviders() {
+	req, err := http.NewRequest("GET", "/api/v1/providers", nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+
+	var respBody v1.ProvidersResponse
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Len(respBody.Providers, 2)
+	rts.Require().Equal(provider.StateReady, respBody.Providers[provider.ProviderBinance].State)
+	rts.Require().Equal(provider.StateFailed, respBody.Providers[provider.ProviderKraken].State)
+	rts.Require().Equal("unreachable", respBody.Providers[provider.ProviderKraken].Error)
+}
+
+func (rts *RouterTestSuite) TestReputation() {
+	req, err := http.NewRequest("GET", "/api/v1/reputation", nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+
+	var respBody v1.ReputationResponse
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Len(respBody.Providers, 1)
+	rts.Require().Equal(sdk.MustNewDecFromStr("0.5"), respBody.Providers[provider.ProviderBinance].Trust)
+	rts.Require().Equal(uint64(3), respBody.Providers[provider.ProviderBinance].Observations)
+}
+
+func (rts *RouterTestSuite) TestAudit() {
+	req, err := http.NewRequest("GET", "/api/v1/audit/atom", nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+
+	var respBody v1.AuditResponse
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Len(respBody.Audits, 2)
+	rts.Require().Equal("34.840000000000000000", respBody.Audits[0].Price.String())
+	rts.Require().Equal("deviating price", respBody.Audits[0].Sources[1].Filtered)
+	rts.Require().Equal("not enough tickers", respBody.Audits[1].Error)
+
+	req, err = http.NewRequest("GET", "/api/v1/audit/ATOM?limit=1", nil)
+	rts.Require().NoError(err)
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Len(respBody.Audits, 1)
+
+	req, err = http.NewRequest("GET", "/api/v1/audit/ATOM?limit=abc", nil)
+	rts.Require().NoError(err)
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusBadRequest, response.Code)
+}
+
+func (rts *RouterTestSuite) TestSubmissions() {
+	req, err := http.NewRequest("GET", "/api/v1/submissions", nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+
+	var respBody v1.SubmissionsResponse
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Len(respBody.Submissions, 1)
+	rts.Require().Equal(tracker.StatusConfirmed, respBody.Submissions[0].Status)
+	rts.Require().Equal(uint64(7), respBody.Submissions[0].SequenceNumber)
+}
+
+func (rts *RouterTestSuite) TestReload() {
+	req, err := http.NewRequest("GET", "/api/v1/admin/reload", nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusMethodNotAllowed, response.Code)
+	rts.Require().Zero(rts.reloader.reloads)
+
+	// admin endpoints are only served to local clients
+	req, err = http.NewRequest("POST", "/api/v1/admin/reload", nil)
+	rts.Require().NoError(err)
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusForbidden, response.Code)
+	rts.Require().Zero(rts.reloader.reloads)
+
+	req, err = http.NewRequest("POST", "/api/v1/admin/reload", nil)
+	rts.Require().NoError(err)
+	req.RemoteAddr = "127.0.0.1:1234"
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code)
+
+	var respBody v1.ReloadResponse
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &respBody))
+	rts.Require().Equal(v1.StatusReloaded, respBody.Status)
+	rts.Require().Equal(1, rts.reloader.reloads)
 }
@@ -0,0 +1,9 @@
+package v1
+
+import "price-feeder/oracle/tracker"
+
+// Tracker defines the submission tracker interface contract that the v1
+// router depends on.
+type Tracker interface {
+	GetSubmissions() []tracker.Submission
+}